@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/hybrid"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+)
+
+func main() {
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+
+	encInput := encryptCmd.String("input", "", "Input file to encrypt")
+	encOutput := encryptCmd.String("output", "", "Output file (default: input.enc)")
+	encKey := encryptCmd.String("key", "rsa_keys", "Recipient key file (demo8 or demo9, without .pub)")
+	encAlg := encryptCmd.String("alg", "rsa", "Key algorithm: rsa or elgamal")
+	encDEM := encryptCmd.String("dem", "aes-gcm", "Symmetric cipher: aes-gcm or kuznyechik-mgm")
+
+	decInput := decryptCmd.String("input", "", "Input file to decrypt")
+	decOutput := decryptCmd.String("output", "", "Output file (default: input without .enc)")
+	decKey := decryptCmd.String("key", "rsa_keys", "Private key file (demo8 or demo9, without .priv)")
+	decAlg := decryptCmd.String("alg", "rsa", "Key algorithm: rsa or elgamal")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		if *encInput == "" {
+			fmt.Println("Error: input file is required")
+			encryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *encOutput == "" {
+			*encOutput = *encInput + ".enc"
+		}
+		encryptFile(*encInput, *encOutput, *encKey, *encAlg, *encDEM)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		if *decInput == "" {
+			fmt.Println("Error: input file is required")
+			decryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *decOutput == "" {
+			*decOutput = strings.TrimSuffix(*decInput, ".enc")
+			if *decOutput == *decInput {
+				*decOutput = *decInput + ".dec"
+			}
+		}
+		decryptFile(*decInput, *decOutput, *decKey, *decAlg)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  encrypt - encrypt a file to a recipient's RSA or ElGamal public key")
+	fmt.Println("  decrypt - decrypt a file with the matching private key")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func encryptFile(inputFile, outputFile, keyFile, alg, demName string) {
+	var dem hybrid.DEM
+	switch demName {
+	case "aes-gcm":
+		dem = hybrid.DEMAESGCM
+	case "kuznyechik-mgm":
+		dem = hybrid.DEMKuznyechikMGM
+	default:
+		log.Fatalf("Unknown symmetric cipher: %s", demName)
+	}
+
+	var wrapper hybrid.Wrapper
+	switch alg {
+	case "rsa":
+		nums, err := loadNumbers(keyFile+".pub", 2)
+		if err != nil {
+			log.Fatalf("Error loading public key: %v", err)
+		}
+		wrapper = hybrid.RSAWrapper{N: nums[0], D: nums[1]}
+	case "elgamal":
+		nums, err := loadNumbers(keyFile+".pub", 3)
+		if err != nil {
+			log.Fatalf("Error loading public key: %v", err)
+		}
+		wrapper = hybrid.ElGamalWrapper{P: nums[0], G: nums[1], Y: nums[2]}
+	default:
+		log.Fatalf("Unknown key algorithm: %s", alg)
+	}
+
+	fmt.Printf("Encrypting %s with %s + %s...\n", inputFile, alg, dem)
+	err := hybrid.EncryptFile(inputFile, outputFile, wrapper, dem)
+	if err != nil {
+		log.Fatalf("Error encrypting file: %v", err)
+	}
+	fmt.Printf("Encrypted file saved to: %s\n", outputFile)
+}
+
+func decryptFile(inputFile, outputFile, keyFile, alg string) {
+	var unwrapper hybrid.Unwrapper
+	switch alg {
+	case "rsa":
+		nums, err := loadNumbers(keyFile+".priv", 2)
+		if err != nil {
+			log.Fatalf("Error loading private key: %v", err)
+		}
+		unwrapper = hybrid.RSAUnwrapper{N: nums[0], C: nums[1]}
+	case "elgamal":
+		priv, err := loadNumbers(keyFile+".priv", 1)
+		if err != nil {
+			log.Fatalf("Error loading private key: %v", err)
+		}
+		pub, err := loadNumbers(keyFile+".pub", 3)
+		if err != nil {
+			log.Fatalf("Error loading public key: %v", err)
+		}
+		unwrapper = hybrid.ElGamalUnwrapper{P: pub[0], X: priv[0]}
+	default:
+		log.Fatalf("Unknown key algorithm: %s", alg)
+	}
+
+	fmt.Printf("Decrypting %s...\n", inputFile)
+	err := hybrid.DecryptFile(inputFile, outputFile, unwrapper)
+	if err != nil {
+		log.Fatalf("Error decrypting file: %v", err)
+	}
+	fmt.Printf("Decrypted file saved to: %s\n", outputFile)
+}
+
+// Key files from demo8 and demo9 are decimal numbers, one per line.
+func loadNumbers(filename string, count int) ([]*big.Int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d numbers in %s, got %d", count, filename, len(fields))
+	}
+
+	nums := make([]*big.Int, count)
+	for i := range nums {
+		n, ok := new(big.Int).SetString(fields[i], 10)
+		if !ok {
+			return nil, fmt.Errorf("error while reading number %d in %s", i+1, filename)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/attack"
+	"information-defending/internal/container"
+	"information-defending/internal/rsa"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	setupCmd := flag.NewFlagSet("setup", flag.ExitOnError)
+	commonCmd := flag.NewFlagSet("common-modulus", flag.ExitOnError)
+	hastadCmd := flag.NewFlagSet("hastad", flag.ExitOnError)
+	cubeCmd := flag.NewFlagSet("cube-root", flag.ExitOnError)
+	batchCmd := flag.NewFlagSet("batch-gcd", flag.ExitOnError)
+	wienerCmd := flag.NewFlagSet("wiener", flag.ExitOnError)
+
+	setupAttack := setupCmd.String("attack", "", "Attack to prepare: common-modulus, hastad, cube-root, batch-gcd or wiener")
+	setupInput := setupCmd.String("input", "", "Secret message file to encrypt")
+	setupDir := setupCmd.String("dir", "lab", "Directory for generated keys and ciphertexts")
+
+	commonC1 := commonCmd.String("c1", "", "First ciphertext (rsa.EncryptFile output)")
+	commonC2 := commonCmd.String("c2", "", "Second ciphertext under the same N")
+	commonKey1 := commonCmd.String("key1", "", "First public key file (.pub)")
+	commonKey2 := commonCmd.String("key2", "", "Second public key file (.pub)")
+	commonOutput := commonCmd.String("output", "recovered.txt", "Output file")
+
+	hastadC := hastadCmd.String("c", "", "Comma-separated ciphertexts of one message")
+	hastadKeys := hastadCmd.String("key", "", "Comma-separated public key files, in the same order")
+	hastadOutput := hastadCmd.String("output", "recovered.txt", "Output file")
+
+	cubeC := cubeCmd.String("c", "", "Ciphertext under a small exponent")
+	cubeKey := cubeCmd.String("key", "", "Public key file (.pub)")
+	cubeMaxK := cubeCmd.Int("k", 1000, "Try c + k*N for k below this bound")
+	cubeOutput := cubeCmd.String("output", "recovered.txt", "Output file")
+
+	batchDir := batchCmd.String("dir", ".", "Directory with .pub files")
+
+	wienerKey := wienerCmd.String("key", "", "Public key file (.pub)")
+	wienerC := wienerCmd.String("c", "", "Ciphertext to decrypt with the recovered key (optional)")
+	wienerOutput := wienerCmd.String("output", "recovered.txt", "Output file")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "setup":
+		setupCmd.Parse(os.Args[2:])
+		if *setupAttack == "" || (*setupInput == "" && *setupAttack != "batch-gcd") {
+			fmt.Println("Error: attack and input file are required")
+			setupCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		setup(*setupAttack, *setupInput, *setupDir)
+	case "common-modulus":
+		commonCmd.Parse(os.Args[2:])
+		if *commonC1 == "" || *commonC2 == "" || *commonKey1 == "" || *commonKey2 == "" {
+			fmt.Println("Error: two ciphertexts and two keys are required")
+			commonCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		commonModulus(*commonC1, *commonC2, *commonKey1, *commonKey2, *commonOutput)
+	case "hastad":
+		hastadCmd.Parse(os.Args[2:])
+		if *hastadC == "" || *hastadKeys == "" {
+			fmt.Println("Error: ciphertexts and keys are required")
+			hastadCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		hastad(strings.Split(*hastadC, ","), strings.Split(*hastadKeys, ","), *hastadOutput)
+	case "cube-root":
+		cubeCmd.Parse(os.Args[2:])
+		if *cubeC == "" || *cubeKey == "" {
+			fmt.Println("Error: ciphertext and key are required")
+			cubeCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		cubeRoot(*cubeC, *cubeKey, *cubeMaxK, *cubeOutput)
+	case "batch-gcd":
+		batchCmd.Parse(os.Args[2:])
+		batchGCD(*batchDir)
+	case "wiener":
+		wienerCmd.Parse(os.Args[2:])
+		if *wienerKey == "" {
+			fmt.Println("Error: key is required")
+			wienerCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		wiener(*wienerKey, *wienerC, *wienerOutput)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  setup          - generate vulnerable keys and ciphertexts for an attack")
+	fmt.Println("  common-modulus - decrypt one message encrypted under the same N with two exponents")
+	fmt.Println("  hastad         - decrypt one message broadcast to e recipients with exponent e")
+	fmt.Println("  cube-root      - decrypt an unpadded message under a small exponent")
+	fmt.Println("  batch-gcd      - find RSA moduli that share a prime")
+	fmt.Println("  wiener         - recover a small private exponent from the public key")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func setup(name, inputFile, dir string) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Fatalf("Error creating directory: %v", err)
+	}
+	path := func(file string) string { return filepath.Join(dir, file) }
+
+	switch name {
+	case "common-modulus":
+		a, b, err := attack.SharedModulusKeys(2048, 65537, 17)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+		saveAndEncrypt(inputFile, path("a"), a)
+		saveAndEncrypt(inputFile, path("b"), b)
+		fmt.Printf("Run: common-modulus -c1 %s -c2 %s -key1 %s -key2 %s\n",
+			path("a.enc"), path("b.enc"), path("a.pub"), path("b.pub"))
+	case "hastad":
+		keys, err := attack.BroadcastKeys(2048, 3, 3)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+		var cs, pubs []string
+		for i, k := range keys {
+			base := path(fmt.Sprintf("k%d", i+1))
+			saveAndEncrypt(inputFile, base, k)
+			cs = append(cs, base+".enc")
+			pubs = append(pubs, base+".pub")
+		}
+		fmt.Printf("Run: hastad -c %s -key %s\n", strings.Join(cs, ","), strings.Join(pubs, ","))
+	case "cube-root":
+		k, err := rsa.GenerateKeysWithExponent(2048, 3)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+		saveAndEncrypt(inputFile, path("k"), k)
+		fmt.Printf("Run: cube-root -c %s -key %s\n", path("k.enc"), path("k.pub"))
+	case "batch-gcd":
+		weak, err := attack.SharedPrimeKeys(1024, 3)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+		for i := range 5 {
+			k, err := rsa.GenerateKeysWithExponent(1024, 65537)
+			if err != nil {
+				log.Fatalf("Error generating keys: %v", err)
+			}
+			weak = append(weak, k)
+			if i < 2 {
+				weak[i], weak[len(weak)-1] = weak[len(weak)-1], weak[i]
+			}
+		}
+		for i, k := range weak {
+			err := rsa.WritePublicKeyFile(path(fmt.Sprintf("key%02d.pub", i)), k)
+			if err != nil {
+				log.Fatalf("Error saving key: %v", err)
+			}
+		}
+		fmt.Printf("Run: batch-gcd -dir %s\n", dir)
+	case "wiener":
+		k, err := attack.WienerKeys(2048)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+		saveAndEncrypt(inputFile, path("k"), k)
+		fmt.Printf("Run: wiener -key %s -c %s\n", path("k.pub"), path("k.enc"))
+	default:
+		log.Fatalf("Unknown attack: %s", name)
+	}
+}
+
+func saveAndEncrypt(inputFile, base string, k rsa.Keys) {
+	err := rsa.WritePublicKeyFile(base+".pub", k)
+	if err != nil {
+		log.Fatalf("Error saving key: %v", err)
+	}
+	err = rsa.EncryptFile(inputFile, base+".enc", k.D, k.N)
+	if err != nil {
+		log.Fatalf("Error encrypting file: %v", err)
+	}
+	fmt.Printf("Key %s.pub (e = %s), ciphertext %s.enc\n", base, k.D, base)
+}
+
+func readBlocks(filename string) ([]*big.Int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cr, err := container.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Header().Algorithm != container.AlgRSA {
+		return nil, fmt.Errorf("%s is not an RSA ciphertext", filename)
+	}
+
+	var blocks []*big.Int
+	for {
+		block, err := cr.ReadBlock()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, new(big.Int).SetBytes(block))
+	}
+}
+
+// rsa.EncryptFile encrypts byte by byte, so every recovered block is one byte.
+func writeRecovered(outputFile string, ms []*big.Int) {
+	out := make([]byte, len(ms))
+	for i, m := range ms {
+		if !m.IsInt64() || m.Int64() > 255 {
+			log.Fatalf("Block %d recovered to %s, which is not a byte", i, m)
+		}
+		out[i] = byte(m.Int64())
+	}
+
+	err := os.WriteFile(outputFile, out, 0644)
+	if err != nil {
+		log.Fatalf("Error writing output: %v", err)
+	}
+	fmt.Printf("Recovered %d bytes to %s\n", len(out), outputFile)
+}
+
+func commonModulus(c1File, c2File, key1File, key2File, outputFile string) {
+	k1, err := rsa.ReadPublicKeyFile(key1File)
+	if err != nil {
+		log.Fatalf("Error loading key: %v", err)
+	}
+	k2, err := rsa.ReadPublicKeyFile(key2File)
+	if err != nil {
+		log.Fatalf("Error loading key: %v", err)
+	}
+	if k1.N.Cmp(k2.N) != 0 {
+		log.Fatalf("Keys have different moduli, the attack does not apply")
+	}
+
+	c1, err := readBlocks(c1File)
+	if err != nil {
+		log.Fatalf("Error reading ciphertext: %v", err)
+	}
+	c2, err := readBlocks(c2File)
+	if err != nil {
+		log.Fatalf("Error reading ciphertext: %v", err)
+	}
+	if len(c1) != len(c2) {
+		log.Fatalf("Ciphertexts have different lengths: %d and %d blocks", len(c1), len(c2))
+	}
+
+	ms := make([]*big.Int, len(c1))
+	for i := range c1 {
+		ms[i], err = attack.CommonModulus(c1[i], c2[i], k1.D, k2.D, k1.N)
+		if err != nil {
+			log.Fatalf("Block %d: %v", i, err)
+		}
+	}
+	writeRecovered(outputFile, ms)
+}
+
+func hastad(cFiles, keyFiles []string, outputFile string) {
+	if len(cFiles) != len(keyFiles) {
+		log.Fatalf("Got %d ciphertexts and %d keys", len(cFiles), len(keyFiles))
+	}
+
+	var moduli []*big.Int
+	var e *big.Int
+	cs := make([][]*big.Int, len(cFiles))
+	for i := range cFiles {
+		k, err := rsa.ReadPublicKeyFile(keyFiles[i])
+		if err != nil {
+			log.Fatalf("Error loading key: %v", err)
+		}
+		if e != nil && e.Cmp(k.D) != 0 {
+			log.Fatalf("All recipients must share the exponent, got %s and %s", e, k.D)
+		}
+		e = k.D
+		moduli = append(moduli, k.N)
+
+		cs[i], err = readBlocks(cFiles[i])
+		if err != nil {
+			log.Fatalf("Error reading ciphertext: %v", err)
+		}
+		if len(cs[i]) != len(cs[0]) {
+			log.Fatalf("Ciphertexts have different lengths")
+		}
+	}
+	if !e.IsInt64() || e.Int64() > int64(len(cFiles)) {
+		log.Fatalf("Need at least e = %s ciphertexts, got %d", e, len(cFiles))
+	}
+
+	ms := make([]*big.Int, len(cs[0]))
+	for j := range ms {
+		column := make([]*big.Int, len(cs))
+		for i := range cs {
+			column[i] = cs[i][j]
+		}
+		m, err := attack.Hastad(column, moduli, int(e.Int64()))
+		if err != nil {
+			log.Fatalf("Block %d: %v", j, err)
+		}
+		ms[j] = m
+	}
+	writeRecovered(outputFile, ms)
+}
+
+func cubeRoot(cFile, keyFile string, maxK int, outputFile string) {
+	k, err := rsa.ReadPublicKeyFile(keyFile)
+	if err != nil {
+		log.Fatalf("Error loading key: %v", err)
+	}
+	if !k.D.IsInt64() || k.D.Int64() > 65537 {
+		log.Fatalf("Exponent is too large for a root attack")
+	}
+
+	cs, err := readBlocks(cFile)
+	if err != nil {
+		log.Fatalf("Error reading ciphertext: %v", err)
+	}
+
+	ms := make([]*big.Int, len(cs))
+	for i, c := range cs {
+		ms[i], err = attack.CubeRoot(c, k.N, int(k.D.Int64()), maxK)
+		if err != nil {
+			log.Fatalf("Block %d: %v", i, err)
+		}
+	}
+	writeRecovered(outputFile, ms)
+}
+
+func batchGCD(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pub"))
+	if err != nil {
+		log.Fatalf("Error listing keys: %v", err)
+	}
+	sort.Strings(files)
+
+	var names []string
+	var moduli []*big.Int
+	for _, file := range files {
+		k, err := rsa.ReadPublicKeyFile(file)
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", file, err)
+			continue
+		}
+		names = append(names, file)
+		moduli = append(moduli, k.N)
+	}
+	if len(moduli) < 2 {
+		log.Fatalf("Need at least two RSA keys in %s, found %d", dir, len(moduli))
+	}
+
+	fmt.Printf("Checking %d moduli...\n", len(moduli))
+	one := big.NewInt(1)
+	found := 0
+	for i, g := range attack.BatchGCD(moduli) {
+		if g.Cmp(one) == 0 {
+			continue
+		}
+		found++
+		// every prime is shared: some pairwise gcd is a proper factor
+		if g.Cmp(moduli[i]) == 0 {
+			for j := range moduli {
+				pg := new(big.Int).GCD(nil, nil, moduli[i], moduli[j])
+				if j != i && pg.Cmp(one) != 0 && pg.Cmp(moduli[i]) != 0 {
+					g = pg
+					break
+				}
+			}
+		}
+		if g.Cmp(moduli[i]) == 0 {
+			fmt.Printf("✗ %s: N is shared with another key\n", names[i])
+			continue
+		}
+		fmt.Printf("✗ %s: N = %s * %s\n", names[i], g, new(big.Int).Div(moduli[i], g))
+	}
+	if found == 0 {
+		fmt.Println("✓ No shared primes found")
+	}
+}
+
+func wiener(keyFile, cFile, outputFile string) {
+	pub, err := rsa.ReadPublicKeyFile(keyFile)
+	if err != nil {
+		log.Fatalf("Error loading key: %v", err)
+	}
+
+	k, err := attack.Wiener(pub.D, pub.N)
+	if err != nil {
+		fmt.Println("✓ Wiener's attack failed, c > N^(1/4)/3")
+		return
+	}
+	fmt.Printf("✗ Private exponent recovered (%d bits): c = %s\n", k.C.BitLen(), k.C)
+	fmt.Printf("  N = %s * %s\n", k.Primes[0], k.Primes[1])
+
+	if cFile == "" {
+		return
+	}
+	err = rsa.DecryptFile(cFile, outputFile, k.C, k.N)
+	if err != nil {
+		log.Fatalf("Error decrypting file: %v", err)
+	}
+	fmt.Printf("Decrypted to %s\n", outputFile)
+}
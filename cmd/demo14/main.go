@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"information-defending/internal/rsa"
+	"information-defending/internal/threshold"
+	"log"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dealCmd := flag.NewFlagSet("deal", flag.ExitOnError)
+	signCmd := flag.NewFlagSet("sign", flag.ExitOnError)
+	combineCmd := flag.NewFlagSet("combine", flag.ExitOnError)
+	runCmd := flag.NewFlagSet("run", flag.ExitOnError)
+
+	dealKey := dealCmd.String("key", "rsa_keys", "demo8 key with safe primes to split (key.pub and key.priv)")
+	dealGenerate := dealCmd.Bool("generate", false, "Generate a new key with safe primes instead of splitting an existing one")
+	dealBits := dealCmd.Int("bits", 2048, "Modulus size for -generate")
+	dealT := dealCmd.Int("t", 2, "Number of parties needed to sign")
+	dealN := dealCmd.Int("n", 3, "Number of parties")
+	dealDir := dealCmd.String("dir", "shares", "Directory for threshold.pub and the shares")
+
+	signShare := signCmd.String("share", "", "Party's share file (shareN.priv)")
+	signParams := signCmd.String("params", "shares/threshold.pub", "Threshold public key")
+	signInput := signCmd.String("input", "", "Input file to sign")
+	signOutput := signCmd.String("output", "", "Output signature share (default: input.sigN, - for stdout)")
+
+	combineParams := combineCmd.String("params", "shares/threshold.pub", "Threshold public key")
+	combineInput := combineCmd.String("input", "", "Signed input file")
+	combineShares := combineCmd.String("shares", "", "Comma-separated signature share files")
+	combineOutput := combineCmd.String("output", "", "Output signature file (default: input.sig)")
+
+	runDir := runCmd.String("dir", "shares", "Directory with threshold.pub and the shares")
+	runInput := runCmd.String("input", "", "Input file to sign")
+	runOutput := runCmd.String("output", "", "Output signature file (default: input.sig)")
+	runMode := runCmd.String("mode", "goroutines", "Run parties as goroutines or processes")
+	runParties := runCmd.String("parties", "", "Comma-separated party indices (default: all)")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "deal":
+		dealCmd.Parse(os.Args[2:])
+		deal(*dealKey, *dealGenerate, *dealBits, *dealT, *dealN, *dealDir)
+	case "sign":
+		signCmd.Parse(os.Args[2:])
+		if *signShare == "" || *signInput == "" {
+			fmt.Println("Error: share and input file are required")
+			signCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		signPart(*signShare, *signParams, *signInput, *signOutput)
+	case "combine":
+		combineCmd.Parse(os.Args[2:])
+		if *combineInput == "" || *combineShares == "" {
+			fmt.Println("Error: input file and signature shares are required")
+			combineCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *combineOutput == "" {
+			*combineOutput = *combineInput + ".sig"
+		}
+		combine(*combineParams, *combineInput, strings.Split(*combineShares, ","), *combineOutput)
+	case "run":
+		runCmd.Parse(os.Args[2:])
+		if *runInput == "" {
+			fmt.Println("Error: input file is required")
+			runCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *runOutput == "" {
+			*runOutput = *runInput + ".sig"
+		}
+		run(*runDir, *runInput, *runOutput, *runMode, *runParties)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  deal    - split an RSA private key into t-of-n shares")
+	fmt.Println("  sign    - produce one party's signature share")
+	fmt.Println("  combine - verify signature shares and assemble an ordinary RSA signature")
+	fmt.Println("  run     - sign with several parties as goroutines or processes and combine")
+	fmt.Println("\nThe result verifies with: demo8 verify -input file -signature file.sig -key rsa_keys")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func deal(keyFile string, generate bool, bits, t, n int, dir string) {
+	var keys rsa.Keys
+	if generate {
+		fmt.Println("Generating RSA keys with safe primes...")
+		var err error
+		keys, err = threshold.GenerateKeys(bits, 65537)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+		err = rsa.WritePublicKeyFile(keyFile+".pub", keys)
+		if err != nil {
+			log.Fatalf("Error saving public key: %v", err)
+		}
+		fmt.Printf("Public key saved to %s.pub\n", keyFile)
+	} else {
+		pub, err := rsa.ReadPublicKeyFile(keyFile + ".pub")
+		if err != nil {
+			log.Fatalf("Error loading public key: %v", err)
+		}
+		keys, err = rsa.ReadPrivateKeyFile(keyFile + ".priv")
+		if err != nil {
+			log.Fatalf("Error loading private key: %v", err)
+		}
+		if keys.N.Cmp(pub.N) != 0 {
+			log.Fatalf("Error: %s.pub and %s.priv have different moduli", keyFile, keyFile)
+		}
+		keys.D = pub.D
+		if len(keys.Primes) == 0 {
+			keys.Primes, err = rsa.RecoverPrimes(keys.C, keys.D, keys.N)
+			if err != nil {
+				log.Fatalf("Error recovering primes: %v", err)
+			}
+		}
+		if !threshold.IsSafe(keys) {
+			log.Fatalf("Error: %s has no safe primes, which the share proofs need (use -generate)", keyFile)
+		}
+	}
+
+	pub, shares, err := threshold.Deal(keys, t, n)
+	if err != nil {
+		log.Fatalf("Error splitting key: %v", err)
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		log.Fatalf("Error creating directory: %v", err)
+	}
+	err = threshold.WritePublicKey(filepath.Join(dir, "threshold.pub"), pub)
+	if err != nil {
+		log.Fatalf("Error saving threshold key: %v", err)
+	}
+	for _, s := range shares {
+		err := threshold.WriteShare(filepath.Join(dir, fmt.Sprintf("share%d.priv", s.Index)), s)
+		if err != nil {
+			log.Fatalf("Error saving share: %v", err)
+		}
+	}
+
+	fmt.Printf("Split into %d shares, any %d can sign\n", n, t)
+	fmt.Printf("Threshold key: %s\n", filepath.Join(dir, "threshold.pub"))
+	fmt.Printf("Shares: %s\n", filepath.Join(dir, "share*.priv"))
+	if !generate {
+		fmt.Printf("Hand the shares out and delete %s.priv\n", keyFile)
+	}
+}
+
+func hashFile(inputFile string) *big.Int {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+	hash := sha256.Sum256(data)
+	return new(big.Int).SetBytes(hash[:])
+}
+
+func signPart(shareFile, paramsFile, inputFile, outputFile string) {
+	pub, err := threshold.ReadPublicKey(paramsFile)
+	if err != nil {
+		log.Fatalf("Error loading threshold key: %v", err)
+	}
+	share, err := threshold.ReadShare(shareFile)
+	if err != nil {
+		log.Fatalf("Error loading share: %v", err)
+	}
+
+	ss, err := share.Sign(pub, hashFile(inputFile))
+	if err != nil {
+		log.Fatalf("Error signing: %v", err)
+	}
+	data, _ := ss.MarshalText()
+
+	if outputFile == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	if outputFile == "" {
+		outputFile = fmt.Sprintf("%s.sig%d", inputFile, share.Index)
+	}
+	err = os.WriteFile(outputFile, data, 0644)
+	if err != nil {
+		log.Fatalf("Error writing signature share: %v", err)
+	}
+	fmt.Printf("Signature share %d saved to: %s\n", share.Index, outputFile)
+}
+
+func combine(paramsFile, inputFile string, shareFiles []string, outputFile string) {
+	var shares []threshold.SignatureShare
+	for _, file := range shareFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error reading signature share: %v", err)
+		}
+		var ss threshold.SignatureShare
+		if err := ss.UnmarshalText(data); err != nil {
+			log.Fatalf("%s: %v", file, err)
+		}
+		shares = append(shares, ss)
+	}
+	combineShares(paramsFile, inputFile, shares, outputFile)
+}
+
+func combineShares(paramsFile, inputFile string, shares []threshold.SignatureShare, outputFile string) {
+	pub, err := threshold.ReadPublicKey(paramsFile)
+	if err != nil {
+		log.Fatalf("Error loading threshold key: %v", err)
+	}
+	x := hashFile(inputFile)
+
+	for _, ss := range shares {
+		if err := pub.VerifyShare(x, ss); err != nil {
+			fmt.Printf("✗ Share %d: proof does not verify, ignored\n", ss.Index)
+		} else {
+			fmt.Printf("✓ Share %d\n", ss.Index)
+		}
+	}
+
+	s, err := pub.Combine(x, shares)
+	if err != nil {
+		log.Fatalf("Error combining: %v", err)
+	}
+
+	signatureBytes := s.Bytes()
+	err = os.WriteFile(outputFile, signatureBytes, 0644)
+	if err != nil {
+		log.Fatalf("Error writing signature: %v", err)
+	}
+	fmt.Printf("Signature saved to: %s\n", outputFile)
+	fmt.Printf("Signature: %x\n", signatureBytes)
+}
+
+func run(dir, inputFile, outputFile, mode, parties string) {
+	paramsFile := filepath.Join(dir, "threshold.pub")
+	pub, err := threshold.ReadPublicKey(paramsFile)
+	if err != nil {
+		log.Fatalf("Error loading threshold key: %v", err)
+	}
+
+	var indices []int
+	if parties == "" {
+		for i := 1; i <= pub.Parties; i++ {
+			indices = append(indices, i)
+		}
+	} else {
+		for _, p := range strings.Split(parties, ",") {
+			var i int
+			if _, err := fmt.Sscanf(p, "%d", &i); err != nil {
+				log.Fatalf("Bad party index: %s", p)
+			}
+			indices = append(indices, i)
+		}
+	}
+
+	var shares []threshold.SignatureShare
+	switch mode {
+	case "goroutines":
+		shares = runGoroutines(dir, pub, hashFile(inputFile), indices)
+	case "processes":
+		shares = runProcesses(dir, paramsFile, inputFile, indices)
+	default:
+		log.Fatalf("Unknown mode: %s", mode)
+	}
+	combineShares(paramsFile, inputFile, shares, outputFile)
+}
+
+// runGoroutines runs every party in its own goroutine; the shares reach
+// the combiner over a channel only.
+func runGoroutines(dir string, pub *threshold.PublicKey, x *big.Int, indices []int) []threshold.SignatureShare {
+	type result struct {
+		ss  threshold.SignatureShare
+		err error
+	}
+	results := make(chan result)
+	for _, i := range indices {
+		go func() {
+			share, err := threshold.ReadShare(filepath.Join(dir, fmt.Sprintf("share%d.priv", i)))
+			if err != nil {
+				results <- result{err: err}
+				return
+			}
+			ss, err := share.Sign(pub, x)
+			results <- result{ss, err}
+		}()
+	}
+
+	var shares []threshold.SignatureShare
+	for range indices {
+		r := <-results
+		if r.err != nil {
+			fmt.Printf("Party failed: %v\n", r.err)
+			continue
+		}
+		shares = append(shares, r.ss)
+	}
+	return shares
+}
+
+// runProcesses starts "demo14 sign" once per party and reads each share
+// from the child's stdout.
+func runProcesses(dir, paramsFile, inputFile string, indices []int) []threshold.SignatureShare {
+	self, err := os.Executable()
+	if err != nil {
+		log.Fatalf("Error locating executable: %v", err)
+	}
+
+	cmds := make([]*exec.Cmd, len(indices))
+	outs := make([]*bytes.Buffer, len(indices))
+	for k, i := range indices {
+		outs[k] = new(bytes.Buffer)
+		cmds[k] = exec.Command(self, "sign",
+			"-share", filepath.Join(dir, fmt.Sprintf("share%d.priv", i)),
+			"-params", paramsFile, "-input", inputFile, "-output", "-")
+		cmds[k].Stdout = outs[k]
+		cmds[k].Stderr = os.Stderr
+		if err := cmds[k].Start(); err != nil {
+			log.Fatalf("Error starting party %d: %v", i, err)
+		}
+		fmt.Printf("Party %d: pid %d\n", i, cmds[k].Process.Pid)
+	}
+
+	var shares []threshold.SignatureShare
+	for k, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			fmt.Printf("Party %d failed: %v\n", indices[k], err)
+			continue
+		}
+		var ss threshold.SignatureShare
+		if err := ss.UnmarshalText(outs[k].Bytes()); err != nil {
+			fmt.Printf("Party %d: %v\n", indices[k], err)
+			continue
+		}
+		shares = append(shares, ss)
+	}
+	return shares
+}
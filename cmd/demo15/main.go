@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"information-defending/internal/hybrid"
+	"information-defending/internal/timelock"
+	"log"
+	"math/big"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	calibrateCmd := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	sealCmd := flag.NewFlagSet("seal", flag.ExitOnError)
+	openCmd := flag.NewFlagSet("open", flag.ExitOnError)
+
+	calibrateBits := calibrateCmd.Int("bits", 2048, "Modulus size")
+
+	sealInput := sealCmd.String("input", "", "File to seal")
+	sealOutput := sealCmd.String("output", "", "Output file (default: input.tlp)")
+	sealAfter := sealCmd.Duration("after", time.Hour, "How long opening should take, e.g. 90m")
+	sealBits := sealCmd.Int("bits", 2048, "Modulus size")
+	sealRate := sealCmd.Float64("rate", 0, "Squarings per second of the fastest expected solver (default: calibrate on this machine)")
+
+	openInput := openCmd.String("input", "", "Sealed file")
+	openOutput := openCmd.String("output", "", "Output file")
+	openCheckpoint := openCmd.String("checkpoint", "", "Checkpoint file (default: input.ckpt)")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "calibrate":
+		calibrateCmd.Parse(os.Args[2:])
+		calibrate(*calibrateBits)
+	case "seal":
+		sealCmd.Parse(os.Args[2:])
+		if *sealInput == "" {
+			fmt.Println("Error: input file is required")
+			sealCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *sealOutput == "" {
+			*sealOutput = *sealInput + ".tlp"
+		}
+		seal(*sealInput, *sealOutput, *sealAfter, *sealBits, *sealRate)
+	case "open":
+		openCmd.Parse(os.Args[2:])
+		if *openInput == "" || *openOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			openCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *openCheckpoint == "" {
+			*openCheckpoint = *openInput + ".ckpt"
+		}
+		open(*openInput, *openOutput, *openCheckpoint)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  calibrate - measure modular squarings per second")
+	fmt.Println("  seal      - encrypt a file so that it opens only after a set time")
+	fmt.Println("  open      - solve the puzzle and decrypt (Ctrl-C saves progress, rerun to resume)")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func calibrate(bits int) {
+	fmt.Printf("Measuring %d-bit squarings for 3s...\n", bits)
+	rate, err := timelock.SquaringsPerSecond(bits, 3*time.Second)
+	if err != nil {
+		log.Fatalf("Error calibrating: %v", err)
+	}
+	fmt.Printf("%.0f squarings per second\n", rate)
+	fmt.Printf("One hour: -rate %.0f gives t = %.0f\n", rate, rate*3600)
+}
+
+func seal(inputFile, outputFile string, after time.Duration, bits int, rate float64) {
+	if rate == 0 {
+		fmt.Println("Calibrating...")
+		var err error
+		rate, err = timelock.SquaringsPerSecond(bits, 2*time.Second)
+		if err != nil {
+			log.Fatalf("Error calibrating: %v", err)
+		}
+	}
+	t := uint64(rate * after.Seconds())
+	fmt.Printf("%.0f squarings per second, t = %d\n", rate, t)
+
+	err := hybrid.EncryptFile(inputFile, outputFile, hybrid.TimeLockWrapper{Bits: bits, T: t}, hybrid.DEMAESGCM)
+	if err != nil {
+		log.Fatalf("Error sealing file: %v", err)
+	}
+	fmt.Printf("Sealed to %s, opens after about %s of sequential work\n", outputFile, after)
+}
+
+func open(inputFile, outputFile, checkpoint string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	solver := &timelock.Solver{
+		Checkpoint: checkpoint,
+		Every:      5 * time.Second,
+		Progress: func(done, total uint64) {
+			fmt.Printf("\r%6.2f%% (%d/%d), %s elapsed", 100*float64(done)/float64(total), done, total,
+				time.Since(start).Round(time.Second))
+		},
+	}
+	unwrapper := hybrid.TimeLockUnwrapper{
+		Solve: func(p *timelock.Puzzle) (*big.Int, error) {
+			return solver.Solve(ctx, p)
+		},
+	}
+
+	err := hybrid.DecryptFile(inputFile, outputFile, unwrapper)
+	fmt.Println()
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("Interrupted, progress saved to %s\n", checkpoint)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("Error opening file: %v", err)
+	}
+	fmt.Printf("Opened to %s\n", outputFile)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rabin"
+	"log"
+	"math/big"
+	"os"
+	"time"
+)
+
+func main() {
+	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	signCmd := flag.NewFlagSet("sign", flag.ExitOnError)
+	verifyCmd := flag.NewFlagSet("verify", flag.ExitOnError)
+	sqrtCmd := flag.NewFlagSet("sqrt", flag.ExitOnError)
+
+	keyFile := generateCmd.String("key", "rabin_keys", "File to save Rabin keys")
+	keyBits := generateCmd.Int("bits", 2048, "Modulus size")
+
+	encInput := encryptCmd.String("input", "", "Input file")
+	encOutput := encryptCmd.String("output", "", "Output file (default: input.enc)")
+	encKey := encryptCmd.String("key", "rabin_keys", "Rabin public key file")
+
+	decInput := decryptCmd.String("input", "", "Encrypted file")
+	decOutput := decryptCmd.String("output", "", "Output file")
+	decKey := decryptCmd.String("key", "rabin_keys", "Rabin private key file")
+
+	signInput := signCmd.String("input", "", "Input file to sign")
+	signOutput := signCmd.String("output", "", "Output signature file (default: input.sig)")
+	signKey := signCmd.String("key", "rabin_keys", "Rabin private key file")
+
+	verifyInput := verifyCmd.String("input", "", "Input file to verify")
+	verifySig := verifyCmd.String("signature", "", "Signature file")
+	verifyKey := verifyCmd.String("key", "rabin_keys", "Rabin public key file")
+
+	sqrtA := sqrtCmd.String("a", "", "Number to take the square root of")
+	sqrtP := sqrtCmd.String("p", "", "Odd prime modulus")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "generate":
+		generateCmd.Parse(os.Args[2:])
+		generateKeys(*keyFile, *keyBits)
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		if *encInput == "" {
+			fmt.Println("Error: input file is required")
+			encryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *encOutput == "" {
+			*encOutput = *encInput + ".enc"
+		}
+		encryptFile(*encInput, *encOutput, *encKey)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		if *decInput == "" || *decOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			decryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		decryptFile(*decInput, *decOutput, *decKey)
+	case "sign":
+		signCmd.Parse(os.Args[2:])
+		if *signInput == "" {
+			fmt.Println("Error: input file is required")
+			signCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *signOutput == "" {
+			*signOutput = *signInput + ".sig"
+		}
+		signFile(*signInput, *signOutput, *signKey)
+	case "verify":
+		verifyCmd.Parse(os.Args[2:])
+		if *verifyInput == "" || *verifySig == "" {
+			fmt.Println("Error: input file and signature file are required")
+			verifyCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		verifySignature(*verifyInput, *verifySig, *verifyKey)
+	case "sqrt":
+		sqrtCmd.Parse(os.Args[2:])
+		if *sqrtA == "" || *sqrtP == "" {
+			fmt.Println("Error: a and p are required")
+			sqrtCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		modSqrt(*sqrtA, *sqrtP)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  generate - generate Rabin keys (Blum primes)")
+	fmt.Println("  encrypt  - encrypt a file")
+	fmt.Println("  decrypt  - decrypt a file")
+	fmt.Println("  sign     - sign a file (Rabin-Williams)")
+	fmt.Println("  verify   - verify a file signature")
+	fmt.Println("  sqrt     - square root modulo a prime by Tonelli-Shanks and Cipolla")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func generateKeys(keyFile string, bits int) {
+	fmt.Println("Generating Rabin keys...")
+	keys, err := rabin.GenerateKeys(bits)
+	if err != nil {
+		log.Fatalf("Error generating keys: %v", err)
+	}
+
+	err = os.WriteFile(keyFile+".pub", []byte(keys.N.String()), 0644)
+	if err != nil {
+		log.Fatalf("Error saving keys: %v", err)
+	}
+	err = os.WriteFile(keyFile+".priv", fmt.Appendf(nil, "%s\n%s", keys.P, keys.Q), 0600)
+	if err != nil {
+		log.Fatalf("Error saving keys: %v", err)
+	}
+
+	fmt.Printf("Keys saved to %s.pub and %s.priv\n", keyFile, keyFile)
+	fmt.Printf("Public key (N): %s\n", keys.N)
+	fmt.Printf("P = %s (mod 8 = 3)\n", keys.P)
+	fmt.Printf("Q = %s (mod 8 = 7)\n", keys.Q)
+}
+
+func loadNumbers(filename string, count int) ([]*big.Int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	nums, err := crypto.ParseBigInts(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if len(nums) != count {
+		return nil, fmt.Errorf("%s: expected %d numbers, got %d", filename, count, len(nums))
+	}
+	return nums, nil
+}
+
+func loadPrivateKey(keyFile string) rabin.Keys {
+	nums, err := loadNumbers(keyFile+".priv", 2)
+	if err != nil {
+		log.Fatalf("Error loading private key: %v", err)
+	}
+	return rabin.Keys{N: new(big.Int).Mul(nums[0], nums[1]), P: nums[0], Q: nums[1]}
+}
+
+func loadPublicKey(keyFile string) *big.Int {
+	nums, err := loadNumbers(keyFile+".pub", 1)
+	if err != nil {
+		log.Fatalf("Error loading public key: %v", err)
+	}
+	return nums[0]
+}
+
+func encryptFile(inputFile, outputFile, keyFile string) {
+	err := rabin.EncryptFile(inputFile, outputFile, loadPublicKey(keyFile))
+	if err != nil {
+		log.Fatalf("Error encrypting file: %v", err)
+	}
+	fmt.Printf("Encrypted to: %s\n", outputFile)
+}
+
+func decryptFile(inputFile, outputFile, keyFile string) {
+	keys := loadPrivateKey(keyFile)
+	err := rabin.DecryptFile(inputFile, outputFile, keys.P, keys.Q)
+	if err != nil {
+		log.Fatalf("Error decrypting file: %v", err)
+	}
+	fmt.Printf("Decrypted to: %s\n", outputFile)
+}
+
+func signFile(inputFile, outputFile, keyFile string) {
+	fmt.Printf("Signing file: %s\n", inputFile)
+	keys := loadPrivateKey(keyFile)
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+
+	sig, err := rabin.Sign(data, keys)
+	if err != nil {
+		log.Fatalf("Error signing: %v", err)
+	}
+
+	err = os.WriteFile(outputFile, fmt.Appendf(nil, "%d\n%d\n%s", sig.E, sig.F, sig.S), 0644)
+	if err != nil {
+		log.Fatalf("Error writing signature: %v", err)
+	}
+	fmt.Printf("Signature saved to: %s\n", outputFile)
+	fmt.Printf("Signature: e = %d, f = %d, s = %x\n", sig.E, sig.F, sig.S)
+}
+
+func verifySignature(inputFile, signatureFile, keyFile string) {
+	fmt.Printf("Verifying file: %s\n", inputFile)
+	N := loadPublicKey(keyFile)
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+	nums, err := loadNumbers(signatureFile, 3)
+	if err != nil {
+		log.Fatalf("Error reading signature: %v", err)
+	}
+	sig := rabin.Signature{E: int(nums[0].Int64()), F: int(nums[1].Int64()), S: nums[2]}
+
+	if rabin.Verify(data, sig, N) == nil {
+		fmt.Println("✓ Signature is VALID")
+	} else {
+		fmt.Println("✗ Signature is INVALID")
+	}
+}
+
+func modSqrt(aStr, pStr string) {
+	a, ok := new(big.Int).SetString(aStr, 10)
+	if !ok {
+		log.Fatalf("Error parsing a: %s", aStr)
+	}
+	p, ok := new(big.Int).SetString(pStr, 10)
+	if !ok || p.Bit(0) == 0 || !p.ProbablyPrime(20) {
+		log.Fatalf("p must be an odd prime: %s", pStr)
+	}
+
+	fmt.Printf("Legendre (a/p) = %d\n", crypto.Legendre(a, p))
+	methods := []struct {
+		name string
+		fn   func(a, p *big.Int) (*big.Int, error)
+	}{
+		{"Tonelli-Shanks", crypto.SqrtTonelliShanks},
+		{"Cipolla", crypto.SqrtCipolla},
+	}
+	for _, m := range methods {
+		start := time.Now()
+		r, err := m.fn(a, p)
+		if err != nil {
+			fmt.Printf("%-15s %v\n", m.name, err)
+			continue
+		}
+		fmt.Printf("%-15s x = %s, -x = %s (%s)\n", m.name, r, new(big.Int).Sub(p, r), time.Since(start))
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/homomorphic"
+	"information-defending/internal/rsa"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+)
+
+func main() {
+	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	encNumCmd := flag.NewFlagSet("encrypt-number", flag.ExitOnError)
+	decNumCmd := flag.NewFlagSet("decrypt-number", flag.ExitOnError)
+	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	mulCmd := flag.NewFlagSet("mul", flag.ExitOnError)
+	rerandCmd := flag.NewFlagSet("rerandomize", flag.ExitOnError)
+
+	keyFile := generateCmd.String("key", "he_keys", "File to save keys (RSA PEM)")
+	keyBits := generateCmd.Int("bits", 2048, "Modulus size")
+
+	encScheme := encryptCmd.String("scheme", "paillier", "Scheme: gm or paillier")
+	encInput := encryptCmd.String("input", "", "Input file")
+	encOutput := encryptCmd.String("output", "", "Output file (default: input.enc)")
+	encKey := encryptCmd.String("key", "he_keys", "Key file base name")
+
+	decScheme := decryptCmd.String("scheme", "paillier", "Scheme: gm or paillier")
+	decInput := decryptCmd.String("input", "", "Encrypted file")
+	decOutput := decryptCmd.String("output", "", "Output file")
+	decKey := decryptCmd.String("key", "he_keys", "Key file base name")
+
+	encNumValue := encNumCmd.String("value", "", "Number to encrypt")
+	encNumOutput := encNumCmd.String("output", "", "Output ciphertext file")
+	encNumKey := encNumCmd.String("key", "he_keys", "Key file base name")
+
+	decNumInput := decNumCmd.String("input", "", "Ciphertext file")
+	decNumKey := decNumCmd.String("key", "he_keys", "Key file base name")
+
+	addInputs := addCmd.String("inputs", "", "Comma-separated ciphertext files to add")
+	addPlain := addCmd.String("plain", "", "Plaintext constant to add (optional)")
+	addOutput := addCmd.String("output", "", "Output ciphertext file")
+	addKey := addCmd.String("key", "he_keys", "Key file base name")
+
+	mulInput := mulCmd.String("input", "", "Ciphertext file")
+	mulK := mulCmd.String("k", "", "Plaintext constant")
+	mulOutput := mulCmd.String("output", "", "Output ciphertext file")
+	mulKey := mulCmd.String("key", "he_keys", "Key file base name")
+
+	rerandInput := rerandCmd.String("input", "", "Ciphertext file")
+	rerandOutput := rerandCmd.String("output", "", "Output ciphertext file")
+	rerandKey := rerandCmd.String("key", "he_keys", "Key file base name")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	required := func(fs *flag.FlagSet, msg string, values ...string) {
+		for _, v := range values {
+			if v == "" {
+				fmt.Println("Error: " + msg)
+				fs.PrintDefaults()
+				os.Exit(1)
+			}
+		}
+	}
+
+	switch os.Args[1] {
+	case "generate":
+		generateCmd.Parse(os.Args[2:])
+		generateKeys(*keyFile, *keyBits)
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		required(encryptCmd, "input file is required", *encInput)
+		if *encOutput == "" {
+			*encOutput = *encInput + ".enc"
+		}
+		encryptFile(*encScheme, *encInput, *encOutput, *encKey)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		required(decryptCmd, "input and output files are required", *decInput, *decOutput)
+		decryptFile(*decScheme, *decInput, *decOutput, *decKey)
+	case "encrypt-number":
+		encNumCmd.Parse(os.Args[2:])
+		required(encNumCmd, "value and output file are required", *encNumValue, *encNumOutput)
+		encryptNumber(*encNumValue, *encNumOutput, *encNumKey)
+	case "decrypt-number":
+		decNumCmd.Parse(os.Args[2:])
+		required(decNumCmd, "input file is required", *decNumInput)
+		decryptNumber(*decNumInput, *decNumKey)
+	case "add":
+		addCmd.Parse(os.Args[2:])
+		required(addCmd, "inputs and output file are required", *addInputs, *addOutput)
+		add(strings.Split(*addInputs, ","), *addPlain, *addOutput, *addKey)
+	case "mul":
+		mulCmd.Parse(os.Args[2:])
+		required(mulCmd, "input, k and output file are required", *mulInput, *mulK, *mulOutput)
+		mul(*mulInput, *mulK, *mulOutput, *mulKey)
+	case "rerandomize":
+		rerandCmd.Parse(os.Args[2:])
+		required(rerandCmd, "input and output files are required", *rerandInput, *rerandOutput)
+		rerandomize(*rerandInput, *rerandOutput, *rerandKey)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  generate       - generate keys for Goldwasser-Micali and Paillier")
+	fmt.Println("  encrypt        - encrypt a file (gm or paillier)")
+	fmt.Println("  decrypt        - decrypt a file")
+	fmt.Println("  encrypt-number - encrypt a number with Paillier")
+	fmt.Println("  decrypt-number - decrypt a Paillier ciphertext")
+	fmt.Println("  add            - add Paillier ciphertexts without decrypting them")
+	fmt.Println("  mul            - multiply a Paillier ciphertext by a constant")
+	fmt.Println("  rerandomize    - make a Paillier ciphertext unlinkable to the original")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func generateKeys(keyFile string, bits int) {
+	fmt.Println("Generating keys...")
+	keys, err := homomorphic.GenerateKeys(bits)
+	if err != nil {
+		log.Fatalf("Error generating keys: %v", err)
+	}
+
+	pubData, err := rsa.EncodePublicKeyPEM(keys, rsa.FormatPKCS1)
+	if err != nil {
+		log.Fatalf("Error encoding keys: %v", err)
+	}
+	err = os.WriteFile(keyFile+".pub", pubData, 0644)
+	if err != nil {
+		log.Fatalf("Error saving keys: %v", err)
+	}
+	privData, err := rsa.EncodePrivateKeyPEM(keys, rsa.FormatPKCS1)
+	if err != nil {
+		log.Fatalf("Error encoding keys: %v", err)
+	}
+	err = os.WriteFile(keyFile+".priv", privData, 0600)
+	if err != nil {
+		log.Fatalf("Error saving keys: %v", err)
+	}
+
+	fmt.Printf("Keys saved to %s.pub and %s.priv\n", keyFile, keyFile)
+	fmt.Printf("N = %s\n", keys.N)
+}
+
+func loadPublic(keyFile string) rsa.Keys {
+	k, err := rsa.ReadPublicKeyFile(keyFile + ".pub")
+	if err != nil {
+		log.Fatalf("Error loading public key: %v", err)
+	}
+	return k
+}
+
+func loadPrivate(keyFile string) rsa.Keys {
+	k, err := rsa.ReadPrivateKeyFile(keyFile + ".priv")
+	if err != nil {
+		log.Fatalf("Error loading private key: %v", err)
+	}
+	return k
+}
+
+func encryptFile(scheme, inputFile, outputFile, keyFile string) {
+	k := loadPublic(keyFile)
+	var err error
+	switch scheme {
+	case "gm":
+		err = homomorphic.NewGMPublicKey(k).EncryptFile(inputFile, outputFile)
+	case "paillier":
+		err = homomorphic.NewPaillierPublicKey(k).EncryptFile(inputFile, outputFile)
+	default:
+		log.Fatalf("Unknown scheme: %s", scheme)
+	}
+	if err != nil {
+		log.Fatalf("Error encrypting file: %v", err)
+	}
+	fmt.Printf("Encrypted to: %s\n", outputFile)
+}
+
+func decryptFile(scheme, inputFile, outputFile, keyFile string) {
+	k := loadPrivate(keyFile)
+	var err error
+	switch scheme {
+	case "gm":
+		var priv *homomorphic.GMPrivateKey
+		priv, err = homomorphic.NewGMPrivateKey(k)
+		if err == nil {
+			err = priv.DecryptFile(inputFile, outputFile)
+		}
+	case "paillier":
+		var priv *homomorphic.PaillierPrivateKey
+		priv, err = homomorphic.NewPaillierPrivateKey(k)
+		if err == nil {
+			err = priv.DecryptFile(inputFile, outputFile)
+		}
+	default:
+		log.Fatalf("Unknown scheme: %s", scheme)
+	}
+	if err != nil {
+		log.Fatalf("Error decrypting file: %v", err)
+	}
+	fmt.Printf("Decrypted to: %s\n", outputFile)
+}
+
+func parseNumber(s string) *big.Int {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+	if !ok {
+		log.Fatalf("Error parsing number: %s", s)
+	}
+	return n
+}
+
+func readCiphertext(filename string) *big.Int {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Error reading ciphertext: %v", err)
+	}
+	return parseNumber(string(data))
+}
+
+func writeCiphertext(filename string, c *big.Int) {
+	err := os.WriteFile(filename, []byte(c.String()), 0644)
+	if err != nil {
+		log.Fatalf("Error writing ciphertext: %v", err)
+	}
+	fmt.Printf("Ciphertext saved to: %s\n", filename)
+}
+
+func encryptNumber(value, outputFile, keyFile string) {
+	pub := homomorphic.NewPaillierPublicKey(loadPublic(keyFile))
+	c, err := pub.Encrypt(parseNumber(value))
+	if err != nil {
+		log.Fatalf("Error encrypting: %v", err)
+	}
+	writeCiphertext(outputFile, c)
+}
+
+func decryptNumber(inputFile, keyFile string) {
+	priv, err := homomorphic.NewPaillierPrivateKey(loadPrivate(keyFile))
+	if err != nil {
+		log.Fatalf("Error loading key: %v", err)
+	}
+	m, err := priv.Decrypt(readCiphertext(inputFile))
+	if err != nil {
+		log.Fatalf("Error decrypting: %v", err)
+	}
+	fmt.Printf("Plaintext: %s\n", m)
+}
+
+func add(inputFiles []string, plain, outputFile, keyFile string) {
+	pub := homomorphic.NewPaillierPublicKey(loadPublic(keyFile))
+	c := readCiphertext(inputFiles[0])
+	for _, file := range inputFiles[1:] {
+		c = pub.Add(c, readCiphertext(file))
+	}
+	if plain != "" {
+		c = pub.AddPlain(c, parseNumber(plain))
+	}
+	writeCiphertext(outputFile, c)
+}
+
+func mul(inputFile, k, outputFile, keyFile string) {
+	pub := homomorphic.NewPaillierPublicKey(loadPublic(keyFile))
+	writeCiphertext(outputFile, pub.Mul(readCiphertext(inputFile), parseNumber(k)))
+}
+
+func rerandomize(inputFile, outputFile, keyFile string) {
+	pub := homomorphic.NewPaillierPublicKey(loadPublic(keyFile))
+	c, err := pub.Rerandomize(readCiphertext(inputFile))
+	if err != nil {
+		log.Fatalf("Error re-randomizing: %v", err)
+	}
+	writeCiphertext(outputFile, c)
+}
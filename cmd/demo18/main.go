@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/shamir"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	sendCmd := flag.NewFlagSet("send", flag.ExitOnError)
+	receiveCmd := flag.NewFlagSet("receive", flag.ExitOnError)
+
+	sendAddr := sendCmd.String("connect", "localhost:9000", "Receiver address (host:port or unix:/path)")
+	sendInput := sendCmd.String("input", "", "File to send")
+	sendBits := sendCmd.Int("bits", 1024, "Size of the prime proposed for the session")
+	sendTimeout := sendCmd.Duration("timeout", shamir.DefaultTimeout, "Timeout for every message")
+
+	recvAddr := receiveCmd.String("listen", ":9000", "Address to listen on (host:port or unix:/path)")
+	recvDir := receiveCmd.String("dir", ".", "Directory for received files")
+	recvMinBits := receiveCmd.Int("min-bits", shamir.DefaultMinBits, "Smallest prime to accept")
+	recvTimeout := receiveCmd.Duration("timeout", shamir.DefaultTimeout, "Timeout for every message")
+	recvOnce := receiveCmd.Bool("once", false, "Exit after one file")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "send":
+		sendCmd.Parse(os.Args[2:])
+		if *sendInput == "" {
+			fmt.Println("Error: input file is required")
+			sendCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		send(*sendAddr, *sendInput, *sendBits, *sendTimeout)
+	case "receive":
+		receiveCmd.Parse(os.Args[2:])
+		receive(*recvAddr, *recvDir, *recvMinBits, *recvTimeout, *recvOnce)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  send    - send a file with the Shamir three-pass protocol")
+	fmt.Println("  receive - wait for files from senders")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func network(addr string) (string, string) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return "unix", path
+	}
+	return "tcp", addr
+}
+
+func send(addr, inputFile string, bits int, timeout time.Duration) {
+	f, err := os.Open(inputFile)
+	if err != nil {
+		log.Fatalf("Error opening file: %v", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+
+	fmt.Printf("Generating %d-bit prime...\n", bits)
+	p, err := shamir.GeneratePrime(bits)
+	if err != nil {
+		log.Fatalf("Error generating prime: %v", err)
+	}
+
+	netw, address := network(addr)
+	conn, err := net.DialTimeout(netw, address, timeout)
+	if err != nil {
+		log.Fatalf("Error connecting: %v", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	s := &shamir.Sender{P: p, Timeout: timeout}
+	err = s.Send(conn, filepath.Base(inputFile), f, info.Size())
+	if err != nil {
+		log.Fatalf("Error sending file: %v", err)
+	}
+	fmt.Printf("✓ Sent %s (%d bytes) in %s\n", inputFile, info.Size(), time.Since(start).Round(time.Millisecond))
+}
+
+func receive(addr, dir string, minBits int, timeout time.Duration, once bool) {
+	netw, address := network(addr)
+	if netw == "unix" {
+		os.Remove(address)
+	}
+	ln, err := net.Listen(netw, address)
+	if err != nil {
+		log.Fatalf("Error listening: %v", err)
+	}
+	defer ln.Close()
+	fmt.Printf("Listening on %s\n", ln.Addr())
+
+	rc := &shamir.Receiver{MinBits: minBits, Timeout: timeout}
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("Error accepting: %v", err)
+		}
+		if once {
+			err := receiveFile(rc, conn, dir)
+			if err != nil {
+				log.Fatalf("Error receiving file: %v", err)
+			}
+			return
+		}
+		go func() {
+			if err := receiveFile(rc, conn, dir); err != nil {
+				fmt.Printf("✗ %s: %v\n", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+func receiveFile(rc *shamir.Receiver, conn net.Conn, dir string) error {
+	defer conn.Close()
+
+	tmp, err := os.CreateTemp(dir, ".receiving-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	name, err := rc.Receive(conn, tmp)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	name = filepath.Base(name)
+	if name == "." || name == "/" || name == ".." {
+		name = "received"
+	}
+	outputFile := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), outputFile); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Received %s\n", outputFile)
+	return nil
+}
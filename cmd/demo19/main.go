@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"information-defending/internal/gf2n"
+	"information-defending/internal/masseyomura"
+	"information-defending/internal/shamir"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func main() {
+	fieldCmd := flag.NewFlagSet("field", flag.ExitOnError)
+	runCmd := flag.NewFlagSet("run", flag.ExitOnError)
+	benchCmd := flag.NewFlagSet("bench", flag.ExitOnError)
+
+	fieldN := fieldCmd.Int("n", 233, "Field degree")
+	fieldPoly := fieldCmd.String("poly", "", "Check this polynomial instead (hex, bit i is x^i)")
+
+	runN := runCmd.Int("n", 233, "Field degree")
+	runInput := runCmd.String("input", "", "File to transfer")
+	runOutput := runCmd.String("output", "", "Where Bob writes the received file")
+	runEncrypted := runCmd.String("encrypted", "", "Also write the container encrypted with both keys")
+
+	benchSizes := benchCmd.String("n", "127,233,409,521", "Comma-separated field degrees and prime sizes")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "field":
+		fieldCmd.Parse(os.Args[2:])
+		field(*fieldN, *fieldPoly)
+	case "run":
+		runCmd.Parse(os.Args[2:])
+		if *runInput == "" || *runOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			runCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		run(*runN, *runInput, *runOutput, *runEncrypted)
+	case "bench":
+		benchCmd.Parse(os.Args[2:])
+		benchmark(*benchSizes)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  field - choose or check the irreducible polynomial of GF(2^n)")
+	fmt.Println("  run   - pass a file from Alice to Bob with Massey-Omura")
+	fmt.Println("  bench - compare Massey-Omura over GF(2^n) with Shamir modulo an n-bit prime")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func field(n int, poly string) {
+	var f *gf2n.Field
+	var err error
+	if poly != "" {
+		p, ok := new(big.Int).SetString(poly, 16)
+		if !ok {
+			log.Fatalf("Error parsing polynomial: %s", poly)
+		}
+		f, err = gf2n.NewField(p)
+	} else {
+		f, err = gf2n.Standard(n)
+	}
+	if err != nil {
+		log.Fatalf("Error choosing field: %v", err)
+	}
+
+	fmt.Printf("GF(2^%d) = GF(2)[x] / (%s)\n", f.N, gf2n.Format(f.Poly))
+	fmt.Printf("poly   = %x\n", f.Poly)
+	fmt.Printf("2^n-1  = %s\n", f.Order())
+	if f.Order().ProbablyPrime(20) {
+		fmt.Println("2^n-1 is a Mersenne prime: every exponent 1 < c < 2^n-1 is a key")
+	}
+	c, d := masseyomura.GenerateKeys(f)
+	fmt.Printf("sample keys: c = %x\n             d = %x\n", c, d)
+}
+
+func run(n int, inputFile, outputFile, encryptedFile string) {
+	msg, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+	f, err := gf2n.Standard(n)
+	if err != nil {
+		log.Fatalf("Error choosing field: %v", err)
+	}
+	fmt.Printf("GF(2^%d), %s, %d bytes per chunk\n", f.N, gf2n.Format(f.Poly), masseyomura.ChunkSize(f))
+
+	alice, err := masseyomura.NewAlice(f)
+	if err != nil {
+		log.Fatalf("Error creating Alice: %v", err)
+	}
+	bob, err := masseyomura.NewBob(f)
+	if err != nil {
+		log.Fatalf("Error creating Bob: %v", err)
+	}
+
+	start := time.Now()
+	x1, err := alice.Encrypt(msg)
+	if err != nil {
+		log.Fatalf("Error in pass 1: %v", err)
+	}
+	x2, err := bob.Encrypt(x1)
+	if err != nil {
+		log.Fatalf("Error in pass 2: %v", err)
+	}
+	x3, err := alice.Decrypt(x2)
+	if err != nil {
+		log.Fatalf("Error in pass 3: %v", err)
+	}
+	received, err := bob.Decrypt(x3)
+	if err != nil {
+		log.Fatalf("Error decrypting: %v", err)
+	}
+	fmt.Printf("%d chunks passed three times in %s\n", len(x1), time.Since(start).Round(time.Millisecond))
+	if len(x1) > 0 {
+		fmt.Printf("x1[0] = %x\nx2[0] = %x\nx3[0] = %x\n", x1[0], x2[0], x3[0])
+	}
+
+	if err := os.WriteFile(outputFile, received, 0644); err != nil {
+		log.Fatalf("Error writing file: %v", err)
+	}
+	fmt.Printf("Bob wrote %s\n", outputFile)
+
+	if encryptedFile != "" {
+		cA, _ := masseyomura.GenerateKeys(f)
+		cB, _ := masseyomura.GenerateKeys(f)
+		if err := masseyomura.EncryptFile(inputFile, encryptedFile, f, cA, cB); err != nil {
+			log.Fatalf("Error encrypting file: %v", err)
+		}
+		fmt.Printf("Encrypted container written to %s\n", encryptedFile)
+	}
+}
+
+func benchmark(sizes string) {
+	fmt.Printf("%-6s %-14s %-14s %-14s %-14s\n", "n", "shamir exp", "GF(2^n) exp", "shamir KB/s", "GF(2^n) KB/s")
+	for _, s := range strings.Split(sizes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			log.Fatalf("Error parsing size %q: %v", s, err)
+		}
+		f, err := gf2n.Standard(n)
+		if err != nil {
+			log.Fatalf("Error choosing field: %v", err)
+		}
+		p, err := shamir.GeneratePrime(n)
+		if err != nil {
+			log.Fatalf("Error generating prime: %v", err)
+		}
+		c, _ := shamir.GenerateKeys(p)
+		e, _ := masseyomura.GenerateKeys(f)
+		x, _ := rand.Int(rand.Reader, p)
+		y, _ := rand.Int(rand.Reader, f.Order())
+
+		modp := testing.Benchmark(func(b *testing.B) {
+			for b.Loop() {
+				new(big.Int).Exp(x, c, p)
+			}
+		})
+		gf := testing.Benchmark(func(b *testing.B) {
+			for b.Loop() {
+				f.Exp(y, e)
+			}
+		})
+		// a chunk costs four exponentiations, two per party
+		fmt.Printf("%-6d %-14s %-14s %-14.1f %-14.1f\n", n,
+			time.Duration(modp.NsPerOp()), time.Duration(gf.NsPerOp()),
+			throughput(shamir.ChunkSize(p), modp.NsPerOp()),
+			throughput(masseyomura.ChunkSize(f), gf.NsPerOp()))
+	}
+}
+
+func throughput(chunk int, nsPerExp int64) float64 {
+	return float64(chunk) / 1024 / (4 * float64(nsPerExp) / 1e9)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"information-defending/internal/sss"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	splitCmd := flag.NewFlagSet("split", flag.ExitOnError)
+	verifyCmd := flag.NewFlagSet("verify", flag.ExitOnError)
+	combineCmd := flag.NewFlagSet("combine", flag.ExitOnError)
+
+	splitKey := splitCmd.String("key", "", "Private key file to back up (demo8, demo9 or demo10 .priv)")
+	splitT := splitCmd.Int("t", 3, "Shares needed to restore the key")
+	splitN := splitCmd.Int("n", 5, "Number of shares")
+	splitDir := splitCmd.String("dir", "shares", "Directory for the shares")
+	splitScheme := splitCmd.String("scheme", "pedersen", "Sharing scheme: pedersen (verifiable, over Z_q) or gf256")
+	splitQBits := splitCmd.Int("qbits", 256, "Size of the prime field for pedersen")
+	splitPBits := splitCmd.Int("pbits", 1024, "Size of the commitment group modulus for pedersen")
+
+	verifyShare := verifyCmd.String("share", "", "Share file")
+	verifyComm := verifyCmd.String("commitments", "", "Commitments file written by split")
+
+	combineShares := combineCmd.String("shares", "", "Comma-separated share files")
+	combineComm := combineCmd.String("commitments", "", "Commitments file (pedersen shares only)")
+	combineOutput := combineCmd.String("output", "", "Restored private key file")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "split":
+		splitCmd.Parse(os.Args[2:])
+		if *splitKey == "" {
+			fmt.Println("Error: key file is required")
+			splitCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		split(*splitKey, *splitT, *splitN, *splitDir, *splitScheme, *splitQBits, *splitPBits)
+	case "verify":
+		verifyCmd.Parse(os.Args[2:])
+		if *verifyShare == "" || *verifyComm == "" {
+			fmt.Println("Error: share and commitments files are required")
+			verifyCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		verify(*verifyShare, *verifyComm)
+	case "combine":
+		combineCmd.Parse(os.Args[2:])
+		if *combineShares == "" || *combineOutput == "" {
+			fmt.Println("Error: share files and output file are required")
+			combineCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		combine(strings.Split(*combineShares, ","), *combineComm, *combineOutput)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  split   - split a private key file into t-of-n shares")
+	fmt.Println("  verify  - check a share against the Pedersen commitments")
+	fmt.Println("  combine - restore the private key from t shares")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+// keyKind recognises the private key files of the other demos.
+func keyKind(data []byte) string {
+	if strings.HasPrefix(string(data), "-----BEGIN") {
+		return "RSA private key, PEM (demo8)"
+	}
+	fields := strings.Fields(string(data))
+	for _, f := range fields {
+		if _, ok := new(big.Int).SetString(f, 10); !ok {
+			return "unknown file"
+		}
+	}
+	switch len(fields) {
+	case 1:
+		return "ElGamal private key (demo9)"
+	case 2:
+		return "RSA private key (demo8)"
+	case 5:
+		return "GOST private key (demo10)"
+	}
+	return "unknown file"
+}
+
+func split(keyFile string, t, n int, dir, scheme string, qBits, pBits int) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		log.Fatalf("Error reading key: %v", err)
+	}
+	kind := keyKind(data)
+	if kind == "unknown file" {
+		fmt.Printf("Warning: %s is not a demo8, demo9 or demo10 private key, splitting it as is\n", keyFile)
+	} else {
+		fmt.Printf("Key: %s\n", kind)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Fatalf("Error creating directory: %v", err)
+	}
+	base := filepath.Join(dir, strings.TrimSuffix(filepath.Base(keyFile), filepath.Ext(keyFile)))
+
+	switch scheme {
+	case "gf256":
+		shares, err := sss.Split(data, t, n)
+		if err != nil {
+			log.Fatalf("Error splitting key: %v", err)
+		}
+		for _, s := range shares {
+			err := sss.WriteByteShare(fmt.Sprintf("%s.share%d", base, s.X), s)
+			if err != nil {
+				log.Fatalf("Error saving share: %v", err)
+			}
+		}
+	case "pedersen":
+		fmt.Printf("Generating %d-bit group with a %d-bit subgroup...\n", pBits, qBits)
+		g, err := sss.GenerateGroup(qBits, pBits)
+		if err != nil {
+			log.Fatalf("Error generating group: %v", err)
+		}
+		shares, comm, err := g.SplitBytes(data, t, n)
+		if err != nil {
+			log.Fatalf("Error splitting key: %v", err)
+		}
+		if err := sss.WriteCommitments(base+".commitments", g, comm); err != nil {
+			log.Fatalf("Error saving commitments: %v", err)
+		}
+		for _, s := range shares {
+			if err := sss.WriteShare(fmt.Sprintf("%s.share%d", base, s.X), s); err != nil {
+				log.Fatalf("Error saving share: %v", err)
+			}
+		}
+		fmt.Printf("Commitments: %s.commitments (public, give a copy to every holder)\n", base)
+	default:
+		log.Fatalf("Error: unknown scheme %q", scheme)
+	}
+
+	fmt.Printf("Split into %d shares, any %d restore the key\n", n, t)
+	fmt.Printf("Shares: %s.share*\n", base)
+	fmt.Printf("Hand the shares out and delete %s\n", keyFile)
+}
+
+func verify(shareFile, commFile string) {
+	g, comm, err := sss.ReadCommitments(commFile)
+	if err != nil {
+		log.Fatalf("Error loading commitments: %v", err)
+	}
+	s, err := sss.ReadShare(shareFile)
+	if err != nil {
+		log.Fatalf("Error loading share: %v", err)
+	}
+	if err := g.Verify(s, comm); err != nil {
+		fmt.Printf("Share %d is INVALID: %v\n", s.X, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Share %d is valid\n", s.X)
+}
+
+func combine(shareFiles []string, commFile, outputFile string) {
+	var data []byte
+	if commFile == "" {
+		var shares []sss.ByteShare
+		for _, file := range shareFiles {
+			s, err := sss.ReadByteShare(file)
+			if errors.Is(err, sss.ErrInvalidShare) {
+				fmt.Printf("Skipping %v\n", err)
+				continue
+			}
+			if err != nil {
+				log.Fatalf("Error loading share: %v", err)
+			}
+			shares = append(shares, s)
+		}
+		var err error
+		data, err = sss.Combine(shares)
+		if err != nil {
+			log.Fatalf("Error combining shares: %v", err)
+		}
+	} else {
+		g, comm, err := sss.ReadCommitments(commFile)
+		if err != nil {
+			log.Fatalf("Error loading commitments: %v", err)
+		}
+		var shares []sss.Share
+		for _, file := range shareFiles {
+			s, err := sss.ReadShare(file)
+			if err != nil {
+				log.Fatalf("Error loading share: %v", err)
+			}
+			if err := g.Verify(s, comm); err != nil {
+				fmt.Printf("Skipping %s: %v\n", file, err)
+				continue
+			}
+			shares = append(shares, s)
+		}
+		data, err = g.CombineBytes(shares, comm)
+		if err != nil {
+			log.Fatalf("Error combining shares: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(outputFile, data, 0600); err != nil {
+		log.Fatalf("Error writing key: %v", err)
+	}
+	fmt.Printf("Restored %s to %s\n", keyKind(data), outputFile)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/container"
+	"information-defending/internal/vernam"
+	"log"
+	"os"
+)
+
+func main() {
+	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
+
+	generatePad := generateCmd.String("pad", "otp.pad", "Pad file to create")
+	generateSize := generateCmd.Int64("size", 1<<20, "Pad size in bytes")
+
+	encryptPad := encryptCmd.String("pad", "otp.pad", "Pad file")
+	encryptParty := encryptCmd.String("party", "A", "Your side of the pad: A uses it from the front, B from the back")
+	encryptInput := encryptCmd.String("input", "", "Input file to encrypt")
+	encryptOutput := encryptCmd.String("output", "", "Output encrypted file")
+	encryptMAC := encryptCmd.String("mac", "hmac-sha256", "Message authentication: hmac-sha256, hmac-streebog or gost28147")
+
+	decryptPad := decryptCmd.String("pad", "otp.pad", "Pad file")
+	decryptParty := decryptCmd.String("party", "B", "Your side of the pad")
+	decryptInput := decryptCmd.String("input", "", "Input encrypted file")
+	decryptOutput := decryptCmd.String("output", "", "Output decrypted file")
+
+	statusPad := statusCmd.String("pad", "otp.pad", "Pad file")
+	statusParty := statusCmd.String("party", "A", "Your side of the pad")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "generate":
+		generateCmd.Parse(os.Args[2:])
+		if err := vernam.GeneratePad(*generatePad, *generateSize); err != nil {
+			log.Fatalf("Error generating pad: %v", err)
+		}
+		fmt.Printf("Pad of %d bytes saved to %s\n", *generateSize, *generatePad)
+		fmt.Println("Give an identical copy to the other party; one of you is A, the other B")
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		if *encryptInput == "" || *encryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			encryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		m, err := container.ParseMAC(*encryptMAC)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		pad := openPad(*encryptPad, *encryptParty)
+		defer pad.Close()
+		pad.MAC = m
+		if err := pad.EncryptFile(*encryptInput, *encryptOutput); err != nil {
+			log.Fatalf("Error encrypting file: %v", err)
+		}
+		fmt.Printf("File encrypted: %s\n", *encryptOutput)
+		fmt.Printf("Pad left for sending: %d bytes\n", pad.Remaining())
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		if *decryptInput == "" || *decryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			decryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		pad := openPad(*decryptPad, *decryptParty)
+		defer pad.Close()
+		if err := pad.DecryptFile(*decryptInput, *decryptOutput); err != nil {
+			log.Fatalf("Error decrypting file: %v", err)
+		}
+		fmt.Printf("File decrypted: %s\n", *decryptOutput)
+	case "status":
+		statusCmd.Parse(os.Args[2:])
+		pad := openPad(*statusPad, *statusParty)
+		defer pad.Close()
+		fmt.Printf("Pad size:             %d bytes\n", pad.Size())
+		fmt.Printf("Pad left for sending: %d bytes\n", pad.Remaining())
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  generate - create a random one-time pad")
+	fmt.Println("  encrypt  - encrypt a file with unused pad bytes")
+	fmt.Println("  decrypt  - decrypt a file from the other party")
+	fmt.Println("  status   - show how much of the pad is left")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func openPad(filename, party string) *vernam.Pad {
+	p, err := vernam.ParseParty(party)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	pad, err := vernam.OpenPad(filename, p)
+	if err != nil {
+		log.Fatalf("Error opening pad: %v", err)
+	}
+	return pad
+}
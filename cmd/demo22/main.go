@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"information-defending/internal/attack"
+	"information-defending/internal/container"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	setupCmd := flag.NewFlagSet("setup", flag.ExitOnError)
+	singleCmd := flag.NewFlagSet("single", flag.ExitOnError)
+	repeatingCmd := flag.NewFlagSet("repeating", flag.ExitOnError)
+	cribCmd := flag.NewFlagSet("crib", flag.ExitOnError)
+	manyCmd := flag.NewFlagSet("many", flag.ExitOnError)
+
+	setupMode := setupCmd.String("mode", "pad", "How to misuse the key: pad (one pad for all files), repeating or single")
+	setupInputs := setupCmd.String("inputs", "", "Comma-separated plaintext files")
+	setupKey := setupCmd.String("key", "", "Key for repeating or single mode (default: random)")
+	setupDir := setupCmd.String("dir", "xor", "Directory for the ciphertexts")
+
+	singleInput := singleCmd.String("input", "", "Ciphertext (raw, demo7 container or old text format)")
+	singleLang := singleCmd.String("lang", "auto", "Plaintext language: auto, en, ru-utf8 or ru-cp1251")
+	singleOutput := singleCmd.String("output", "", "Where to write the plaintext")
+
+	repeatingInput := repeatingCmd.String("input", "", "Ciphertext")
+	repeatingLang := repeatingCmd.String("lang", "auto", "Plaintext language: auto, en, ru-utf8 or ru-cp1251")
+	repeatingMax := repeatingCmd.Int("max-key", 40, "Longest key length to try")
+	repeatingOutput := repeatingCmd.String("output", "", "Where to write the plaintext")
+
+	cribInputs := cribCmd.String("inputs", "", "Comma-separated ciphertexts under the same pad")
+	cribText := cribCmd.String("crib", "", "Text guessed to be in one of the messages")
+	cribLang := cribCmd.String("lang", "en", "Plaintext language: en, ru-utf8 or ru-cp1251")
+	cribTop := cribCmd.Int("top", 10, "Number of positions to show")
+
+	manyInputs := manyCmd.String("inputs", "", "Comma-separated ciphertexts under the same pad")
+	manyLang := manyCmd.String("lang", "en", "Plaintext language: en, ru-utf8 or ru-cp1251")
+	manyInteractive := manyCmd.Bool("interactive", false, "Drag cribs by hand after the automatic attack")
+	manyDir := manyCmd.String("dir", "", "Write recovered plaintexts to this directory")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "setup":
+		setupCmd.Parse(os.Args[2:])
+		if *setupInputs == "" {
+			fmt.Println("Error: input files are required")
+			setupCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		setup(*setupMode, strings.Split(*setupInputs, ","), *setupKey, *setupDir)
+	case "single":
+		singleCmd.Parse(os.Args[2:])
+		if *singleInput == "" {
+			fmt.Println("Error: input file is required")
+			singleCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		single(*singleInput, *singleLang, *singleOutput)
+	case "repeating":
+		repeatingCmd.Parse(os.Args[2:])
+		if *repeatingInput == "" {
+			fmt.Println("Error: input file is required")
+			repeatingCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		repeating(*repeatingInput, *repeatingLang, *repeatingMax, *repeatingOutput)
+	case "crib":
+		cribCmd.Parse(os.Args[2:])
+		if *cribInputs == "" || *cribText == "" {
+			fmt.Println("Error: input files and crib are required")
+			cribCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		l := language(*cribLang)
+		crib(readAll(strings.Split(*cribInputs, ",")), l.Encode(*cribText), l, *cribTop)
+	case "many":
+		manyCmd.Parse(os.Args[2:])
+		if *manyInputs == "" {
+			fmt.Println("Error: input files are required")
+			manyCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		many(strings.Split(*manyInputs, ","), *manyLang, *manyInteractive, *manyDir)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  setup     - encrypt plaintexts with a reused pad or a short XOR key")
+	fmt.Println("  single    - break a single-byte XOR key, such as demo7 output")
+	fmt.Println("  repeating - break a repeating-key XOR cipher")
+	fmt.Println("  crib      - drag a guessed word through messages under one pad")
+	fmt.Println("  many      - recover messages under one pad automatically or interactively")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func language(name string) *attack.Language {
+	l, err := attack.LanguageByName(name)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return l
+}
+
+// readCiphertext accepts raw bytes, the blocks of a container or the old
+// text format with one decimal byte per line.
+func readCiphertext(filename string) ([]byte, *container.Header) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+	if container.IsContainer(data) {
+		cr, err := container.NewReader(strings.NewReader(string(data)))
+		if err != nil {
+			log.Fatalf("Error reading container: %v", err)
+		}
+		var out []byte
+		for {
+			b, err := cr.ReadBlock()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				log.Fatalf("Error reading container: %v", err)
+			}
+			out = append(out, b...)
+		}
+		h := cr.Header()
+		return out[:min(uint64(len(out)), cr.Len())], &h
+	}
+
+	var out []byte
+	for _, f := range strings.Fields(string(data)) {
+		v, err := strconv.ParseUint(f, 10, 8)
+		if err != nil {
+			return data, nil
+		}
+		out = append(out, byte(v))
+	}
+	if len(out) == 0 {
+		return data, nil
+	}
+	return out, nil
+}
+
+// solve breaks c under the named model or, for "auto", under each of
+// them, keeping the key whose plaintext the model likes best.
+func solve(c []byte, lang string, fn func(*attack.Language) ([]byte, error)) ([]byte, *attack.Language) {
+	langs := attack.Languages
+	if lang != "auto" {
+		langs = []*attack.Language{language(lang)}
+	}
+	var key []byte
+	var l *attack.Language
+	best := 0.0
+	for _, cand := range langs {
+		k, err := fn(cand)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if s := cand.Score(attack.XOR(c, k)); l == nil || s > best {
+			key, l, best = k, cand, s
+		}
+	}
+	if lang == "auto" {
+		fmt.Printf("Language: %s\n", l.Name)
+	}
+	return key, l
+}
+
+func readAll(files []string) [][]byte {
+	var cts [][]byte
+	for _, f := range files {
+		c, _ := readCiphertext(f)
+		cts = append(cts, c)
+	}
+	return cts
+}
+
+func setup(mode string, inputs []string, key, dir string) {
+	var pts [][]byte
+	n := 0
+	for _, f := range inputs {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			log.Fatalf("Error reading file: %v", err)
+		}
+		pts = append(pts, data)
+		n = max(n, len(data))
+	}
+
+	k := []byte(key)
+	switch {
+	case mode == "pad":
+		k = make([]byte, n)
+		rand.Read(k)
+	case mode == "single" && len(k) == 0:
+		k = make([]byte, 1)
+		rand.Read(k)
+	case mode == "single":
+		k = k[:1]
+	case mode == "repeating" && len(k) == 0:
+		k = make([]byte, 7)
+		rand.Read(k)
+	case mode != "repeating":
+		log.Fatalf("Error: unknown mode %q", mode)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Error creating directory: %v", err)
+	}
+	for i, p := range pts {
+		out := filepath.Join(dir, filepath.Base(inputs[i])+".xor")
+		if err := os.WriteFile(out, attack.XOR(p, k), 0644); err != nil {
+			log.Fatalf("Error writing file: %v", err)
+		}
+		fmt.Printf("Encrypted: %s\n", out)
+	}
+	if mode != "pad" {
+		fmt.Printf("Key: %x\n", k)
+	}
+}
+
+func single(inputFile, lang, outputFile string) {
+	c, h := readCiphertext(inputFile)
+	key, l := solve(c, lang, func(l *attack.Language) ([]byte, error) {
+		k, _ := attack.SingleByteXOR(c, l)
+		return []byte{k}, nil
+	})
+	k := key[0]
+	if h != nil && h.Algorithm == container.AlgVernam {
+		// older demo7 files carry a hash of the one-byte key, which gives it away too
+		for x := range 256 {
+			if container.Fingerprint(big.NewInt(int64(x))) == h.Fingerprint {
+				fmt.Printf("Key from the header fingerprint: %d\n", x)
+				k = byte(x)
+			}
+		}
+	}
+	fmt.Printf("Key: %d (0x%02x)\n", k, k)
+	report(attack.XOR(c, []byte{k}), l, outputFile)
+}
+
+func repeating(inputFile, lang string, maxLen int, outputFile string) {
+	c, _ := readCiphertext(inputFile)
+	sizes := attack.KeySizes(c, 1, maxLen)
+	fmt.Println("Likely key lengths:")
+	for _, ks := range sizes[:min(len(sizes), 5)] {
+		fmt.Printf("  %3d  %.3f bits/byte\n", ks.Length, ks.Distance)
+	}
+
+	key, l := solve(c, lang, func(l *attack.Language) ([]byte, error) {
+		return attack.RepeatingKeyXOR(c, l, maxLen)
+	})
+	fmt.Printf("Key (%d bytes): %x %q\n", len(key), key, key)
+	report(attack.XOR(c, key), l, outputFile)
+}
+
+func report(plain []byte, l *attack.Language, outputFile string) {
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, plain, 0644); err != nil {
+			log.Fatalf("Error writing file: %v", err)
+		}
+		fmt.Printf("Plaintext saved to: %s\n", outputFile)
+		return
+	}
+	text := l.Decode(plain)
+	if r := []rune(text); len(r) > 500 {
+		text = string(r[:500]) + "..."
+	}
+	fmt.Println(text)
+}
+
+func crib(cts [][]byte, word []byte, l *attack.Language, top int) {
+	hits := attack.CribDrag(cts, word, l)
+	for _, h := range hits[:min(top, len(hits))] {
+		fmt.Printf("message %d, position %d, score %.2f\n", h.Ciphertext, h.Pos, h.Score)
+		for j, c := range cts {
+			if j == h.Ciphertext || h.Pos+len(word) > len(c) {
+				continue
+			}
+			fmt.Printf("    %d: %q\n", j, l.Decode(attack.XOR(c[h.Pos:h.Pos+len(word)], h.Key)))
+		}
+	}
+}
+
+func many(files []string, lang string, interactive bool, dir string) {
+	cts := readAll(files)
+	l := language(lang)
+	key := attack.Refine(cts, attack.ManyTimePad(cts, l), l)
+	show(cts, key, l)
+
+	if interactive {
+		key = session(cts, key, l)
+	}
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Error creating directory: %v", err)
+	}
+	for i, c := range cts {
+		out := filepath.Join(dir, strings.TrimSuffix(filepath.Base(files[i]), ".xor")+".txt")
+		if err := os.WriteFile(out, attack.XOR(c, key[:len(c)]), 0644); err != nil {
+			log.Fatalf("Error writing file: %v", err)
+		}
+		fmt.Printf("Recovered: %s\n", out)
+	}
+}
+
+func show(cts [][]byte, key []byte, l *attack.Language) {
+	for i, c := range cts {
+		fmt.Printf("%2d: %s\n", i, l.Decode(attack.XOR(c, key[:len(c)])))
+	}
+}
+
+// session reads commands from stdin:
+//
+//	<message> <position> <text>   the plaintext there is text
+//	crib <text>                   show where text fits best
+//	auto                          drag the common words again
+//	show                          print the messages
+//	quit
+func session(cts [][]byte, key []byte, l *attack.Language) []byte {
+	fmt.Println("Commands: <message> <position> <text>, crib <text>, auto, show, quit")
+	sc := bufio.NewScanner(os.Stdin)
+	for fmt.Print("> "); sc.Scan(); fmt.Print("> ") {
+		line := sc.Text()
+		cmd, rest, _ := strings.Cut(line, " ")
+		switch cmd {
+		case "":
+		case "quit", "exit":
+			return key
+		case "show":
+			show(cts, key, l)
+		case "auto":
+			key = attack.Refine(cts, key, l)
+			show(cts, key, l)
+		case "crib":
+			crib(cts, l.Encode(rest), l, 5)
+		default:
+			i, err1 := strconv.Atoi(cmd)
+			pos, text, _ := strings.Cut(rest, " ")
+			p, err2 := strconv.Atoi(pos)
+			if err1 != nil || err2 != nil || i < 0 || i >= len(cts) {
+				fmt.Println("Unknown command")
+				continue
+			}
+			b := l.Encode(text)
+			if p < 0 || p+len(b) > len(cts[i]) {
+				fmt.Println("Text does not fit in the message")
+				continue
+			}
+			copy(key[p:], attack.XOR(cts[i][p:p+len(b)], b))
+			show(cts, key, l)
+		}
+	}
+	return key
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/attack"
+	"information-defending/internal/vernam"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	keyCmd := flag.NewFlagSet("key", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	breakCmd := flag.NewFlagSet("break", flag.ExitOnError)
+
+	keyAlphabet := keyCmd.String("alphabet", "ru", "Alphabet: ru (33 letters), en or the letters of a custom one")
+	keyLength := keyCmd.Int("length", 0, "Key length (default: letters in -input, for a one-time key)")
+	keyInput := keyCmd.String("input", "", "Message the one-time key is for")
+	keyOutput := keyCmd.String("output", "vigenere.key", "File to save the key")
+
+	encryptAlphabet := encryptCmd.String("alphabet", "ru", "Alphabet: ru (33 letters), en or the letters of a custom one")
+	encryptKey := encryptCmd.String("key", "", "Key text")
+	encryptKeyFile := encryptCmd.String("key-file", "", "File with the key")
+	encryptPolicy := encryptCmd.String("policy", "keep", "Characters outside the alphabet: keep or drop")
+	encryptInput := encryptCmd.String("input", "", "Input text file")
+	encryptOutput := encryptCmd.String("output", "", "Output encrypted file")
+
+	decryptAlphabet := decryptCmd.String("alphabet", "ru", "Alphabet: ru (33 letters), en or the letters of a custom one")
+	decryptKey := decryptCmd.String("key", "", "Key text")
+	decryptKeyFile := decryptCmd.String("key-file", "", "File with the key")
+	decryptInput := decryptCmd.String("input", "", "Input encrypted file")
+	decryptOutput := decryptCmd.String("output", "", "Output decrypted file")
+
+	breakAlphabet := breakCmd.String("alphabet", "ru", "Alphabet: ru (33 letters), en or the letters of a custom one")
+	breakInput := breakCmd.String("input", "", "Ciphertext under a repeating key")
+	breakMax := breakCmd.Int("max-key", 30, "Longest key length to try")
+	breakOutput := breakCmd.String("output", "", "Where to write the plaintext")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "key":
+		keyCmd.Parse(os.Args[2:])
+		if *keyLength == 0 && *keyInput == "" {
+			fmt.Println("Error: key length or input file is required")
+			keyCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		generateKey(alphabet(*keyAlphabet), *keyLength, *keyInput, *keyOutput)
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		if *encryptInput == "" || *encryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			encryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		policy, err := vernam.ParsePolicy(*encryptPolicy)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		v := cipher(alphabet(*encryptAlphabet), *encryptKey, *encryptKeyFile, policy)
+		if err := v.EncryptFile(*encryptInput, *encryptOutput); err != nil {
+			log.Fatalf("Error encrypting file: %v", err)
+		}
+		fmt.Printf("File encrypted: %s\n", *encryptOutput)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		if *decryptInput == "" || *decryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			decryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		v := cipher(alphabet(*decryptAlphabet), *decryptKey, *decryptKeyFile, vernam.Keep)
+		if err := v.DecryptFile(*decryptInput, *decryptOutput); err != nil {
+			log.Fatalf("Error decrypting file: %v", err)
+		}
+		fmt.Printf("File decrypted: %s\n", *decryptOutput)
+	case "break":
+		breakCmd.Parse(os.Args[2:])
+		if *breakInput == "" {
+			fmt.Println("Error: input file is required")
+			breakCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		breakKey(alphabet(*breakAlphabet), *breakInput, *breakMax, *breakOutput)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  key     - generate a random key over the alphabet")
+	fmt.Println("  encrypt - encrypt text letter by letter")
+	fmt.Println("  decrypt - decrypt text letter by letter")
+	fmt.Println("  break   - find a repeating key with Kasiski and the index of coincidence")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func alphabet(name string) *vernam.Alphabet {
+	a, err := vernam.AlphabetByName(name)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return a
+}
+
+func cipher(a *vernam.Alphabet, key, keyFile string, policy vernam.Policy) *vernam.Vigenere {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			log.Fatalf("Error reading key: %v", err)
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	if key == "" {
+		log.Fatalf("Error: -key or -key-file is required")
+	}
+	v, err := vernam.NewVigenere(a, key, policy)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return v
+}
+
+func generateKey(a *vernam.Alphabet, n int, inputFile, outputFile string) {
+	if inputFile != "" {
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			log.Fatalf("Error reading file: %v", err)
+		}
+		n = 0
+		for _, r := range string(data) {
+			if _, _, ok := a.Index(r); ok {
+				n++
+			}
+		}
+	}
+	key, err := vernam.RandomKey(a, n)
+	if err != nil {
+		log.Fatalf("Error generating key: %v", err)
+	}
+	if err := os.WriteFile(outputFile, []byte(key+"\n"), 0600); err != nil {
+		log.Fatalf("Error saving key: %v", err)
+	}
+	fmt.Printf("Key of %d letters saved to %s\n", n, outputFile)
+}
+
+func breakKey(a *vernam.Alphabet, inputFile string, maxLen int, outputFile string) {
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+	text := string(data)
+
+	lengths := attack.VigenereKeyLengths(text, a, maxLen)
+	fmt.Printf("%-8s %-8s %s\n", "length", "IC", "Kasiski")
+	for _, l := range lengths[:min(len(lengths), 5)] {
+		fmt.Printf("%-8d %-8.4f %d\n", l.Length, l.IC, l.Kasiski)
+	}
+	key, err := attack.BreakVigenere(text, a, maxLen)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	fmt.Printf("Key: %s\n", key)
+
+	v, err := vernam.NewVigenere(a, key, vernam.Keep)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	plain := v.Decrypt(text)
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, []byte(plain), 0644); err != nil {
+			log.Fatalf("Error writing file: %v", err)
+		}
+		fmt.Printf("Plaintext saved to: %s\n", outputFile)
+		return
+	}
+	if r := []rune(plain); len(r) > 500 {
+		plain = string(r[:500]) + "..."
+	}
+	fmt.Println(plain)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"information-defending/internal/attack"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/kdf"
+	"information-defending/internal/keystream"
+	"information-defending/internal/vernam"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	dhCmd := flag.NewFlagSet("dh", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	attackCmd := flag.NewFlagSet("attack", flag.ExitOnError)
+
+	dhOutput := dhCmd.String("output", "shared.key", "File to save the shared key")
+
+	encryptGen := encryptCmd.String("gen", "gost", "Keystream generator: lfsr, bbs or gost")
+	encryptSecret := encryptCmd.String("secret", "", "Shared secret text")
+	encryptSecretFile := encryptCmd.String("secret-file", "", "File with the shared secret")
+	encryptTaps := encryptCmd.String("taps", "", "LFSR polynomial exponents, e.g. 16,14,13,11 (default: 64,63,61,60)")
+	encryptBits := encryptCmd.Int("bits", 1024, "BBS modulus size")
+	encryptMAC := encryptCmd.String("mac", "hmac-sha256", "Message authentication: hmac-sha256, hmac-streebog or gost28147")
+	encryptPassword := encryptCmd.String("password", "", "Passphrase instead of a shared secret")
+	encryptPasswordFile := encryptCmd.String("password-file", "", "File with the passphrase")
+	encryptKDF := encryptCmd.String("kdf", "scrypt", "Password key derivation: pbkdf2-sha256, scrypt or pbkdf2-streebog")
+	encryptCost := encryptCmd.Uint("cost", 0, "KDF cost: PBKDF2 iterations or log2 N for scrypt (default: recommended)")
+	encryptInput := encryptCmd.String("input", "", "Input file to encrypt")
+	encryptOutput := encryptCmd.String("output", "", "Output encrypted file")
+
+	decryptSecret := decryptCmd.String("secret", "", "Shared secret text")
+	decryptSecretFile := decryptCmd.String("secret-file", "", "File with the shared secret")
+	decryptPassword := decryptCmd.String("password", "", "Passphrase the file was encrypted with")
+	decryptPasswordFile := decryptCmd.String("password-file", "", "File with the passphrase")
+	decryptInput := decryptCmd.String("input", "", "Input encrypted file")
+	decryptOutput := decryptCmd.String("output", "", "Output decrypted file")
+
+	attackInput := attackCmd.String("input", "", "File encrypted with an LFSR keystream")
+	attackKnown := attackCmd.String("known", "", "File with the known start of the plaintext")
+	attackOutput := attackCmd.String("output", "", "Output decrypted file")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "dh":
+		dhCmd.Parse(os.Args[2:])
+		dh(*dhOutput)
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		if *encryptInput == "" || *encryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			encryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		spec := generator(*encryptGen, *encryptTaps, *encryptBits)
+		m, err := container.ParseMAC(*encryptMAC)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if pass := password(*encryptPassword, *encryptPasswordFile); pass != nil {
+			p := kdfParams(*encryptKDF, *encryptCost)
+			err = vernam.EncryptPasswordFile(*encryptInput, *encryptOutput, spec, pass, p, m)
+		} else {
+			err = vernam.EncryptKeystreamFile(*encryptInput, *encryptOutput, spec, secret(*encryptSecret, *encryptSecretFile), m)
+		}
+		if err != nil {
+			log.Fatalf("Error encrypting file: %v", err)
+		}
+		fmt.Printf("File encrypted with the %s keystream and %s: %s\n", spec.Kind, m, *encryptOutput)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		if *decryptInput == "" || *decryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			decryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		var err error
+		if pass := password(*decryptPassword, *decryptPasswordFile); pass != nil {
+			err = vernam.DecryptPasswordFile(*decryptInput, *decryptOutput, pass)
+		} else {
+			err = vernam.DecryptKeystreamFile(*decryptInput, *decryptOutput, secret(*decryptSecret, *decryptSecretFile))
+		}
+		if err != nil {
+			log.Fatalf("Error decrypting file: %v", err)
+		}
+		fmt.Printf("File decrypted: %s\n", *decryptOutput)
+	case "attack":
+		attackCmd.Parse(os.Args[2:])
+		if *attackInput == "" || *attackKnown == "" || *attackOutput == "" {
+			fmt.Println("Error: input, known plaintext and output files are required")
+			attackCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		breakLFSR(*attackInput, *attackKnown, *attackOutput)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  dh      - agree on a short shared key with Diffie-Hellman")
+	fmt.Println("  encrypt - encrypt a file with a keystream seeded from the shared key")
+	fmt.Println("  decrypt - decrypt a file with the shared key")
+	fmt.Println("  attack  - recover an LFSR keystream from known plaintext (Berlekamp-Massey)")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func dh(outputFile string) {
+	p, g, a, b, k := crypto.RandDiffieHellman()
+	fmt.Printf("p = %d, g = %d, a = %d, b = %d, K = %d\n", p, g, a, b, k)
+	if err := os.WriteFile(outputFile, []byte(strconv.FormatInt(k, 10)), 0600); err != nil {
+		log.Fatalf("Error saving key: %v", err)
+	}
+	fmt.Printf("Shared key saved to %s\n", outputFile)
+}
+
+func secret(text, file string) []byte {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error reading secret: %v", err)
+		}
+		return []byte(strings.TrimSpace(string(data)))
+	}
+	if text == "" {
+		log.Fatalf("Error: -secret or -secret-file is required")
+	}
+	return []byte(text)
+}
+
+// password returns nil when neither flag is set. Only the line break at
+// the end of a file is dropped; other spaces belong to the passphrase.
+func password(text, file string) []byte {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error reading password: %v", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n"))
+	}
+	if text == "" {
+		return nil
+	}
+	return []byte(text)
+}
+
+func kdfParams(name string, cost uint) kdf.Params {
+	alg, err := kdf.ParseAlg(name)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	p, err := kdf.New(alg, uint32(cost))
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return p
+}
+
+func generator(name, taps string, bits int) keystream.Spec {
+	kind, err := keystream.ParseKind(name)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	switch kind {
+	case keystream.KindLFSR:
+		if taps == "" {
+			return keystream.DefaultLFSR
+		}
+		spec := keystream.Spec{Kind: kind}
+		for _, t := range strings.Split(taps, ",") {
+			i, err := strconv.Atoi(strings.TrimSpace(t))
+			if err != nil || i < 1 || i > 64 {
+				log.Fatalf("Error: bad LFSR exponent %q", t)
+			}
+			spec.Taps |= 1 << (i - 1)
+			spec.Length = max(spec.Length, i)
+		}
+		return spec
+	case keystream.KindBBS:
+		fmt.Printf("Generating %d-bit BBS modulus...\n", bits)
+		N, err := keystream.GenerateBBSModulus(bits)
+		if err != nil {
+			log.Fatalf("Error generating modulus: %v", err)
+		}
+		return keystream.Spec{Kind: kind, N: N}
+	}
+	return keystream.Spec{Kind: kind}
+}
+
+func breakLFSR(inputFile, knownFile, outputFile string) {
+	f, err := os.Open(inputFile)
+	if err != nil {
+		log.Fatalf("Error opening file: %v", err)
+	}
+	defer f.Close()
+	cr, err := container.NewReader(f)
+	if err != nil {
+		log.Fatalf("Error reading container: %v", err)
+	}
+	var c []byte
+	for {
+		b, err := cr.ReadBlock()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Fatalf("Error reading container: %v", err)
+		}
+		c = append(c, b...)
+	}
+
+	known, err := os.ReadFile(knownFile)
+	if err != nil {
+		log.Fatalf("Error reading known plaintext: %v", err)
+	}
+	n := min(len(known), len(c))
+	ks := make([]byte, n)
+	for i := range ks {
+		ks[i] = c[i] ^ known[i]
+	}
+
+	lfsr, err := attack.RecoverLFSR(ks)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	fmt.Printf("Recovered LFSR of length %d from %d known bytes, taps %#x\n", lfsr.Length, n, lfsr.Taps)
+	lfsr.XORKeyStream(c, c)
+	if err := os.WriteFile(outputFile, c, 0644); err != nil {
+		log.Fatalf("Error writing file: %v", err)
+	}
+	fmt.Printf("File decrypted without the key: %s\n", outputFile)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"information-defending/internal/gost3412"
+	"information-defending/internal/gost3413"
+	"information-defending/internal/kdf"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gogost3412 "github.com/ftomza/gogost/gost3412128"
+	"github.com/ftomza/gogost/gost341264"
+	gogost3413 "github.com/ftomza/gogost/gost3413"
+)
+
+func main() {
+	selftestCmd := flag.NewFlagSet("selftest", flag.ExitOnError)
+	keygenCmd := flag.NewFlagSet("keygen", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	macCmd := flag.NewFlagSet("mac", flag.ExitOnError)
+	benchCmd := flag.NewFlagSet("bench", flag.ExitOnError)
+	calibrateCmd := flag.NewFlagSet("calibrate", flag.ExitOnError)
+
+	selftestRounds := selftestCmd.Int("n", 1000, "Random inputs to compare with gogost and crypto/cipher")
+
+	keygenOutput := keygenCmd.String("output", "gost.key", "File to save the 256-bit key (hex)")
+
+	encryptCipher := encryptCmd.String("cipher", "kuznyechik", "Block cipher: kuznyechik or magma")
+	encryptMode := encryptCmd.String("mode", "ctr", "Mode: ecb, cbc, ctr, ofb or cfb")
+	encryptKey := encryptCmd.String("key", "gost.key", "Key file")
+	encryptPassword := encryptCmd.String("password", "", "Passphrase instead of a key file")
+	encryptPasswordFile := encryptCmd.String("password-file", "", "File with the passphrase")
+	encryptKDF := encryptCmd.String("kdf", "pbkdf2-streebog", "Password key derivation: pbkdf2-sha256, scrypt or pbkdf2-streebog")
+	encryptCost := encryptCmd.Uint("cost", 0, "KDF cost: PBKDF2 iterations or log2 N for scrypt (default: recommended)")
+	encryptInput := encryptCmd.String("input", "", "Input file to encrypt")
+	encryptOutput := encryptCmd.String("output", "", "Output encrypted file")
+
+	decryptKey := decryptCmd.String("key", "gost.key", "Key file")
+	decryptPassword := decryptCmd.String("password", "", "Passphrase the file was encrypted with")
+	decryptPasswordFile := decryptCmd.String("password-file", "", "File with the passphrase")
+	decryptInput := decryptCmd.String("input", "", "Input encrypted file")
+	decryptOutput := decryptCmd.String("output", "", "Output decrypted file")
+
+	macCipher := macCmd.String("cipher", "kuznyechik", "Block cipher: kuznyechik or magma")
+	macKey := macCmd.String("key", "gost.key", "Key file")
+	macSize := macCmd.Int("size", 0, "MAC size in bytes (default: half a block)")
+	macInput := macCmd.String("input", "", "File to authenticate")
+	macVerify := macCmd.String("verify", "", "Expected MAC (hex) to check instead of printing")
+
+	benchSize := benchCmd.Int("size", 1<<20, "Bytes per run")
+
+	calibrateKDF := calibrateCmd.String("kdf", "scrypt", "Password key derivation: pbkdf2-sha256, scrypt or pbkdf2-streebog")
+	calibrateTime := calibrateCmd.Duration("time", time.Second, "Time one key derivation should take")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	switch os.Args[1] {
+	case "selftest":
+		selftestCmd.Parse(os.Args[2:])
+		selftest(*selftestRounds)
+	case "keygen":
+		keygenCmd.Parse(os.Args[2:])
+		key := make([]byte, gost3412.KeySize)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("Error generating key: %v", err)
+		}
+		if err := os.WriteFile(*keygenOutput, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+			log.Fatalf("Error saving key: %v", err)
+		}
+		fmt.Printf("Key saved to %s\n", *keygenOutput)
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		if *encryptInput == "" || *encryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			encryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		alg, err := gost3413.ParseCipher(*encryptCipher)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		mode, err := gost3413.ParseMode(*encryptMode)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if pass := password(*encryptPassword, *encryptPasswordFile); pass != nil {
+			p := kdfParams(*encryptKDF, *encryptCost)
+			err = gost3413.EncryptPasswordFile(*encryptInput, *encryptOutput, alg, mode, pass, p)
+		} else {
+			err = gost3413.EncryptFile(*encryptInput, *encryptOutput, alg, mode, readKey(*encryptKey))
+		}
+		if err != nil {
+			log.Fatalf("Error encrypting file: %v", err)
+		}
+		fmt.Printf("File encrypted with %s-%s and its MAC: %s\n", alg, mode, *encryptOutput)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		if *decryptInput == "" || *decryptOutput == "" {
+			fmt.Println("Error: input and output files are required")
+			decryptCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		var err error
+		if pass := password(*decryptPassword, *decryptPasswordFile); pass != nil {
+			err = gost3413.DecryptPasswordFile(*decryptInput, *decryptOutput, pass)
+		} else {
+			err = gost3413.DecryptFile(*decryptInput, *decryptOutput, readKey(*decryptKey))
+		}
+		if err != nil {
+			log.Fatalf("Error decrypting file: %v", err)
+		}
+		fmt.Printf("File decrypted: %s\n", *decryptOutput)
+	case "mac":
+		macCmd.Parse(os.Args[2:])
+		if *macInput == "" {
+			fmt.Println("Error: input file is required")
+			macCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		mac(*macCipher, *macKey, *macSize, *macInput, *macVerify)
+	case "bench":
+		benchCmd.Parse(os.Args[2:])
+		benchmark(*benchSize)
+	case "calibrate":
+		calibrateCmd.Parse(os.Args[2:])
+		alg, err := kdf.ParseAlg(*calibrateKDF)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		cost, err := kdf.Calibrate(alg, *calibrateTime)
+		if err != nil {
+			log.Fatalf("Error calibrating: %v", err)
+		}
+		fmt.Printf("%s: use -cost %d for about %s per key\n", alg, cost, *calibrateTime)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  selftest  - check Magma, Kuznyechik and their modes against the standards")
+	fmt.Println("  keygen    - generate a random 256-bit key")
+	fmt.Println("  encrypt   - encrypt a file with a key or a password (GOST R 34.13-2015 mode + MAC)")
+	fmt.Println("  decrypt   - decrypt a file")
+	fmt.Println("  mac       - compute or verify the GOST R 34.13-2015 MAC of a file")
+	fmt.Println("  bench     - compare speed with gogost")
+	fmt.Println("  calibrate - find the password KDF cost for a given time")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+// password returns nil when neither flag is set. Only the line break at
+// the end of a file is dropped; other spaces belong to the passphrase.
+func password(text, file string) []byte {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("Error reading password: %v", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n"))
+	}
+	if text == "" {
+		return nil
+	}
+	return []byte(text)
+}
+
+func kdfParams(name string, cost uint) kdf.Params {
+	alg, err := kdf.ParseAlg(name)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	p, err := kdf.New(alg, uint32(cost))
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return p
+}
+
+func readKey(file string) []byte {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatalf("Error reading key: %v", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != gost3412.KeySize {
+		log.Fatalf("Error: %s must hold %d hex bytes", file, gost3412.KeySize)
+	}
+	return key
+}
+
+func newBlock(name string, key []byte) cipher.Block {
+	alg, err := gost3413.ParseCipher(name)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	b, err := gost3413.NewCipher(alg, key)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return b
+}
+
+func selftest(rounds int) {
+	if err := gost3413.SelfTest(); err != nil {
+		log.Fatalf("Self-test failed: %v", err)
+	}
+	fmt.Println("GOST R 34.12-2015 examples: OK")
+	fmt.Println("GOST R 34.13-2015 examples (ECB, CBC, CTR, OFB, CFB, MAC): OK")
+
+	others := map[string]func([]byte) cipher.Block{
+		"kuznyechik": func(k []byte) cipher.Block { return gogost3412.NewCipher(k) },
+		"magma":      func(k []byte) cipher.Block { return gost341264.NewCipher(k) },
+	}
+	for _, name := range []string{"kuznyechik", "magma"} {
+		for range rounds {
+			key, data := random(gost3412.KeySize), random(64)
+			ours, theirs := newBlock(name, key), others[name](key)
+			n := ours.BlockSize()
+			iv := random(n)
+
+			// the modes with a one-block register are the classic ones
+			check(name+" block", crypt(ours, data[:n], ours.Encrypt), crypt(theirs, data[:n], theirs.Encrypt))
+			got, _ := gost3413.Crypt(ours, gost3413.ModeCBC, iv, data, false)
+			want := make([]byte, len(data))
+			cipher.NewCBCEncrypter(theirs, iv).CryptBlocks(want, data)
+			check(name+" cbc", got, want)
+			got, _ = gost3413.Crypt(ours, gost3413.ModeCTR, iv[:n/2], data[:len(data)-3], false)
+			ctr := append(append([]byte(nil), iv[:n/2]...), make([]byte, n/2)...)
+			cipher.NewCTR(theirs, ctr).XORKeyStream(want, data)
+			check(name+" ctr", got, want[:len(data)-3])
+		}
+		fmt.Printf("%s matches gogost and crypto/cipher on %d random inputs: OK\n", name, rounds)
+	}
+	// gogost pads an empty message with a zero block, the standard leaves it empty
+	for i := 1; i < 40; i++ {
+		data := random(i)
+		check("pad1", gost3413.Pad1(bytes.Clone(data), 16), gogost3413.Pad1(bytes.Clone(data), 16))
+		check("pad2", gost3413.Pad2(bytes.Clone(data), 16), gogost3413.Pad2(bytes.Clone(data), 16))
+		check("pad3", gost3413.Pad3(bytes.Clone(data), 16), gogost3413.Pad3(bytes.Clone(data), 16))
+	}
+	fmt.Println("Padding procedures match gogost: OK")
+}
+
+func random(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return b
+}
+
+func crypt(b cipher.Block, src []byte, f func(dst, src []byte)) []byte {
+	dst := make([]byte, len(src))
+	f(dst, src)
+	return dst
+}
+
+func check(what string, got, want []byte) {
+	if !bytes.Equal(got, want) {
+		log.Fatalf("Self-test failed: %s gives %x, gogost %x", what, got, want)
+	}
+}
+
+func mac(name, keyFile string, size int, inputFile, verify string) {
+	b := newBlock(name, readKey(keyFile))
+	if size == 0 {
+		size = b.BlockSize() / 2
+	}
+	h, err := gost3413.NewMAC(b, size)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	f, err := os.Open(inputFile)
+	if err != nil {
+		log.Fatalf("Error opening file: %v", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+	sum := h.Sum(nil)
+	if verify == "" {
+		fmt.Printf("%x\n", sum)
+		return
+	}
+	want, err := hex.DecodeString(verify)
+	if err != nil || !bytes.Equal(sum, want) {
+		fmt.Println("MAC does not match: the file or the key is different")
+		os.Exit(1)
+	}
+	fmt.Println("MAC matches")
+}
+
+func benchmark(size int) {
+	key, data := random(gost3412.KeySize), random(size)
+	fmt.Printf("%-12s %-14s %-14s\n", "cipher", "own MB/s", "gogost MB/s")
+	for _, name := range []string{"kuznyechik", "magma"} {
+		ours := newBlock(name, key)
+		var theirs cipher.Block = gogost3412.NewCipher(key)
+		if name == "magma" {
+			theirs = gost341264.NewCipher(key)
+		}
+		speed := func(b cipher.Block) float64 {
+			r := testing.Benchmark(func(tb *testing.B) {
+				for tb.Loop() {
+					gost3413.NewECBEncrypter(b).CryptBlocks(data, data)
+				}
+			})
+			return float64(size) / float64(r.NsPerOp()) * 1e3
+		}
+		fmt.Printf("%-12s %-14.1f %-14.1f\n", name, speed(ours), speed(theirs))
+	}
+}
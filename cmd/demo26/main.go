@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"information-defending/internal/elgamal"
+	"log"
+	"math/big"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
+	encryptCmd := flag.NewFlagSet("encrypt", flag.ExitOnError)
+	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	decryptCmd := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	rerandCmd := flag.NewFlagSet("rerandomize", flag.ExitOnError)
+	simulateCmd := flag.NewFlagSet("simulate", flag.ExitOnError)
+
+	keyFile := generateCmd.String("key", "tally_keys", "File to save ElGamal keys (same format as demo9)")
+
+	encValue := encryptCmd.Int64("value", 1, "Number to encrypt: 1 for a yes ballot, 0 for no, or a counter step")
+	encOutput := encryptCmd.String("output", "", "Output ciphertext file")
+	encKey := encryptCmd.String("key", "tally_keys", "Key file base name")
+
+	addInputs := addCmd.String("inputs", "", "Comma-separated ciphertext files to add")
+	addPlain := addCmd.Int64("plain", 0, "Plaintext constant to add (optional)")
+	addOutput := addCmd.String("output", "", "Output ciphertext file")
+	addKey := addCmd.String("key", "tally_keys", "Key file base name")
+
+	decInput := decryptCmd.String("input", "", "Ciphertext file")
+	decMax := decryptCmd.Int64("max", 1000000, "Largest plaintext to search for")
+	decKey := decryptCmd.String("key", "tally_keys", "Key file base name")
+
+	rerandInput := rerandCmd.String("input", "", "Ciphertext file")
+	rerandOutput := rerandCmd.String("output", "", "Output ciphertext file")
+	rerandKey := rerandCmd.String("key", "tally_keys", "Key file base name")
+
+	simVoters := simulateCmd.Int("voters", 1000, "Number of ballots")
+
+	if len(os.Args) < 2 {
+		printUsage()
+		return
+	}
+
+	required := func(fs *flag.FlagSet, msg string, values ...string) {
+		for _, v := range values {
+			if v == "" {
+				fmt.Println("Error: " + msg)
+				fs.PrintDefaults()
+				os.Exit(1)
+			}
+		}
+	}
+
+	switch os.Args[1] {
+	case "generate":
+		generateCmd.Parse(os.Args[2:])
+		generateKeys(*keyFile)
+	case "encrypt":
+		encryptCmd.Parse(os.Args[2:])
+		required(encryptCmd, "output file is required", *encOutput)
+		encrypt(*encValue, *encOutput, *encKey)
+	case "add":
+		addCmd.Parse(os.Args[2:])
+		required(addCmd, "inputs and output file are required", *addInputs, *addOutput)
+		add(strings.Split(*addInputs, ","), *addPlain, *addOutput, *addKey)
+	case "decrypt":
+		decryptCmd.Parse(os.Args[2:])
+		required(decryptCmd, "input file is required", *decInput)
+		decrypt(*decInput, *decMax, *decKey)
+	case "rerandomize":
+		rerandCmd.Parse(os.Args[2:])
+		required(rerandCmd, "input and output files are required", *rerandInput, *rerandOutput)
+		rerandomize(*rerandInput, *rerandOutput, *rerandKey)
+	case "simulate":
+		simulateCmd.Parse(os.Args[2:])
+		simulate(*simVoters)
+	default:
+		printUsage()
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage:")
+	fmt.Println("  generate    - generate ElGamal keys")
+	fmt.Println("  encrypt     - encrypt a ballot or counter step as g^m")
+	fmt.Println("  add         - add ciphertexts without decrypting them")
+	fmt.Println("  decrypt     - decrypt a sum with baby-step giant-step")
+	fmt.Println("  rerandomize - make a ciphertext unlinkable to the original")
+	fmt.Println("  simulate    - tally random yes/no ballots and check the count")
+	fmt.Println("\nUse [command] -h for more information about a command")
+}
+
+func generateKeys(keyFile string) {
+	fmt.Println("Generating keys...")
+	keys, err := elgamal.GenerateKeys()
+	if err != nil {
+		log.Fatalf("Error generating keys: %v", err)
+	}
+
+	pubData := fmt.Sprintf("%s\n%s\n%s", keys.P, keys.G, keys.Y)
+	err = os.WriteFile(keyFile+".pub", []byte(pubData), 0644)
+	if err != nil {
+		log.Fatalf("Error saving keys: %v", err)
+	}
+	err = os.WriteFile(keyFile+".priv", []byte(keys.X.String()), 0600)
+	if err != nil {
+		log.Fatalf("Error saving keys: %v", err)
+	}
+
+	fmt.Printf("Keys saved to %s.pub and %s.priv\n", keyFile, keyFile)
+	fmt.Printf("p = %s\n", keys.P)
+}
+
+func readNumbers(filename string, n int) []*big.Int {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Error reading %s: %v", filename, err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != n {
+		log.Fatalf("Error reading %s: expected %d numbers, got %d", filename, n, len(fields))
+	}
+	nums := make([]*big.Int, n)
+	for i, f := range fields {
+		x, ok := new(big.Int).SetString(f, 10)
+		if !ok {
+			log.Fatalf("Error reading %s: invalid number %q", filename, f)
+		}
+		nums[i] = x
+	}
+	return nums
+}
+
+func loadPublic(keyFile string) *elgamal.PublicKey {
+	n := readNumbers(keyFile+".pub", 3)
+	return &elgamal.PublicKey{P: n[0], G: n[1], Y: n[2]}
+}
+
+func loadKeys(keyFile string) *elgamal.Keys {
+	pub := loadPublic(keyFile)
+	x := readNumbers(keyFile+".priv", 1)[0]
+	return &elgamal.Keys{P: pub.P, G: pub.G, X: x, Y: pub.Y}
+}
+
+func readCiphertext(filename string) elgamal.Ciphertext {
+	n := readNumbers(filename, 2)
+	return elgamal.Ciphertext{R: n[0], E: n[1]}
+}
+
+func writeCiphertext(filename string, c elgamal.Ciphertext) {
+	err := os.WriteFile(filename, []byte(fmt.Sprintf("%s\n%s", c.R, c.E)), 0644)
+	if err != nil {
+		log.Fatalf("Error writing ciphertext: %v", err)
+	}
+	fmt.Printf("Ciphertext saved to: %s\n", filename)
+}
+
+func encrypt(value int64, outputFile, keyFile string) {
+	c, err := loadPublic(keyFile).EncryptExp(value)
+	if err != nil {
+		log.Fatalf("Error encrypting: %v", err)
+	}
+	writeCiphertext(outputFile, c)
+}
+
+func add(inputFiles []string, plain int64, outputFile, keyFile string) {
+	pub := loadPublic(keyFile)
+	cs := make([]elgamal.Ciphertext, len(inputFiles))
+	for i, file := range inputFiles {
+		cs[i] = readCiphertext(file)
+	}
+	c, err := pub.Sum(cs...)
+	if err != nil {
+		log.Fatalf("Error adding: %v", err)
+	}
+	if plain != 0 {
+		c, err = pub.AddPlain(c, plain)
+		if err != nil {
+			log.Fatalf("Error adding: %v", err)
+		}
+	}
+	writeCiphertext(outputFile, c)
+}
+
+func decrypt(inputFile string, max int64, keyFile string) {
+	m, err := loadKeys(keyFile).DecryptExp(readCiphertext(inputFile), max)
+	if err != nil {
+		log.Fatalf("Error decrypting: %v", err)
+	}
+	fmt.Printf("Plaintext: %d\n", m)
+}
+
+func rerandomize(inputFile, outputFile, keyFile string) {
+	c, err := loadPublic(keyFile).Rerandomize(readCiphertext(inputFile))
+	if err != nil {
+		log.Fatalf("Error re-randomizing: %v", err)
+	}
+	writeCiphertext(outputFile, c)
+}
+
+// simulate encrypts random ballots, multiplies them together and decrypts
+// only the total.
+func simulate(voters int) {
+	keys, err := elgamal.GenerateKeys()
+	if err != nil {
+		log.Fatalf("Error generating keys: %v", err)
+	}
+	pub := keys.Public()
+
+	start := time.Now()
+	ballots := make([]elgamal.Ciphertext, voters)
+	yes := 0
+	for i := range ballots {
+		v := rand.Intn(2)
+		yes += v
+		ballots[i], err = pub.EncryptExp(int64(v))
+		if err != nil {
+			log.Fatalf("Error encrypting: %v", err)
+		}
+	}
+	fmt.Printf("%d ballots encrypted in %v\n", voters, time.Since(start))
+
+	start = time.Now()
+	total, err := pub.Sum(ballots...)
+	if err != nil {
+		log.Fatalf("Error adding: %v", err)
+	}
+	fmt.Printf("Tallied in %v\n", time.Since(start))
+
+	start = time.Now()
+	got, err := keys.DecryptExp(total, int64(voters))
+	if err != nil {
+		log.Fatalf("Error decrypting: %v", err)
+	}
+	fmt.Printf("Decrypted in %v\n", time.Since(start))
+
+	fmt.Printf("Yes: %d, no: %d (expected yes: %d)\n", got, int64(voters)-got, yes)
+	if got != int64(yes) {
+		log.Fatalf("Error: tally mismatch")
+	}
+}
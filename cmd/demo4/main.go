@@ -33,4 +33,36 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	// Те же четыре шага, но у каждой стороны только свои ключи
+	alice, err := shamir.NewAlice(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bob, err := shamir.NewBob(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	x1, err := alice.Encrypt(original)
+	if err != nil {
+		log.Fatal(err)
+	}
+	x2, err := bob.Encrypt(x1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	x3, err := alice.Decrypt(x2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	msg, err := bob.Decrypt(x3)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("x1 = %v\n", x1)
+	fmt.Printf("x2 = %v\n", x2)
+	fmt.Printf("x3 = %v\n", x3)
+	fmt.Printf("B получил: %s\n", msg)
 }
@@ -1,36 +1,45 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"information-defending/internal/crypto"
+	"information-defending/internal/attack"
+	"information-defending/internal/container"
 	"information-defending/internal/elgamal"
+	"io"
 	"log"
 	"math/big"
 	"os"
 )
 
 func main() {
+	unsafeFixedK := flag.Bool("unsafe-fixed-k", false, "encrypt every byte with one k and break it from one known byte (teaching only)")
+	flag.Parse()
+
 	original := []byte("Зашифрованное сообщение Эль-Гамаля")
 	err := os.WriteFile("input.txt", original, 0644)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// p и g генерятся как в системе Диффи-Хеллмана
-	p := big.NewInt(crypto.GenerateP())
-	g := big.NewInt(crypto.GenerateG(p.Int64()))
-
-	// Секретный (Cb) и открытый (Db) ключи абонента B
-	Cb, Db := elgamal.RandElGamal(p, g)
+	// p = 2q + 1, g, секретный (Cb = x) и открытый (Db = y) ключи абонента B
+	keys, err := elgamal.GenerateKeys()
+	if err != nil {
+		log.Fatal(err)
+	}
+	p, g, Cb, Db := keys.P, keys.G, keys.X, keys.Y
 
 	fmt.Printf("p = %d g = %d\n", p, g)
 	fmt.Printf("B: (cb=%d, db=%d)\n", Cb, Db)
 
-	// Абонент A генерит случайное число k [2, p-1)
-	k := big.NewInt(crypto.RandInt64(2, p.Int64()-1))
-	fmt.Printf("k = %d\n", k)
+	if *unsafeFixedK {
+		breakFixedK(original, p, g, Db)
+		return
+	}
 
-	err = elgamal.EncryptFile("input.txt", "encrypted.txt", p, g, Db, k)
+	// k для каждого блока генерится внутри EncryptFile
+	fmt.Printf("%d bytes per block\n", elgamal.ChunkSize(p))
+	err = elgamal.EncryptFile("input.txt", "encrypted.txt", p, g, Db)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -40,3 +49,48 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// breakFixedK шифрует по байту с одним k на весь файл и восстанавливает
+// текст без секретного ключа, зная только его первый байт.
+func breakFixedK(original []byte, p, g, Db *big.Int) {
+	k, err := elgamal.GenerateX(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("k = %d (one for every byte)\n", k)
+
+	err = elgamal.EncryptFileUnsafeFixedK("input.txt", "encrypted.txt", p, g, Db, k)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open("encrypted.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	cr, err := container.NewReader(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var blocks [][]byte
+	for {
+		b, err := cr.ReadBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		blocks = append(blocks, b)
+	}
+
+	msg, err := attack.ElGamalFixedK(blocks, p, original[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("known first byte %#x, recovered: %s\n", original[0], msg)
+	if err := os.WriteFile("broken.txt", msg, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"information-defending/internal/container"
 	"information-defending/internal/crypto"
+	"information-defending/internal/kdf"
 	"information-defending/internal/vernam"
 	"log"
 	"os"
@@ -19,14 +23,57 @@ func main() {
 
 	fmt.Printf("p = %d, g = %d, a = %d, b = %d, K = %d\n", p, g, a, b, k)
 
-	err = vernam.EncryptFile("input.txt", "encrypted.txt", k)
+	// Ключ имитовставки выводится из отдельного пароля, а не из однобайтового k:
+	// иначе подделка требовала бы не больше 256 попыток.
+	password := make([]byte, 16)
+	if _, err := rand.Read(password); err != nil {
+		log.Fatal(err)
+	}
+	password = []byte(hex.EncodeToString(password))
+	fmt.Printf("Пароль имитовставки: %s\n", password)
+
+	err = vernam.EncryptFile("input.txt", "encrypted.txt", k, password)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = vernam.DecryptFile("encrypted.txt", "decrypted.txt", k)
+	err = vernam.DecryptFile("encrypted.txt", "decrypted.txt", k, password)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Подмена одного бита шифртекста: имитовставка ГОСТ 28147-89 её обнаруживает.
+	params, err := kdf.New(kdf.PBKDF2, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = vernam.EncryptFileMAC("input.txt", "encrypted.txt", k, password, params, container.MACGOST28147)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := os.ReadFile("encrypted.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	// последний байт шифртекста, перед длиной и имитовставкой
+	data[len(data)-8-container.MACGOST28147.Size()-1] ^= 1
+	if err := os.WriteFile("tampered.txt", data, 0644); err != nil {
+		log.Fatal(err)
+	}
+	err = vernam.DecryptFile("tampered.txt", "tampered_decrypted.txt", k, password)
+	fmt.Printf("Расшифровка изменённого файла: %v\n", err)
+
+	// Шифр Вернама над русским алфавитом: ключ из случайных букв длиной с сообщение
+	key, err := vernam.RandomKey(vernam.Russian, len([]rune(string(original))))
+	if err != nil {
+		log.Fatal(err)
+	}
+	v, err := vernam.NewVigenere(vernam.Russian, key, vernam.Keep)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encrypted := v.Encrypt(string(original))
+	fmt.Printf("Ключ:        %s\n", key)
+	fmt.Printf("Шифртекст:   %s\n", encrypted)
+	fmt.Printf("Расшифровка: %s\n", v.Decrypt(encrypted))
 }
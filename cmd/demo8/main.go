@@ -8,14 +8,21 @@ import (
 	"log"
 	"math/big"
 	"os"
+	"strings"
 )
 
 func main() {
 	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
 	signCmd := flag.NewFlagSet("sign", flag.ExitOnError)
 	verifyCmd := flag.NewFlagSet("verify", flag.ExitOnError)
+	blindCmd := flag.NewFlagSet("blind", flag.ExitOnError)
+	blindSignCmd := flag.NewFlagSet("blind-sign", flag.ExitOnError)
+	unblindCmd := flag.NewFlagSet("unblind", flag.ExitOnError)
 
 	keyFile := generateCmd.String("key", "rsa_keys", "File to save RSA keys")
+	keyFormat := generateCmd.String("format", "text", "Key file format: text, pkcs1 or pkcs8 (PEM)")
+	keyExp := generateCmd.Int64("e", 0, "Public exponent, e.g. 65537 (default: random 1024-bit prime)")
+	keyPrimes := generateCmd.Int("primes", 2, "Number of primes in N (more than 2 needs -e, default 65537)")
 
 	signInput := signCmd.String("input", "", "Input file to sign")
 	signOutput := signCmd.String("output", "", "Output signature file (default: input.sig)")
@@ -25,6 +32,16 @@ func main() {
 	verifySig := verifyCmd.String("signature", "", "Signature file")
 	verifyKey := verifyCmd.String("key", "rsa_keys", "RSA public key file")
 
+	blindInput := blindCmd.String("input", "", "Input file to be signed blindly")
+	blindKey := blindCmd.String("key", "rsa_keys", "Signer's RSA public key file")
+
+	blindSignInput := blindSignCmd.String("input", "", "Blinded message file (input.blind)")
+	blindSignKey := blindSignCmd.String("key", "rsa_keys", "RSA private key file")
+
+	unblindInput := unblindCmd.String("input", "", "Original input file")
+	unblindOutput := unblindCmd.String("output", "", "Output signature file (default: input.sig)")
+	unblindKey := unblindCmd.String("key", "rsa_keys", "Signer's RSA public key file")
+
 	if len(os.Args) < 2 {
 		printUsage()
 		return
@@ -33,7 +50,7 @@ func main() {
 	switch os.Args[1] {
 	case "generate":
 		generateCmd.Parse(os.Args[2:])
-		generateKeys(*keyFile)
+		generateKeys(*keyFile, *keyFormat, *keyExp, *keyPrimes)
 	case "sign":
 		signCmd.Parse(os.Args[2:])
 		if *signInput == "" {
@@ -53,6 +70,33 @@ func main() {
 			os.Exit(1)
 		}
 		verifySignature(*verifyInput, *verifySig, *verifyKey)
+	case "blind":
+		blindCmd.Parse(os.Args[2:])
+		if *blindInput == "" {
+			fmt.Println("Error: input file is required")
+			blindCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		blindFile(*blindInput, *blindKey)
+	case "blind-sign":
+		blindSignCmd.Parse(os.Args[2:])
+		if *blindSignInput == "" {
+			fmt.Println("Error: input file is required")
+			blindSignCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		blindSign(*blindSignInput, *blindSignKey)
+	case "unblind":
+		unblindCmd.Parse(os.Args[2:])
+		if *unblindInput == "" {
+			fmt.Println("Error: input file is required")
+			unblindCmd.PrintDefaults()
+			os.Exit(1)
+		}
+		if *unblindOutput == "" {
+			*unblindOutput = *unblindInput + ".sig"
+		}
+		unblindSignature(*unblindInput, *unblindOutput, *unblindKey)
 	default:
 		printUsage()
 	}
@@ -60,17 +104,45 @@ func main() {
 
 func printUsage() {
 	fmt.Println("Usage:")
-	fmt.Println("  generate - generate RSA keys")
-	fmt.Println("  sign     - sign a file")
-	fmt.Println("  verify   - verify a file signature")
+	fmt.Println("  generate   - generate RSA keys")
+	fmt.Println("  sign       - sign a file")
+	fmt.Println("  verify     - verify a file signature")
+	fmt.Println("  blind      - blind a file hash for the signer (writes input.blind and input.r)")
+	fmt.Println("  blind-sign - sign a blinded hash without seeing it (writes input.sig)")
+	fmt.Println("  unblind    - turn the blind signature into an ordinary one")
 	fmt.Println("\nUse [command] -h for more information about a command")
 }
 
-func generateKeys(keyFile string) {
+func generateKeys(keyFile, format string, e int64, primes int) {
 	fmt.Println("Generating RSA keys...")
-	keys := rsa.GenerateKeys()
+	if primes != 2 && e == 0 {
+		e = 65537
+	}
+	var keys rsa.Keys
+	if e == 0 {
+		keys = rsa.GenerateKeys()
+	} else {
+		var err error
+		keys, err = rsa.GenerateMultiPrimeKeys(2048, primes, e)
+		if err != nil {
+			log.Fatalf("Error generating keys: %v", err)
+		}
+	}
+	if primes != 2 && format == "text" {
+		fmt.Println("Warning: text key files keep only N and C, the primes are lost")
+	}
 
-	err := saveKeys(keys, keyFile)
+	var err error
+	switch format {
+	case "text":
+		err = saveKeys(keys, keyFile)
+	case "pkcs1":
+		err = saveKeysPEM(keys, keyFile, rsa.FormatPKCS1)
+	case "pkcs8":
+		err = saveKeysPEM(keys, keyFile, rsa.FormatPKCS8)
+	default:
+		log.Fatalf("Unknown key format: %s", format)
+	}
 	if err != nil {
 		log.Fatalf("Error saving keys: %v", err)
 	}
@@ -79,12 +151,17 @@ func generateKeys(keyFile string) {
 	fmt.Printf("Public key (N): %s\n", keys.N.String())
 	fmt.Printf("Public exponent (D): %s\n", keys.D.String())
 	fmt.Printf("Private exponent (C): %s\n", keys.C.String())
+	if primes != 2 {
+		for i, p := range keys.Primes {
+			fmt.Printf("Prime %d: %s\n", i+1, p)
+		}
+	}
 }
 
 func signFile(inputFile, outputFile, keyFile string) {
 	fmt.Printf("Signing file: %s\n", inputFile)
 
-	privKey, err := loadPrivateKey(keyFile + ".priv")
+	privKey, err := rsa.ReadPrivateKeyFile(keyFile + ".priv")
 	if err != nil {
 		log.Fatalf("Error loading private key: %v", err)
 	}
@@ -97,7 +174,10 @@ func signFile(inputFile, outputFile, keyFile string) {
 	hash := sha256.Sum256(data)
 	y := big.NewInt(0).SetBytes(hash[:])
 
-	s := rsa.Decrypt(y, privKey.C, privKey.N)
+	s, err := decrypt(privKey, y)
+	if err != nil {
+		log.Fatalf("Error signing: %v", err)
+	}
 
 	signatureBytes := s.Bytes()
 	err = os.WriteFile(outputFile, signatureBytes, 0644)
@@ -112,7 +192,7 @@ func signFile(inputFile, outputFile, keyFile string) {
 func verifySignature(inputFile, signatureFile, keyFile string) {
 	fmt.Printf("Verifying file: %s\n", inputFile)
 
-	pubKey, err := loadPublicKey(keyFile + ".pub")
+	pubKey, err := rsa.ReadPublicKeyFile(keyFile + ".pub")
 	if err != nil {
 		log.Fatalf("Error loading public key: %v", err)
 	}
@@ -143,71 +223,149 @@ func verifySignature(inputFile, signatureFile, keyFile string) {
 	}
 }
 
-type PublicKey struct {
-	N *big.Int
-	D *big.Int
-}
+func blindFile(inputFile, keyFile string) {
+	pubKey, err := rsa.ReadPublicKeyFile(keyFile + ".pub")
+	if err != nil {
+		log.Fatalf("Error loading public key: %v", err)
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading file: %v", err)
+	}
+
+	hash := sha256.Sum256(data)
+	y := big.NewInt(0).SetBytes(hash[:])
+
+	blinded, r, err := rsa.Blind(y, pubKey.D, pubKey.N)
+	if err != nil {
+		log.Fatalf("Error blinding hash: %v", err)
+	}
 
-type PrivateKey struct {
-	N *big.Int
-	C *big.Int
+	err = os.WriteFile(inputFile+".blind", []byte(blinded.String()), 0644)
+	if err != nil {
+		log.Fatalf("Error writing blinded hash: %v", err)
+	}
+	err = os.WriteFile(inputFile+".r", []byte(r.String()), 0600)
+	if err != nil {
+		log.Fatalf("Error writing blinding factor: %v", err)
+	}
+
+	fmt.Printf("Blinded hash saved to: %s.blind (send it to the signer)\n", inputFile)
+	fmt.Printf("Blinding factor saved to: %s.r (keep it secret)\n", inputFile)
 }
 
-func saveKeys(keys rsa.Keys, baseName string) error {
+func blindSign(inputFile, keyFile string) {
+	privKey, err := rsa.ReadPrivateKeyFile(keyFile + ".priv")
+	if err != nil {
+		log.Fatalf("Error loading private key: %v", err)
+	}
 
-	pubData := fmt.Sprintf("%s\n%s", keys.N.String(), keys.D.String())
-	err := os.WriteFile(baseName+".pub", []byte(pubData), 0644)
+	blinded, err := loadNumber(inputFile)
 	if err != nil {
-		return err
+		log.Fatalf("Error reading blinded hash: %v", err)
+	}
+	if blinded.Cmp(privKey.N) >= 0 {
+		log.Fatalf("Error: blinded hash is not less than N")
 	}
 
-	privData := fmt.Sprintf("%s\n%s", keys.N.String(), keys.C.String())
-	err = os.WriteFile(baseName+".priv", []byte(privData), 0644)
+	s, err := decrypt(privKey, blinded)
 	if err != nil {
-		return err
+		log.Fatalf("Error signing: %v", err)
+	}
+	err = os.WriteFile(inputFile+".sig", []byte(s.String()), 0644)
+	if err != nil {
+		log.Fatalf("Error writing blind signature: %v", err)
 	}
 
-	return nil
+	fmt.Printf("Blind signature saved to: %s.sig\n", inputFile)
 }
 
-func loadPublicKey(filename string) (*PublicKey, error) {
-	data, err := os.ReadFile(filename)
+func unblindSignature(inputFile, outputFile, keyFile string) {
+	pubKey, err := rsa.ReadPublicKeyFile(keyFile + ".pub")
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error loading public key: %v", err)
 	}
 
-	var nStr, dStr string
-	_, err = fmt.Sscanf(string(data), "%s\n%s", &nStr, &dStr)
+	blindSig, err := loadNumber(inputFile + ".blind.sig")
 	if err != nil {
-		return nil, err
+		log.Fatalf("Error reading blind signature: %v", err)
+	}
+	r, err := loadNumber(inputFile + ".r")
+	if err != nil {
+		log.Fatalf("Error reading blinding factor: %v", err)
 	}
 
-	N := big.NewInt(0)
-	N.SetString(nStr, 10)
+	s := rsa.Unblind(blindSig, r, pubKey.N)
+	if s == nil {
+		log.Fatalf("Error: blinding factor is not invertible modulo N")
+	}
 
-	D := big.NewInt(0)
-	D.SetString(dStr, 10)
+	signatureBytes := s.Bytes()
+	err = os.WriteFile(outputFile, signatureBytes, 0644)
+	if err != nil {
+		log.Fatalf("Error writing signature: %v", err)
+	}
 
-	return &PublicKey{N: N, D: D}, nil
+	fmt.Printf("Signature saved to: %s\n", outputFile)
+	fmt.Printf("Signature: %x\n", signatureBytes)
 }
 
-func loadPrivateKey(filename string) (*PrivateKey, error) {
+func loadNumber(filename string) (*big.Int, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	var nStr, cStr string
-	_, err = fmt.Sscanf(string(data), "%s\n%s", &nStr, &cStr)
+	n, ok := new(big.Int).SetString(strings.TrimSpace(string(data)), 10)
+	if !ok {
+		return nil, fmt.Errorf("error while reading number from %s", filename)
+	}
+	return n, nil
+}
+
+// decrypt uses CRT when the key file carried the primes, as PEM keys do.
+func decrypt(k rsa.Keys, e *big.Int) (*big.Int, error) {
+	if k.Primes == nil {
+		return rsa.Decrypt(e, k.C, k.N), nil
+	}
+	crt, err := k.Precompute()
 	if err != nil {
 		return nil, err
 	}
+	return crt.Decrypt(e), nil
+}
 
-	N := big.NewInt(0)
-	N.SetString(nStr, 10)
+func saveKeys(keys rsa.Keys, baseName string) error {
 
-	C := big.NewInt(0)
-	C.SetString(cStr, 10)
+	pubData := fmt.Sprintf("%s\n%s", keys.N.String(), keys.D.String())
+	err := os.WriteFile(baseName+".pub", []byte(pubData), 0644)
+	if err != nil {
+		return err
+	}
 
-	return &PrivateKey{N: N, C: C}, nil
+	privData := fmt.Sprintf("%s\n%s", keys.N.String(), keys.C.String())
+	err = os.WriteFile(baseName+".priv", []byte(privData), 0644)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func saveKeysPEM(keys rsa.Keys, baseName string, format rsa.Format) error {
+	pubData, err := rsa.EncodePublicKeyPEM(keys, format)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(baseName+".pub", pubData, 0644)
+	if err != nil {
+		return err
+	}
+
+	privData, err := rsa.EncodePrivateKeyPEM(keys, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(baseName+".priv", privData, 0600)
 }
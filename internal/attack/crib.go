@@ -0,0 +1,106 @@
+package attack
+
+import "slices"
+
+// Several ciphertexts c_i = m_i ^ k under one pad share the key, so a
+// guessed piece of one plaintext gives the key there and with it the same
+// piece of every other plaintext.
+
+// ManyTimePad guesses every key byte from the column of ciphertext bytes
+// at that position, as for a repeating key with one row per message. It
+// needs several messages to be reliable; CribDrag and Refine fix the rest.
+func ManyTimePad(cts [][]byte, l *Language) []byte {
+	n := 0
+	for _, c := range cts {
+		n = max(n, len(c))
+	}
+	key := make([]byte, n)
+	col := make([]byte, 0, len(cts))
+	for p := range key {
+		col = col[:0]
+		for _, c := range cts {
+			if p < len(c) {
+				col = append(col, c[p])
+			}
+		}
+		best := 0.0
+		for k := range 256 {
+			var s float64
+			for _, b := range col {
+				s += l.ScoreBytes([]byte{b ^ byte(k)})
+			}
+			if k == 0 || s > best {
+				key[p], best = byte(k), s
+			}
+		}
+	}
+	return key
+}
+
+type CribHit struct {
+	Ciphertext int // message the crib was placed in
+	Pos        int
+	Score      float64 // mean score of what the other messages show
+	Key        []byte  // key bytes at Pos implied by the crib
+}
+
+// CribDrag slides crib along every ciphertext and scores the fragments it
+// reveals in the others, best first.
+func CribDrag(cts [][]byte, crib []byte, l *Language) []CribHit {
+	var hits []CribHit
+	for i, c := range cts {
+		for p := 0; p+len(crib) <= len(c); p++ {
+			key := XOR(c[p:p+len(crib)], crib)
+			var s float64
+			others := 0
+			for j, d := range cts {
+				if j == i || p+len(crib) > len(d) {
+					continue
+				}
+				s += l.Score(XOR(d[p:p+len(crib)], key))
+				others++
+			}
+			if others > 0 {
+				hits = append(hits, CribHit{Ciphertext: i, Pos: p, Score: s / float64(others), Key: key})
+			}
+		}
+	}
+	slices.SortFunc(hits, func(a, b CribHit) int { return cmpFloat(b.Score, a.Score) })
+	return hits
+}
+
+// Refine drags the common words of l through the messages and takes a
+// hit whenever it makes the text around it read better under the bigram
+// model, until nothing improves.
+func Refine(cts [][]byte, key []byte, l *Language) []byte {
+	key = slices.Clone(key)
+	for changed := true; changed; {
+		changed = false
+		for _, w := range l.Words() {
+			for _, h := range CribDrag(cts, w, l) {
+				lo, hi := max(h.Pos-1, 0), min(h.Pos+len(w)+1, len(key))
+				before := windowScore(cts, key, lo, hi, l)
+				old := slices.Clone(key[h.Pos : h.Pos+len(w)])
+				copy(key[h.Pos:], h.Key)
+				if windowScore(cts, key, lo, hi, l) > before+1e-9 {
+					changed = true
+				} else {
+					copy(key[h.Pos:], old)
+				}
+			}
+		}
+	}
+	return key
+}
+
+func windowScore(cts [][]byte, key []byte, lo, hi int, l *Language) float64 {
+	var s float64
+	for _, c := range cts {
+		if lo >= len(c) {
+			continue
+		}
+		e := min(hi, len(c))
+		s += l.Score(XOR(c[lo:e], key[lo:e])) * float64(e-lo)
+	}
+	return s
+}
@@ -0,0 +1,42 @@
+package attack
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ElGamalFixedK decrypts blocks r || e of elgamal.EncryptFileUnsafeFixedK
+// knowing only the first plaintext byte. With one k for all blocks
+// e_i = m_i * s for the same s = Db^k, so s = e_0 / m_0 and m_i = e_i / s.
+func ElGamalFixedK(blocks [][]byte, p *big.Int, first byte) ([]byte, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("attack: no ciphertext blocks")
+	}
+	if first == 0 {
+		return nil, errors.New("attack: a known byte of 0 says nothing about Db^k")
+	}
+	size := len(blocks[0]) / 2
+	r0 := new(big.Int).SetBytes(blocks[0][:size])
+	e0 := new(big.Int).SetBytes(blocks[0][size:])
+
+	// s^-1 = m_0 / e_0
+	sInv := new(big.Int).ModInverse(e0, p)
+	if sInv == nil {
+		return nil, errors.New("attack: e is not invertible modulo p")
+	}
+	sInv.Mul(sInv, big.NewInt(int64(first))).Mod(sInv, p)
+
+	out := make([]byte, len(blocks))
+	for i, b := range blocks {
+		if len(b) != 2*size || new(big.Int).SetBytes(b[:size]).Cmp(r0) != 0 {
+			return nil, errors.New("attack: r differs between blocks, k was not reused")
+		}
+		m := new(big.Int).SetBytes(b[size:])
+		m.Mul(m, sInv).Mod(m, p)
+		if !m.IsInt64() || m.Int64() > 255 {
+			return nil, errors.New("attack: block does not decrypt to a byte, wrong known byte?")
+		}
+		out[i] = byte(m.Int64())
+	}
+	return out, nil
+}
@@ -0,0 +1,282 @@
+package attack
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Language is a byte-level model of plain text in one encoding: unigram
+// probabilities from letter frequency tables and bigrams from a short
+// sample text, both as natural logarithms.
+type Language struct {
+	Name string
+
+	letters map[rune]float64
+	sample  string
+	common  []string
+	encode  func(string) []byte
+	decode  func([]byte) string
+
+	once  sync.Once
+	uni   [256]float64
+	bi    [256][256]float32
+	words [][]byte
+}
+
+var (
+	English = &Language{
+		Name:    "en",
+		letters: englishLetters,
+		sample:  englishSample,
+		common:  []string{"the", "and", "that", "have", "for", "not", "with", "you", "this", "but", "his", "from", "they", "say", "her", "she", "will", "one", "all", "would", "there", "their", "what", "about", "which", "when", "make", "can", "like", "time", "just", "know", "people", "into", "year", "your", "some", "could", "them", "see", "other", "than", "then", "now", "only", "come", "its", "over", "also", "after", "first", "because", "message", "secret", "attack", "tomorrow"},
+		encode:  func(s string) []byte { return []byte(s) },
+		decode:  decodeASCII,
+	}
+	RussianUTF8 = &Language{
+		Name:    "ru-utf8",
+		letters: russianLetters,
+		sample:  russianSample,
+		common:  russianCommon,
+		encode:  func(s string) []byte { return []byte(s) },
+		decode:  decodeUTF8,
+	}
+	RussianCP1251 = &Language{
+		Name:    "ru-cp1251",
+		letters: russianLetters,
+		sample:  russianSample,
+		common:  russianCommon,
+		encode:  EncodeCP1251,
+		decode:  DecodeCP1251,
+	}
+
+	Languages = []*Language{English, RussianUTF8, RussianCP1251}
+)
+
+var russianCommon = []string{"и", "в", "не", "на", "что", "он", "как", "это", "по", "но", "его", "все", "она", "так", "же", "от", "было", "быть", "вы", "за", "мы", "бы", "если", "уже", "или", "только", "когда", "нет", "для", "был", "сообщение", "секрет", "завтра", "который", "очень", "может", "время", "человек", "сказал", "сейчас"}
+
+func LanguageByName(name string) (*Language, error) {
+	for _, l := range Languages {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("attack: unknown language %q (en, ru-utf8, ru-cp1251)", name)
+}
+
+func (l *Language) build() {
+	l.once.Do(func() {
+		// character shares of typical prose; letters take the rest
+		chars := map[string]float64{" ": 0.16, ",": 0.012, ".": 0.01, "\n": 0.006, "-": 0.003, "\"": 0.002, "'": 0.001, "!": 0.0005, "?": 0.0005, ":": 0.0005, ";": 0.0003, "(": 0.0003, ")": 0.0003}
+		for d := '0'; d <= '9'; d++ {
+			chars[string(d)] = 0.0005
+		}
+		rest := 1.0
+		for _, p := range chars {
+			rest -= p
+		}
+		var total float64
+		for _, f := range l.letters {
+			total += f
+		}
+		for r, f := range l.letters {
+			p := rest * f / total
+			chars[string(r)] += 0.97 * p
+			chars[string(unicode.ToUpper(r))] += 0.03 * p
+		}
+
+		var counts [256]float64
+		for s, p := range chars {
+			for _, b := range l.encode(s) {
+				counts[b] += p
+			}
+		}
+		var sum float64
+		for b, c := range counts {
+			// anything printable is possible, control bytes almost never
+			switch {
+			case b >= 0x20 && b < 0x7f || b == '\t' || b == '\r':
+				c += 1e-4
+			default:
+				c += 1e-7
+			}
+			counts[b] = c
+			sum += c
+		}
+		for b, c := range counts {
+			l.uni[b] = math.Log(c / sum)
+		}
+
+		// p(b | a) = (n(a, b) + k p(b)) / (n(a) + k)
+		const k = 8
+		var pairs [256][256]float64
+		var firsts [256]float64
+		text := l.encode(l.sample)
+		for i := 1; i < len(text); i++ {
+			pairs[text[i-1]][text[i]]++
+			firsts[text[i-1]]++
+		}
+		for a := range 256 {
+			for b := range 256 {
+				l.bi[a][b] = float32(math.Log((pairs[a][b] + k*math.Exp(l.uni[b])) / (firsts[a] + k)))
+			}
+		}
+
+		for _, w := range l.common {
+			l.words = append(l.words, l.encode(" "+w+" "))
+		}
+	})
+}
+
+// Score is the mean log-probability per byte of b as text of l, with
+// bigrams; higher is more plausible.
+func (l *Language) Score(b []byte) float64 {
+	l.build()
+	if len(b) == 0 {
+		return 0
+	}
+	s := l.uni[b[0]]
+	for i := 1; i < len(b); i++ {
+		s += float64(l.bi[b[i-1]][b[i]])
+	}
+	return s / float64(len(b))
+}
+
+// ScoreBytes scores bytes that are not adjacent in the text, such as one
+// key column of several ciphertexts.
+func (l *Language) ScoreBytes(b []byte) float64 {
+	l.build()
+	var s float64
+	for _, c := range b {
+		s += l.uni[c]
+	}
+	return s
+}
+
+// Words returns common words with spaces around them, for crib dragging.
+func (l *Language) Words() [][]byte {
+	l.build()
+	return l.words
+}
+
+func (l *Language) Encode(s string) []byte {
+	return l.encode(s)
+}
+
+// Decode shows b as UTF-8 with unprintable bytes replaced by '.'.
+func (l *Language) Decode(b []byte) string {
+	return l.decode(b)
+}
+
+// DetectLanguage returns the model under which the text scores best.
+func DetectLanguage(b []byte) *Language {
+	return slices.MaxFunc(Languages, func(x, y *Language) int {
+		return cmpFloat(x.Score(b), y.Score(b))
+	})
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func printable(r rune) rune {
+	if r == '\n' || unicode.IsPrint(r) {
+		return r
+	}
+	return '.'
+}
+
+func decodeASCII(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c < 0x80 {
+			sb.WriteRune(printable(rune(c)))
+		} else {
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
+
+func decodeUTF8(b []byte) string {
+	var sb strings.Builder
+	for len(b) > 0 {
+		r, n := utf8.DecodeRune(b)
+		if r == utf8.RuneError {
+			r = '.'
+		}
+		sb.WriteRune(printable(r))
+		b = b[n:]
+	}
+	return sb.String()
+}
+
+// EncodeCP1251 covers ASCII and the Russian alphabet, the rest becomes '?'.
+func EncodeCP1251(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case r >= 'А' && r <= 'я':
+			out = append(out, byte(r-'А'+0xc0))
+		case r == 'Ё':
+			out = append(out, 0xa8)
+		case r == 'ё':
+			out = append(out, 0xb8)
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func DecodeCP1251(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch {
+		case c < 0x80:
+			sb.WriteRune(printable(rune(c)))
+		case c >= 0xc0:
+			sb.WriteRune(rune(c-0xc0) + 'А')
+		case c == 0xa8:
+			sb.WriteRune('Ё')
+		case c == 0xb8:
+			sb.WriteRune('ё')
+		default:
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
+
+// letter frequencies in percent
+var englishLetters = map[rune]float64{
+	'e': 12.7, 't': 9.1, 'a': 8.2, 'o': 7.5, 'i': 7.0, 'n': 6.7, 's': 6.3, 'h': 6.1, 'r': 6.0,
+	'd': 4.3, 'l': 4.0, 'c': 2.8, 'u': 2.8, 'm': 2.4, 'w': 2.4, 'f': 2.2, 'g': 2.0, 'y': 2.0,
+	'p': 1.9, 'b': 1.5, 'v': 1.0, 'k': 0.8, 'j': 0.15, 'x': 0.15, 'q': 0.1, 'z': 0.07,
+}
+
+var russianLetters = map[rune]float64{
+	'о': 10.97, 'е': 8.45, 'а': 8.01, 'и': 7.35, 'н': 6.70, 'т': 6.26, 'с': 5.47, 'р': 4.73,
+	'в': 4.54, 'л': 4.40, 'к': 3.49, 'м': 3.21, 'д': 2.98, 'п': 2.81, 'у': 2.62, 'я': 2.01,
+	'ы': 1.90, 'ь': 1.74, 'г': 1.70, 'з': 1.65, 'б': 1.59, 'ч': 1.44, 'й': 1.21, 'х': 0.97,
+	'ж': 0.94, 'ш': 0.73, 'ю': 0.64, 'ц': 0.48, 'щ': 0.36, 'э': 0.32, 'ф': 0.26, 'ъ': 0.04,
+	'ё': 0.04,
+}
+
+const englishSample = `The one-time pad is the only cipher that has been proven to be perfectly secret, but only as long as the key is truly random, at least as long as the message, kept secret by both parties and never used again. When the same pad is used for two messages, an eavesdropper who adds the two ciphertexts together cancels the key and is left with the sum of the two plaintexts. From there the attack is a matter of patience. She guesses a word that is likely to appear in one of the messages, such as "the" or "and", slides it along the combined text, and looks at what falls out of the other message at every position. Wherever the guess is right, the other message shows a readable piece of English, and the recovered letters suggest the next guess. During the war the same mistake let analysts read thousands of messages that were thought to be unbreakable. The lesson is simple: a key that is used twice is no longer a one-time pad, and the security of the whole system rests on the discipline of the people who handle the keys. Short keys that repeat are even worse, because every block of the message is encrypted with the same few bytes, and the length of the key can be found by comparing the blocks with each other. Once the length is known, every column of the text is a simple shift that can be solved by counting letters.
+`
+
+const russianSample = `Одноразовый блокнот является единственным шифром, стойкость которого доказана строго, но только при условии, что ключ действительно случаен, не короче сообщения, хранится в тайне обеими сторонами и никогда не используется повторно. Если один и тот же блокнот применить к двум сообщениям, то противник, сложив два шифртекста, уничтожит ключ и получит сумму двух открытых текстов. Дальше дело только в терпении. Он предполагает, что в одном из сообщений есть какое-нибудь частое слово, например "что" или "не", двигает его вдоль сложенного текста и смотрит, что получается во втором сообщении на каждой позиции. Там, где догадка верна, во втором тексте появляется осмысленный кусок русского языка, а найденные буквы подсказывают следующее слово. Во время войны именно такая ошибка позволила прочитать тысячи сообщений, которые считались совершенно надёжными. Вывод простой: ключ, использованный дважды, уже не одноразовый блокнот, и безопасность всей системы держится на дисциплине людей, которые работают с ключами. Короткий повторяющийся ключ ещё хуже, потому что каждый блок сообщения шифруется одними и теми же байтами, а длину ключа можно найти, сравнивая блоки между собой. Когда длина известна, каждый столбец текста превращается в простой сдвиг, который решается подсчётом букв.
+`
@@ -0,0 +1,84 @@
+package attack
+
+import (
+	"errors"
+	"information-defending/internal/keystream"
+)
+
+// BerlekampMassey returns the linear complexity L of the bit sequence s
+// and the shortest connection polynomial c, with c[0] = 1 and
+// s_n = sum c_i s_(n-i) for i = 1..L. 2L bits of output determine it.
+func BerlekampMassey(s []byte) (int, []byte) {
+	n := len(s)
+	c := make([]byte, n+1)
+	b := make([]byte, n+1)
+	c[0], b[0] = 1, 1
+	L, m := 0, 1
+	for i := range n {
+		d := s[i]
+		for j := 1; j <= L; j++ {
+			d ^= c[j] & s[i-j]
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		t := append([]byte(nil), c...)
+		for j := 0; j+m <= n; j++ {
+			c[j+m] ^= b[j]
+		}
+		if 2*L <= i {
+			L, b, m = i+1-L, t, 1
+		} else {
+			m++
+		}
+	}
+	return L, c[:L+1]
+}
+
+// Bits expands bytes LSB first, the order keystream.LFSR produces them in.
+func Bits(b []byte) []byte {
+	out := make([]byte, 0, 8*len(b))
+	for _, x := range b {
+		for j := range 8 {
+			out = append(out, x>>j&1)
+		}
+	}
+	return out
+}
+
+var ErrNotLFSR = errors.New("attack: keystream is not a short LFSR sequence")
+
+// RecoverLFSR rebuilds a register that reproduces ks from its
+// first byte on, given at least 2L bits of it. The result is checked on
+// all of ks, so a few extra known bytes guard against a false
+// short polynomial.
+func RecoverLFSR(ks []byte) (*keystream.LFSR, error) {
+	s := Bits(ks)
+	L, c := BerlekampMassey(s)
+	if L == 0 || L > 64 || 2*L > len(s) {
+		return nil, ErrNotLFSR
+	}
+	var taps, seed uint64
+	for i := 1; i <= L; i++ {
+		taps |= uint64(c[i]) << (i - 1)
+	}
+	for j := range L {
+		seed |= uint64(s[j]) << j
+	}
+	// a sequence that starts with L zeros is not one our registers make
+	if seed == 0 || taps>>(L-1)&1 == 0 {
+		return nil, ErrNotLFSR
+	}
+	l, err := keystream.NewLFSR(L, taps, seed)
+	if err != nil {
+		return nil, err
+	}
+	check, _ := keystream.NewLFSR(L, taps, seed)
+	for _, bit := range s {
+		if check.Bit() != bit {
+			return nil, ErrNotLFSR
+		}
+	}
+	return l, nil
+}
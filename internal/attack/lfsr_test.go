@@ -0,0 +1,60 @@
+package attack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"information-defending/internal/keystream"
+	"testing"
+)
+
+func TestBerlekampMassey(t *testing.T) {
+	// s_n = s_(n-3) + s_(n-4) from 1001: complexity 4, c = 1 + x^3 + x^4
+	s := []byte{1, 0, 0, 1}
+	for n := 4; n < 20; n++ {
+		s = append(s, s[n-3]^s[n-4])
+	}
+	L, c := BerlekampMassey(s)
+	if L != 4 || !bytes.Equal(c, []byte{1, 0, 0, 1, 1}) {
+		t.Fatalf("L = %d, c = %v", L, c)
+	}
+	if L, _ := BerlekampMassey([]byte{0, 0, 0, 0}); L != 0 {
+		t.Fatalf("zeros: L = %d", L)
+	}
+}
+
+func TestRecoverLFSR(t *testing.T) {
+	l, err := keystream.DefaultLFSR.New(bytes.Repeat([]byte{0xa5}, keystream.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := make([]byte, 64)
+	l.XORKeyStream(ks, ks)
+
+	// 2L = 128 bits are enough, a few more bytes check the result
+	r, err := RecoverLFSR(ks[:20])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Length != 64 || r.Taps != keystream.DefaultLFSR.Taps {
+		t.Fatalf("length %d, taps %x", r.Length, r.Taps)
+	}
+	got := make([]byte, len(ks))
+	r.XORKeyStream(got, got)
+	if !bytes.Equal(got, ks) {
+		t.Fatal("recovered register gives another keystream")
+	}
+
+	random := make([]byte, 64)
+	rand.Read(random)
+	if _, err := RecoverLFSR(random); err != ErrNotLFSR {
+		t.Fatalf("random bytes: %v", err)
+	}
+	// fewer than 2L bits fit a shorter register that goes wrong later
+	if short, err := RecoverLFSR(ks[:8]); err == nil {
+		clear(got)
+		short.XORKeyStream(got, got)
+		if short.Length >= 64 || bytes.Equal(got, ks) {
+			t.Fatalf("64 bits gave a %d-bit register", short.Length)
+		}
+	}
+}
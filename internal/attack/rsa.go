@@ -0,0 +1,165 @@
+package attack
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rsa"
+	"math/big"
+)
+
+// CommonModulus recovers m from c1 = m^e1 and c2 = m^e2 mod N when
+// gcd(e1, e2) = 1: with a*e1 + b*e2 = 1, m = c1^a * c2^b mod N.
+func CommonModulus(c1, c2, e1, e2, N *big.Int) (*big.Int, error) {
+	a, b := new(big.Int), new(big.Int)
+	g := new(big.Int).GCD(a, b, e1, e2)
+	if g.Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("attack: gcd(e1, e2) = %s, exponents must be coprime", g)
+	}
+
+	// negative exponents use the inverse of the ciphertext
+	x := new(big.Int).Exp(c1, a, N)
+	y := new(big.Int).Exp(c2, b, N)
+	if x == nil || y == nil {
+		return nil, errors.New("attack: ciphertext is not invertible modulo N")
+	}
+	m := x.Mul(x, y)
+	return m.Mod(m, N), nil
+}
+
+// Hastad recovers m sent unpadded to e recipients with public exponent e.
+// By CRT m^e is known modulo N1*...*Ne > m^e, so it is an ordinary integer.
+func Hastad(ciphertexts, moduli []*big.Int, e int) (*big.Int, error) {
+	if len(ciphertexts) < e {
+		return nil, fmt.Errorf("attack: need %d ciphertexts for e = %d, got %d", e, e, len(ciphertexts))
+	}
+	x, _, err := crypto.CRT(ciphertexts[:e], moduli[:e])
+	if err != nil {
+		return nil, err
+	}
+
+	m := crypto.Root(x, e)
+	if new(big.Int).Exp(m, big.NewInt(int64(e)), nil).Cmp(x) != 0 {
+		return nil, errors.New("attack: CRT result is not an exact e-th power")
+	}
+	return m, nil
+}
+
+// CubeRoot recovers m from c = m^e mod N when m^e barely or never wraps
+// around N, by trying c + k*N for k < maxK.
+func CubeRoot(c, N *big.Int, e, maxK int) (*big.Int, error) {
+	bigE := big.NewInt(int64(e))
+	x := new(big.Int).Set(c)
+	for range maxK {
+		m := crypto.Root(x, e)
+		if new(big.Int).Exp(m, bigE, nil).Cmp(x) == 0 {
+			return m, nil
+		}
+		x.Add(x, N)
+	}
+	return nil, fmt.Errorf("attack: no exact %d-th root for k < %d", e, maxK)
+}
+
+// BatchGCD returns gcd(N_i, prod_{j != i} N_j) for every modulus using a
+// product tree and a remainder tree (Bernstein). A result other than 1
+// is a shared factor.
+func BatchGCD(moduli []*big.Int) []*big.Int {
+	if len(moduli) == 0 {
+		return nil
+	}
+
+	tree := [][]*big.Int{moduli}
+	for len(tree[len(tree)-1]) > 1 {
+		level := tree[len(tree)-1]
+		next := make([]*big.Int, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = new(big.Int).Mul(level[2*i], level[2*i+1])
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		tree = append(tree, next)
+	}
+
+	// walk down: r = parent mod child^2
+	rems := tree[len(tree)-1]
+	for i := len(tree) - 2; i >= 0; i-- {
+		level := tree[i]
+		next := make([]*big.Int, len(level))
+		for j, n := range level {
+			sq := new(big.Int).Mul(n, n)
+			next[j] = new(big.Int).Mod(rems[j/2], sq)
+		}
+		rems = next
+	}
+
+	gcds := make([]*big.Int, len(moduli))
+	for i, n := range moduli {
+		z := new(big.Int).Div(rems[i], n)
+		gcds[i] = new(big.Int).GCD(nil, nil, z, n)
+	}
+	return gcds
+}
+
+// Key generators for the lab: each one reproduces a bad configuration.
+
+// SharedModulusKeys returns two key pairs with the same N.
+func SharedModulusKeys(bits int, e1, e2 int64) (rsa.Keys, rsa.Keys, error) {
+	for {
+		k1, err := rsa.GenerateKeysWithExponent(bits, e1)
+		if err != nil {
+			return rsa.Keys{}, rsa.Keys{}, err
+		}
+		phi := big.NewInt(1)
+		for _, p := range k1.Primes {
+			phi.Mul(phi, new(big.Int).Sub(p, big.NewInt(1)))
+		}
+		d2 := big.NewInt(e2)
+		c2 := new(big.Int).ModInverse(d2, phi)
+		if c2 == nil {
+			continue
+		}
+		return k1, rsa.Keys{C: c2, D: d2, N: k1.N, Primes: k1.Primes}, nil
+	}
+}
+
+// BroadcastKeys returns count key pairs sharing a small public exponent.
+func BroadcastKeys(bits int, e int64, count int) ([]rsa.Keys, error) {
+	keys := make([]rsa.Keys, count)
+	for i := range keys {
+		k, err := rsa.GenerateKeysWithExponent(bits, e)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = k
+	}
+	return keys, nil
+}
+
+// SharedPrimeKeys returns count key pairs where every key shares one
+// prime with the next, as happens with a poorly seeded RNG.
+func SharedPrimeKeys(bits, count int) ([]rsa.Keys, error) {
+	primes := make([]*big.Int, count+1)
+	for i := range primes {
+		p, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		primes[i] = p
+	}
+
+	keys := make([]rsa.Keys, count)
+	e := big.NewInt(65537)
+	for i := range keys {
+		p, q := primes[i], primes[i+1]
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1)))
+		c := new(big.Int).ModInverse(e, phi)
+		if c == nil {
+			return SharedPrimeKeys(bits, count)
+		}
+		keys[i] = rsa.Keys{C: c, D: e, N: new(big.Int).Mul(p, q), Primes: []*big.Int{p, q}}
+	}
+	return keys, nil
+}
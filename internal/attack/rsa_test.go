@@ -0,0 +1,81 @@
+package attack
+
+import (
+	"information-defending/internal/rsa"
+	"math/big"
+	"testing"
+)
+
+func TestCommonModulus(t *testing.T) {
+	k1, k2, err := SharedModulusKeys(1024, 65537, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := big.NewInt(0xC0FFEE)
+	c1 := rsa.Encrypt(m, k1.D, k1.N)
+	c2 := rsa.Encrypt(m, k2.D, k2.N)
+	got, err := CommonModulus(c1, c2, k1.D, k2.D, k1.N)
+	if err != nil || got.Cmp(m) != 0 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if _, err := CommonModulus(c1, c2, big.NewInt(6), big.NewInt(9), k1.N); err == nil {
+		t.Fatal("exponents with a common factor accepted")
+	}
+}
+
+func TestHastad(t *testing.T) {
+	keys, err := BroadcastKeys(512, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// m^3 wraps around every N, so only the broadcast gives it away
+	m := new(big.Int).Lsh(big.NewInt(1), 400)
+	m.Add(m, big.NewInt(12345))
+	var cs, ns []*big.Int
+	for _, k := range keys {
+		cs = append(cs, rsa.Encrypt(m, k.D, k.N))
+		ns = append(ns, k.N)
+	}
+	got, err := Hastad(cs, ns, 3)
+	if err != nil || got.Cmp(m) != 0 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if _, err := Hastad(cs[:2], ns[:2], 3); err == nil {
+		t.Fatal("two ciphertexts accepted for e = 3")
+	}
+}
+
+func TestCubeRoot(t *testing.T) {
+	keys, err := BroadcastKeys(512, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := keys[0]
+	for _, m := range []*big.Int{big.NewInt(42), new(big.Int).Lsh(big.NewInt(1), 171)} {
+		got, err := CubeRoot(rsa.Encrypt(m, k.D, k.N), k.N, 3, 1<<12)
+		if err != nil || got.Cmp(m) != 0 {
+			t.Fatalf("m = %s: got %v, %v", m, got, err)
+		}
+	}
+}
+
+func TestBatchGCD(t *testing.T) {
+	keys, err := SharedPrimeKeys(256, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moduli := []*big.Int{big.NewInt(35)} // 5 * 7, shares nothing with the rest
+	for _, k := range keys {
+		moduli = append(moduli, k.N)
+	}
+	gcds := BatchGCD(moduli)
+	if gcds[0].Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("unrelated modulus: gcd %s", gcds[0])
+	}
+	for i, k := range keys {
+		g := gcds[i+1]
+		if g.Cmp(big.NewInt(1)) == 0 || new(big.Int).Mod(k.N, g).Sign() != 0 {
+			t.Fatalf("key %d: gcd %s", i, g)
+		}
+	}
+}
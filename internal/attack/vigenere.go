@@ -0,0 +1,180 @@
+package attack
+
+import (
+	"information-defending/internal/vernam"
+	"math"
+	"slices"
+	"unicode"
+)
+
+// letterIndices keeps only the letters of text, as alphabet numbers.
+func letterIndices(text string, a *vernam.Alphabet) []int {
+	var xs []int
+	for _, r := range text {
+		if i, _, ok := a.Index(r); ok {
+			xs = append(xs, i)
+		}
+	}
+	return xs
+}
+
+// IndexOfCoincidence is the chance that two letters picked from xs are
+// equal: about 0.066 for English and 0.055 for Russian text, 1/size for
+// random letters.
+func IndexOfCoincidence(xs []int, size int) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	counts := make([]int, size)
+	for _, x := range xs {
+		counts[x]++
+	}
+	var s float64
+	for _, c := range counts {
+		s += float64(c * (c - 1))
+	}
+	return s / float64(len(xs)*(len(xs)-1))
+}
+
+// Kasiski counts, for every length up to maxLen, how many distances
+// between repeated trigrams it divides. The key length divides most of
+// them, because a repeated word lined up with the same key letters
+// encrypts to the same trigram.
+func Kasiski(xs []int, maxLen int) []int {
+	votes := make([]int, maxLen+1)
+	last := map[[3]int]int{}
+	for i := 0; i+3 <= len(xs); i++ {
+		t := [3]int{xs[i], xs[i+1], xs[i+2]}
+		if j, ok := last[t]; ok {
+			for k := 2; k <= maxLen; k++ {
+				if (i-j)%k == 0 {
+					votes[k]++
+				}
+			}
+		}
+		last[t] = i
+	}
+	return votes
+}
+
+type VigenereLength struct {
+	Length  int
+	IC      float64 // mean index of coincidence of the columns
+	Kasiski int
+}
+
+// VigenereKeyLengths ranks key lengths by the mean index of coincidence of
+// the columns the key would make. Multiples of the key length score as
+// well and short columns are noisy, so lengths whose IC comes close to
+// that of plain text go first, shortest first.
+func VigenereKeyLengths(text string, a *vernam.Alphabet, maxLen int) []VigenereLength {
+	xs := letterIndices(text, a)
+	maxLen = min(maxLen, len(xs)/2)
+	if maxLen < 1 {
+		return nil
+	}
+	votes := Kasiski(xs, maxLen)
+	var out []VigenereLength
+	for k := 1; k <= maxLen; k++ {
+		var ic float64
+		for j := range k {
+			var col []int
+			for i := j; i < len(xs); i += k {
+				col = append(col, xs[i])
+			}
+			ic += IndexOfCoincidence(col, a.Size())
+		}
+		out = append(out, VigenereLength{Length: k, IC: ic / float64(k), Kasiski: votes[k]})
+	}
+
+	random := 1 / float64(a.Size())
+	var plain float64
+	for _, f := range letterFrequencies(a) {
+		plain += math.Exp(2 * f)
+	}
+	good := func(v VigenereLength) bool { return v.IC >= random+0.7*(plain-random) }
+	slices.SortStableFunc(out, func(x, y VigenereLength) int {
+		switch gx, gy := good(x), good(y); {
+		case gx && !gy:
+			return -1
+		case gy && !gx:
+			return 1
+		case gx && gy:
+			return x.Length - y.Length
+		}
+		return cmpFloat(y.IC, x.IC)
+	})
+
+	// A key like "cryptography" repeats letters half a key apart, which
+	// lifts half its length above the threshold too; a multiple that comes
+	// clearly closer to plain text is the real length.
+	if len(out) > 0 && good(out[0]) {
+		best := 0
+		for i, v := range out {
+			if good(v) && v.Length%out[best].Length == 0 && v.IC-out[best].IC > 0.3*(plain-random) {
+				best = i
+			}
+		}
+		v := out[best]
+		copy(out[1:best+1], out[:best])
+		out[0] = v
+	}
+	return out
+}
+
+// BreakVigenere finds the key length and then every key letter as the
+// shift whose column best fits the letter frequencies of the alphabet.
+func BreakVigenere(text string, a *vernam.Alphabet, maxLen int) (string, error) {
+	lengths := VigenereKeyLengths(text, a, maxLen)
+	if len(lengths) == 0 {
+		return "", ErrTooShort
+	}
+	xs := letterIndices(text, a)
+	freq := letterFrequencies(a)
+	k := lengths[0].Length
+	key := make([]rune, k)
+	for j := range k {
+		var col []int
+		for i := j; i < len(xs); i += k {
+			col = append(col, xs[i])
+		}
+		best, bestScore := 0, math.Inf(-1)
+		for s := range a.Size() {
+			var score float64
+			for _, x := range col {
+				score += freq[(x-s+a.Size())%a.Size()]
+			}
+			if score > bestScore {
+				best, bestScore = s, score
+			}
+		}
+		key[j] = a.Letter(best)
+	}
+	// a multiple of the length may still win on a short text
+	for p := 1; p < k; p++ {
+		if k%p == 0 && slices.Equal(key[p:], key[:k-p]) {
+			return string(key[:p]), nil
+		}
+	}
+	return string(key), nil
+}
+
+// letterFrequencies gives log frequencies of the alphabet's letters from
+// the Russian and English tables; letters in neither count as rare.
+func letterFrequencies(a *vernam.Alphabet) []float64 {
+	freq := make([]float64, a.Size())
+	var total float64
+	for i := range freq {
+		r := unicode.ToLower(a.Letter(i))
+		f := englishLetters[r] + russianLetters[r]
+		if f == 0 {
+			f = 0.05
+		}
+		freq[i] = f
+		total += f
+	}
+	for i := range freq {
+		freq[i] = math.Log(freq[i] / total)
+	}
+	return freq
+}
@@ -0,0 +1,73 @@
+package attack
+
+import (
+	"information-defending/internal/vernam"
+	"strings"
+	"testing"
+)
+
+const englishText = `It was a bright cold day in the middle of winter and the students
+of the cryptography course were gathered in the old laboratory on the third floor.
+The teacher wrote a short message on the board and asked them to encrypt it with
+the key they had chosen at the beginning of the lesson. Most of them picked a word
+that was easy to remember, and the teacher smiled, because a key that repeats is
+the weakness that lets anyone with enough patience read the message. She explained
+that the letters of a natural language are not used equally often, that the letter
+e appears far more often than the letter z, and that a repeated key turns the
+ciphertext into several simple shift ciphers written one after another. Once the
+length of the key is known, every column can be solved on its own by counting the
+letters and comparing the counts with the frequencies of the language. Then she
+showed them how the index of coincidence rises when the guessed length is right and
+how repeated groups of letters in the ciphertext point to the same length.`
+
+const russianText = `Шифр Виженера долгое время считался нераскрываемым, и его называли
+неразгаданным шифром. Однако в девятнадцатом веке Касиски заметил, что одинаковые
+фрагменты открытого текста, которые оказались на одинаковом расстоянии от начала
+ключа, превращаются в одинаковые фрагменты шифртекста. Расстояния между такими
+повторениями делятся на длину ключа, поэтому наибольший общий делитель этих
+расстояний часто совпадает с длиной ключа. Когда длина ключа известна, шифртекст
+разбивается на столбцы, и каждый столбец зашифрован простым сдвигом. Частоты букв
+в каждом столбце сравнивают с частотами букв русского языка, и самая вероятная
+буква ключа находится почти сразу. Индекс совпадений помогает проверить найденную
+длину: для осмысленного текста он заметно выше, чем для случайного набора букв.`
+
+func TestBreakVigenere(t *testing.T) {
+	for _, c := range []struct {
+		a    *vernam.Alphabet
+		text string
+		key  string
+	}{
+		{vernam.Latin, englishText, "lemon"},
+		{vernam.Latin, englishText, "cryptography"},
+		{vernam.Russian, russianText, "ключ"},
+		{vernam.Russian, russianText, "вернам"},
+	} {
+		v, err := vernam.NewVigenere(c.a, c.key, vernam.Keep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := v.Encrypt(c.text)
+		lengths := VigenereKeyLengths(ct, c.a, 20)
+		if len(lengths) == 0 || lengths[0].Length != len([]rune(c.key)) {
+			t.Fatalf("%s: key length ranked %+v", c.key, lengths[:3])
+		}
+		key, err := BreakVigenere(ct, c.a, 20)
+		if err != nil || key != c.key {
+			t.Fatalf("%s: got %q, %v", c.key, key, err)
+		}
+	}
+	if _, err := BreakVigenere("a", vernam.Latin, 20); err != ErrTooShort {
+		t.Fatalf("one letter: %v", err)
+	}
+}
+
+func TestIndexOfCoincidence(t *testing.T) {
+	xs := letterIndices(strings.Repeat("ab", 50), vernam.Latin)
+	if ic := IndexOfCoincidence(xs, 26); ic < 0.49 || ic > 0.5 {
+		t.Fatalf("IC of abab... = %f", ic)
+	}
+	plain := IndexOfCoincidence(letterIndices(englishText, vernam.Latin), 26)
+	if plain < 0.055 || plain > 0.08 {
+		t.Fatalf("IC of English = %f", plain)
+	}
+}
@@ -0,0 +1,80 @@
+package attack
+
+import (
+	"crypto/rand"
+	"errors"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rsa"
+	"math/big"
+)
+
+var ErrNotVulnerable = errors.New("attack: private exponent not found among the convergents")
+
+// Wiener recovers the private exponent c from the public key (d, N) when
+// c < N^(1/4)/3. Then e*c - k*phi = 1 and k/c is a convergent of d/N; each
+// candidate is checked by factoring N with the phi it implies.
+func Wiener(d, N *big.Int) (rsa.Keys, error) {
+	one := big.NewInt(1)
+	ks, cs := crypto.Convergents(crypto.ContinuedFraction(d, N))
+	for i := range ks {
+		k, c := ks[i], cs[i]
+		if k.Sign() == 0 {
+			continue
+		}
+
+		// phi = (d*c - 1) / k must be exact
+		phi, rem := new(big.Int).QuoRem(new(big.Int).Sub(new(big.Int).Mul(d, c), one), k, new(big.Int))
+		if rem.Sign() != 0 {
+			continue
+		}
+
+		// p and q are the roots of x^2 - (N - phi + 1)x + N
+		s := new(big.Int).Add(new(big.Int).Sub(N, phi), one)
+		disc := new(big.Int).Sub(new(big.Int).Mul(s, s), new(big.Int).Lsh(N, 2))
+		if disc.Sign() < 0 {
+			continue
+		}
+		t := new(big.Int).Sqrt(disc)
+		if new(big.Int).Mul(t, t).Cmp(disc) != 0 {
+			continue
+		}
+		p := new(big.Int).Rsh(new(big.Int).Add(s, t), 1)
+		q := new(big.Int).Rsh(new(big.Int).Sub(s, t), 1)
+		if new(big.Int).Mul(p, q).Cmp(N) != 0 {
+			continue
+		}
+		return rsa.Keys{C: c, D: d, N: N, Primes: []*big.Int{p, q}}, nil
+	}
+	return rsa.Keys{}, ErrNotVulnerable
+}
+
+// WienerKeys returns a key pair with the private exponent picked first and
+// just below the Wiener bound, as a "fast decryption" tweak would do.
+func WienerKeys(bits int) (rsa.Keys, error) {
+	for {
+		p, err := rand.Prime(rand.Reader, bits-bits/2)
+		if err != nil {
+			return rsa.Keys{}, err
+		}
+		q, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return rsa.Keys{}, err
+		}
+		N := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || N.BitLen() != bits {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1)))
+
+		// 3c < N^(1/4) holds for c of bits/4 - 2 bits
+		c, err := rand.Prime(rand.Reader, bits/4-2)
+		if err != nil {
+			return rsa.Keys{}, err
+		}
+		d := new(big.Int).ModInverse(c, phi)
+		if d == nil {
+			continue
+		}
+		return rsa.Keys{C: c, D: d, N: N, Primes: []*big.Int{p, q}}, nil
+	}
+}
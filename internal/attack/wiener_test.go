@@ -0,0 +1,31 @@
+package attack
+
+import (
+	"information-defending/internal/rsa"
+	"testing"
+)
+
+func TestWiener(t *testing.T) {
+	keys, err := WienerKeys(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rsa.IsWienerVulnerable(keys) {
+		t.Fatal("key below the bound not reported as vulnerable")
+	}
+	got, err := Wiener(keys.D, keys.N)
+	if err != nil || got.C.Cmp(keys.C) != 0 {
+		t.Fatalf("got %v, %v", got.C, err)
+	}
+
+	safe, err := rsa.GenerateKeysWithExponent(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsa.IsWienerVulnerable(safe) {
+		t.Fatal("e = 65537 key reported as vulnerable")
+	}
+	if _, err := Wiener(safe.D, safe.N); err != ErrNotVulnerable {
+		t.Fatalf("e = 65537 key: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package attack
+
+import (
+	"errors"
+	"math/bits"
+	"slices"
+)
+
+// XOR returns a ^ b over the length of a, repeating b; XOR(c, key) undoes
+// a repeating-key or single-byte cipher.
+func XOR(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i%len(b)]
+	}
+	return out
+}
+
+func Hamming(a, b []byte) int {
+	d := 0
+	for i := range min(len(a), len(b)) {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return d
+}
+
+// SingleByteXOR finds the key byte under which c reads best as l.
+func SingleByteXOR(c []byte, l *Language) (byte, float64) {
+	best, bestScore := byte(0), 0.0
+	buf := make([]byte, len(c))
+	for k := range 256 {
+		for i, b := range c {
+			buf[i] = b ^ byte(k)
+		}
+		if s := l.Score(buf); k == 0 || s > bestScore {
+			best, bestScore = byte(k), s
+		}
+	}
+	return best, bestScore
+}
+
+type KeySize struct {
+	Length   int
+	Distance float64 // mean Hamming distance per byte between blocks
+}
+
+// KeySizes ranks key lengths by the normalised Hamming distance between
+// consecutive blocks. Two plaintext bytes differ in fewer bits than two
+// random bytes, so the right length and its multiples come first.
+func KeySizes(c []byte, minLen, maxLen int) []KeySize {
+	var sizes []KeySize
+	for k := max(minLen, 1); k <= maxLen && 2*k <= len(c); k++ {
+		blocks := min(len(c)/k, 64)
+		var d float64
+		for i := 1; i < blocks; i++ {
+			d += float64(Hamming(c[(i-1)*k:i*k], c[i*k:(i+1)*k]))
+		}
+		sizes = append(sizes, KeySize{Length: k, Distance: d / float64((blocks-1)*k)})
+	}
+	slices.SortFunc(sizes, func(a, b KeySize) int { return cmpFloat(a.Distance, b.Distance) })
+	return sizes
+}
+
+var ErrTooShort = errors.New("attack: ciphertext is too short")
+
+// RepeatingKeyXOR breaks a repeating-key XOR cipher: it tries the most
+// likely key lengths, solves every column as a single-byte cipher and
+// keeps the key whose plaintext scores best.
+func RepeatingKeyXOR(c []byte, l *Language, maxLen int) ([]byte, error) {
+	sizes := KeySizes(c, 1, maxLen)
+	if len(sizes) == 0 {
+		return nil, ErrTooShort
+	}
+	var best []byte
+	var bestScore float64
+	for _, ks := range sizes[:min(len(sizes), 5)] {
+		key := make([]byte, ks.Length)
+		for j := range key {
+			var col []byte
+			for i := j; i < len(c); i += ks.Length {
+				col = append(col, c[i])
+			}
+			key[j], _ = SingleByteXOR(col, l)
+		}
+		key = minimalPeriod(key)
+		if s := l.Score(XOR(c, key)); best == nil || s > bestScore {
+			best, bestScore = key, s
+		}
+	}
+	return polish(c, best, l), nil
+}
+
+// polish revisits every key byte with the bigram model, which sees the
+// neighbours a column score cannot.
+func polish(c, key []byte, l *Language) []byte {
+	best := l.Score(XOR(c, key))
+	for j := range key {
+		old := key[j]
+		for k := range 256 {
+			key[j] = byte(k)
+			if s := l.Score(XOR(c, key)); s > best {
+				best, old = s, byte(k)
+			}
+		}
+		key[j] = old
+	}
+	return key
+}
+
+// minimalPeriod shortens a key that is itself a repetition, as found when
+// a multiple of the true length ranks first.
+func minimalPeriod(key []byte) []byte {
+	for p := 1; p < len(key); p++ {
+		if len(key)%p == 0 && slices.Equal(key[p:], key[:len(key)-p]) {
+			return key[:p]
+		}
+	}
+	return key
+}
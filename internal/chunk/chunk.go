@@ -0,0 +1,52 @@
+// Package chunk cuts messages into numbers for the three-pass packages
+// shamir and masseyomura and for elgamal. A chunk is the number 1 || data: the leading 1
+// keeps it above 1 and keeps leading zero bytes of data.
+package chunk
+
+import (
+	"errors"
+	"math/big"
+)
+
+var ErrGarbage = errors.New("chunk: decodes to garbage, wrong key?")
+
+// Size is the number of data bytes per chunk when a chunk may take at
+// most bits bits.
+func Size(bits int) int {
+	return (bits - 1) / 8
+}
+
+func Encode(data []byte) *big.Int {
+	return new(big.Int).SetBytes(append([]byte{1}, data...))
+}
+
+func Decode(m *big.Int) ([]byte, error) {
+	b := m.Bytes()
+	if len(b) < 2 || b[0] != 1 {
+		return nil, ErrGarbage
+	}
+	return b[1:], nil
+}
+
+// Split cuts msg into chunks of size bytes; the last one may be shorter.
+func Split(msg []byte, size int) []*big.Int {
+	var ms []*big.Int
+	for len(msg) > 0 {
+		n := min(size, len(msg))
+		ms = append(ms, Encode(msg[:n]))
+		msg = msg[n:]
+	}
+	return ms
+}
+
+func Join(ms []*big.Int) ([]byte, error) {
+	var msg []byte
+	for _, m := range ms {
+		data, err := Decode(m)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, data...)
+	}
+	return msg, nil
+}
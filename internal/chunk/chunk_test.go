@@ -0,0 +1,27 @@
+package chunk
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitJoin(t *testing.T) {
+	for _, msg := range [][]byte{nil, {0}, {0, 0, 1}, []byte("three-pass protocol")} {
+		ms := Split(msg, 4)
+		for _, m := range ms {
+			if m.BitLen() > 8*5-7 {
+				t.Fatalf("chunk %x is too long", m)
+			}
+		}
+		got, err := Join(ms)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("%q: got %q, %v", msg, got, err)
+		}
+	}
+	if _, err := Decode(Encode(nil)); err != ErrGarbage {
+		t.Fatalf("empty chunk: %v", err)
+	}
+	if Size(33) != 4 || Size(32) != 3 {
+		t.Fatalf("Size(33) = %d, Size(32) = %d", Size(33), Size(32))
+	}
+}
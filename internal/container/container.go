@@ -0,0 +1,405 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+)
+
+// File layout:
+//
+//	magic[4] | version[1] | algorithm[1] | fingerprint[8] | blockSize[4]
+//	[version 2 to 4: paramsLen[2] | params[paramsLen]]
+//	[version 3 and 4: mac[1]]
+//	[version 4: kdfLen[2] | kdf[kdfLen]]
+//	block[blockSize] ...
+//	originalLength[8] | tag
+//
+// The original length and the tag go into a trailer so that a file can be
+// written in one pass. The tag is SHA-256 over everything before it, or
+// the keyed MAC named in the header. Each version is only used when its
+// fields are needed, so files without algorithm parameters are still
+// written as version 1.
+const (
+	Magic         = "IDCF"
+	Version       = 1
+	VersionParams = 2
+	VersionMAC    = 3
+	VersionKDF    = 4
+	MaxParams     = 1<<16 - 1
+
+	HeaderSize  = 4 + 1 + 1 + 8 + 4
+	TagSize     = sha256.Size
+	TrailerSize = 8 + TagSize
+)
+
+type Algorithm byte
+
+const (
+	AlgVernam Algorithm = iota + 1
+	AlgRSA
+	AlgElGamal
+	AlgShamir
+	AlgRabin
+	AlgGM
+	AlgPaillier
+	AlgMasseyOmura
+	AlgKeystream
+	AlgMagma
+	AlgKuznyechik
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case AlgVernam:
+		return "vernam"
+	case AlgRSA:
+		return "rsa"
+	case AlgElGamal:
+		return "elgamal"
+	case AlgShamir:
+		return "shamir"
+	case AlgRabin:
+		return "rabin"
+	case AlgGM:
+		return "gm"
+	case AlgPaillier:
+		return "paillier"
+	case AlgMasseyOmura:
+		return "massey-omura"
+	case AlgKeystream:
+		return "vernam-keystream"
+	case AlgMagma:
+		return "magma"
+	case AlgKuznyechik:
+		return "kuznyechik"
+	}
+	return fmt.Sprintf("algorithm(%d)", byte(a))
+}
+
+var (
+	ErrNotContainer = errors.New("container: not a container file")
+	ErrWrongKey     = errors.New("container: file was encrypted with a different key")
+	ErrIntegrity    = errors.New("container: integrity check failed")
+)
+
+type Header struct {
+	Algorithm   Algorithm
+	Fingerprint [8]byte
+	BlockSize   uint32
+	Params      []byte // per-file algorithm parameters, authenticated by the tag
+	MAC         MAC
+	KDF         []byte // password key derivation parameters, see package kdf
+}
+
+// Fingerprint identifies a key by its public parameters.
+func Fingerprint(parts ...*big.Int) [8]byte {
+	h := sha256.New()
+	for _, p := range parts {
+		b := p.Bytes()
+		binary.Write(h, binary.BigEndian, uint32(len(b)))
+		h.Write(b)
+	}
+	var fp [8]byte
+	copy(fp[:], h.Sum(nil))
+	return fp
+}
+
+// Check reports whether the file can be decrypted with the given algorithm and key.
+func (h Header) Check(alg Algorithm, fp [8]byte) error {
+	if h.Algorithm != alg {
+		return fmt.Errorf("container: file is encrypted with %s, not %s", h.Algorithm, alg)
+	}
+	if h.Fingerprint != fp {
+		return ErrWrongKey
+	}
+	return nil
+}
+
+func (h Header) marshal() []byte {
+	buf := make([]byte, 0, HeaderSize+2+len(h.Params)+1+2+len(h.KDF))
+	buf = append(buf, Magic...)
+	switch {
+	case len(h.KDF) > 0:
+		buf = append(buf, VersionKDF, byte(h.Algorithm))
+	case h.MAC != MACNone:
+		buf = append(buf, VersionMAC, byte(h.Algorithm))
+	case len(h.Params) > 0:
+		buf = append(buf, VersionParams, byte(h.Algorithm))
+	default:
+		buf = append(buf, Version, byte(h.Algorithm))
+	}
+	buf = append(buf, h.Fingerprint[:]...)
+	buf = binary.BigEndian.AppendUint32(buf, h.BlockSize)
+	if len(h.Params) > 0 || h.MAC != MACNone || len(h.KDF) > 0 {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(h.Params)))
+		buf = append(buf, h.Params...)
+	}
+	if h.MAC != MACNone || len(h.KDF) > 0 {
+		buf = append(buf, byte(h.MAC))
+	}
+	if len(h.KDF) > 0 {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(h.KDF)))
+		buf = append(buf, h.KDF...)
+	}
+	return buf
+}
+
+func IsContainer(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+type Writer struct {
+	w      io.Writer
+	header Header
+	tag    hash.Hash
+}
+
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	if h.MAC != MACNone {
+		return nil, errors.New("container: authenticated files need NewMACWriter")
+	}
+	return newWriter(w, h, sha256.New())
+}
+
+// NewMACWriter tags the file with h.MAC under key instead of SHA-256.
+// Encrypt-then-MAC: the tag covers the header and the ciphertext.
+func NewMACWriter(w io.Writer, h Header, key []byte) (*Writer, error) {
+	if h.MAC == MACNone {
+		return nil, errors.New("container: no MAC algorithm in the header")
+	}
+	tag, err := h.MAC.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(w, h, tag)
+}
+
+// NewTagWriter is NewMACWriter for a tag keyed by the caller, for the MACs
+// that MAC.New cannot build. tag must be the MAC named in h.MAC.
+func NewTagWriter(w io.Writer, h Header, tag hash.Hash) (*Writer, error) {
+	if h.MAC == MACNone || tag.Size() != h.MAC.Size() {
+		return nil, fmt.Errorf("container: tag does not match MAC %s", h.MAC)
+	}
+	return newWriter(w, h, tag)
+}
+
+func newWriter(w io.Writer, h Header, tag hash.Hash) (*Writer, error) {
+	if h.BlockSize == 0 {
+		return nil, errors.New("container: zero block size")
+	}
+	if len(h.Params) > MaxParams || len(h.KDF) > MaxParams {
+		return nil, errors.New("container: parameters too long")
+	}
+	cw := &Writer{w: w, header: h, tag: tag}
+	if err := cw.write(h.marshal()); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *Writer) write(b []byte) error {
+	cw.tag.Write(b)
+	_, err := cw.w.Write(b)
+	return err
+}
+
+// WriteBlock writes one or more whole blocks.
+func (cw *Writer) WriteBlock(block []byte) error {
+	if len(block)%int(cw.header.BlockSize) != 0 {
+		return fmt.Errorf("container: %d bytes is not a multiple of block size %d", len(block), cw.header.BlockSize)
+	}
+	return cw.write(block)
+}
+
+// Close writes the trailer. It does not close the underlying writer.
+func (cw *Writer) Close(originalLength uint64) error {
+	if err := cw.write(binary.BigEndian.AppendUint64(nil, originalLength)); err != nil {
+		return err
+	}
+	_, err := cw.w.Write(cw.tag.Sum(nil))
+	return err
+}
+
+// Reader hands out blocks as it reads them and checks the tag only when
+// ReadBlock reaches the trailer, so anything decrypted from the blocks is
+// unverified until ReadBlock has returned io.EOF. stream.File removes the
+// output when decryption fails; callers of the stream functions have to
+// discard it themselves.
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	raw    []byte // the header as read, fed to the MAC by Authenticate
+	tag    hash.Hash
+	length uint64
+	done   bool
+}
+
+// NewReader parses the header. A file with a MAC in its header cannot be
+// read until Authenticate has supplied the key.
+func NewReader(r io.Reader) (*Reader, error) {
+	cr := &Reader{r: bufio.NewReader(r)}
+
+	raw := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(cr.r, raw); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotContainer
+		}
+		return nil, err
+	}
+	if !IsContainer(raw) {
+		return nil, ErrNotContainer
+	}
+	version := raw[4]
+	if version < Version || version > VersionKDF {
+		return nil, fmt.Errorf("container: unsupported version %d", version)
+	}
+
+	cr.header.Algorithm = Algorithm(raw[5])
+	copy(cr.header.Fingerprint[:], raw[6:14])
+	cr.header.BlockSize = binary.BigEndian.Uint32(raw[14:18])
+	if cr.header.BlockSize == 0 {
+		return nil, errors.New("container: zero block size")
+	}
+
+	var err error
+	if version >= VersionParams {
+		if cr.header.Params, raw, err = cr.readField(raw); err != nil {
+			return nil, err
+		}
+	}
+	if version >= VersionMAC {
+		m, err := cr.r.ReadByte()
+		if err != nil {
+			return nil, ErrIntegrity
+		}
+		cr.header.MAC = MAC(m)
+		if !cr.header.MAC.known() || version == VersionMAC && cr.header.MAC == MACNone {
+			return nil, fmt.Errorf("container: unknown MAC %d", m)
+		}
+		raw = append(raw, m)
+	}
+	if version >= VersionKDF {
+		if cr.header.KDF, raw, err = cr.readField(raw); err != nil {
+			return nil, err
+		}
+	}
+	if cr.header.MAC != MACNone {
+		cr.raw = raw
+		return cr, nil
+	}
+	cr.tag = sha256.New()
+	cr.tag.Write(raw)
+	return cr, nil
+}
+
+// readField reads a length-prefixed header field and appends it to raw.
+func (cr *Reader) readField(raw []byte) ([]byte, []byte, error) {
+	var size [2]byte
+	if _, err := io.ReadFull(cr.r, size[:]); err != nil {
+		return nil, nil, ErrIntegrity
+	}
+	field := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(cr.r, field); err != nil {
+		return nil, nil, ErrIntegrity
+	}
+	return field, append(append(raw, size[:]...), field...), nil
+}
+
+func (cr *Reader) Header() Header {
+	return cr.header
+}
+
+// Authenticate sets the MAC key. It must come before the first ReadBlock,
+// and the caller derives key the same way the writer did.
+func (cr *Reader) Authenticate(key []byte) error {
+	if cr.header.MAC == MACNone {
+		return errors.New("container: file has no MAC")
+	}
+	tag, err := cr.header.MAC.New(key)
+	if err != nil {
+		return err
+	}
+	tag.Write(cr.raw)
+	cr.tag = tag
+	return nil
+}
+
+// AuthenticateTag is Authenticate with a tag keyed by the caller, see
+// NewTagWriter.
+func (cr *Reader) AuthenticateTag(tag hash.Hash) error {
+	if cr.header.MAC == MACNone || tag.Size() != cr.header.MAC.Size() {
+		return fmt.Errorf("container: tag does not match MAC %s", cr.header.MAC)
+	}
+	tag.Reset()
+	tag.Write(cr.raw)
+	cr.tag = tag
+	return nil
+}
+
+func (cr *Reader) trailerSize() int {
+	return 8 + cr.header.MAC.Size()
+}
+
+// ReadBlock returns the next block, or io.EOF once the trailer has been
+// read and the tag verified.
+func (cr *Reader) ReadBlock() ([]byte, error) {
+	if cr.done {
+		return nil, io.EOF
+	}
+	if cr.tag == nil {
+		return nil, errors.New("container: file is authenticated, the MAC key is needed")
+	}
+
+	// Whatever is left after the last block is exactly the trailer.
+	ahead, err := cr.r.Peek(cr.trailerSize() + 1)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(ahead) <= cr.trailerSize() {
+		return nil, cr.readTrailer()
+	}
+
+	block := make([]byte, cr.header.BlockSize)
+	if _, err := io.ReadFull(cr.r, block); err != nil {
+		return nil, ErrIntegrity
+	}
+	cr.tag.Write(block)
+	return block, nil
+}
+
+func (cr *Reader) readTrailer() error {
+	trailer := make([]byte, cr.trailerSize())
+	if _, err := io.ReadFull(cr.r, trailer); err != nil {
+		return ErrIntegrity
+	}
+	cr.tag.Write(trailer[:8])
+	if subtle.ConstantTimeCompare(cr.tag.Sum(nil), trailer[8:]) != 1 {
+		if cr.header.MAC != MACNone {
+			return ErrAuth
+		}
+		return ErrIntegrity
+	}
+	cr.length = binary.BigEndian.Uint64(trailer[:8])
+	cr.done = true
+	return io.EOF
+}
+
+// Len returns the original plaintext length. It is valid after ReadBlock
+// has returned io.EOF.
+func (cr *Reader) Len() uint64 {
+	return cr.length
+}
+
+// Sniff reports whether r starts with a container header without consuming it.
+func Sniff(r *bufio.Reader) bool {
+	magic, _ := r.Peek(len(Magic))
+	return IsContainer(magic)
+}
@@ -0,0 +1,170 @@
+package container
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"io"
+	"math/big"
+	"testing"
+)
+
+func write(t *testing.T, h Header, key []byte, blocks ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var cw *Writer
+	var err error
+	if key != nil {
+		cw, err = NewMACWriter(&buf, h, key)
+	} else {
+		cw, err = NewWriter(&buf, h)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n uint64
+	for _, b := range blocks {
+		if err := cw.WriteBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		n += uint64(len(b))
+	}
+	if err := cw.Close(n); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readAll(data, key []byte) (Header, []byte, error) {
+	cr, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return Header{}, nil, err
+	}
+	if key != nil {
+		if err := cr.Authenticate(key); err != nil {
+			return Header{}, nil, err
+		}
+	}
+	var out []byte
+	for {
+		b, err := cr.ReadBlock()
+		if err == io.EOF {
+			return cr.Header(), out, nil
+		}
+		if err != nil {
+			return Header{}, nil, err
+		}
+		out = append(out, b...)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, MACKeySize)
+	headers := []struct {
+		h   Header
+		key []byte
+	}{
+		{Header{Algorithm: AlgRSA, BlockSize: 4}, nil},
+		{Header{Algorithm: AlgRSA, BlockSize: 4, Params: []byte("params")}, nil},
+		{Header{Algorithm: AlgVernam, BlockSize: 4, Params: []byte("nonce"), MAC: MACHMACSHA256}, key},
+		{Header{Algorithm: AlgVernam, BlockSize: 4, MAC: MACHMACStreebog}, key},
+		{Header{Algorithm: AlgVernam, BlockSize: 4, MAC: MACGOST28147}, key},
+		{Header{Algorithm: AlgKeystream, BlockSize: 4, MAC: MACHMACSHA256, KDF: []byte("kdf")}, key},
+	}
+	blocks := [][]byte{[]byte("abcd"), []byte("efgh")}
+	for _, c := range headers {
+		c.h.Fingerprint = Fingerprint(big.NewInt(42))
+		data := write(t, c.h, c.key, blocks...)
+		h, out, err := readAll(data, c.key)
+		if err != nil {
+			t.Fatalf("%+v: %v", c.h, err)
+		}
+		if !bytes.Equal(out, []byte("abcdefgh")) || !bytes.Equal(h.Params, c.h.Params) ||
+			!bytes.Equal(h.KDF, c.h.KDF) || h.MAC != c.h.MAC || h.Check(c.h.Algorithm, c.h.Fingerprint) != nil {
+			t.Fatalf("%+v: read back %+v, %q", c.h, h, out)
+		}
+	}
+}
+
+func TestTamper(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, MACKeySize)
+	plain := write(t, Header{Algorithm: AlgRSA, BlockSize: 4}, nil, []byte("abcd"))
+	mac := write(t, Header{Algorithm: AlgVernam, BlockSize: 4, MAC: MACGOST28147}, key, []byte("abcd"))
+
+	for i := HeaderSize; i < len(plain); i++ {
+		data := bytes.Clone(plain)
+		data[i] ^= 1
+		if _, _, err := readAll(data, nil); err != ErrIntegrity {
+			t.Fatalf("byte %d: %v", i, err)
+		}
+	}
+	for i := HeaderSize; i < len(mac); i++ {
+		data := bytes.Clone(mac)
+		data[i] ^= 1
+		if _, _, err := readAll(data, key); err == nil {
+			t.Fatalf("byte %d: modified file accepted", i)
+		}
+	}
+	if _, _, err := readAll(mac[:len(mac)-1], key); err == nil {
+		t.Fatal("truncated file accepted")
+	}
+	other := bytes.Repeat([]byte{8}, MACKeySize)
+	if _, _, err := readAll(mac, other); err != ErrAuth {
+		t.Fatalf("wrong MAC key: %v", err)
+	}
+}
+
+// TestTag uses HMAC-SHA256 as a stand-in for a MAC keyed outside the
+// package: only its size has to match the header.
+func TestTag(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, MACKeySize)
+	h := Header{Algorithm: AlgKuznyechik, BlockSize: 1, MAC: MACHMACSHA256}
+	var buf bytes.Buffer
+	cw, err := NewTagWriter(&buf, h, hmac.New(sha256.New, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.WriteBlock([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, out, err := readAll(buf.Bytes(), key); err != nil || string(out) != "abc" {
+		t.Fatalf("read back %q, %v", out, err)
+	}
+	cr, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cr.AuthenticateTag(hmac.New(sha256.New, key[:16])); err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		_, err = cr.ReadBlock()
+	}
+	if err != ErrAuth {
+		t.Fatalf("wrong tag key: %v", err)
+	}
+
+	h.MAC = MACKuznyechik
+	if _, err := NewTagWriter(new(bytes.Buffer), h, hmac.New(sha256.New, key)); err == nil {
+		t.Fatal("32-byte tag accepted for a 16-byte MAC")
+	}
+	if _, err := MACKuznyechik.New(key); err == nil {
+		t.Fatal("MAC.New builds a gost3413 MAC")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	h := Header{Algorithm: AlgRSA, Fingerprint: Fingerprint(big.NewInt(1))}
+	if err := h.Check(AlgRSA, Fingerprint(big.NewInt(2))); err != ErrWrongKey {
+		t.Fatalf("wrong key: %v", err)
+	}
+	if err := h.Check(AlgElGamal, h.Fingerprint); err == nil || err == ErrWrongKey {
+		t.Fatalf("wrong algorithm: %v", err)
+	}
+	if _, err := NewReader(bytes.NewReader([]byte("not a container file"))); err != ErrNotContainer {
+		t.Fatalf("text file: %v", err)
+	}
+}
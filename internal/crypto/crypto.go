@@ -152,6 +152,41 @@ func BSGS(a, y, p int64) []int64 {
 	return answer
 }
 
+// BSGSBound finds the smallest x in [0, max] with a^x = y mod p. BSGS works
+// in int64 and over the whole group; this one takes big p and only needs
+// about sqrt(max) steps and table entries.
+func BSGSBound(a, y, p *big.Int, max int64) (int64, bool) {
+	if max < 0 {
+		return 0, false
+	}
+	m := int64(math.Sqrt(float64(max))) + 1
+
+	// baby steps a^j, j < m; the first j wins
+	baby := make(map[string]int64, m)
+	aj := big.NewInt(1)
+	for j := int64(0); j < m; j++ {
+		if _, ok := baby[string(aj.Bytes())]; !ok {
+			baby[string(aj.Bytes())] = j
+		}
+		aj.Mul(aj, a).Mod(aj, p)
+	}
+
+	// giant steps y * a^(-im) for i = 0..m; aj is a^m now
+	step := new(big.Int).ModInverse(aj, p)
+	if step == nil {
+		return 0, false
+	}
+	g := new(big.Int).Mod(y, p)
+	for i := int64(0); i <= m; i++ {
+		if j, ok := baby[string(g.Bytes())]; ok {
+			x := i*m + j
+			return x, x <= max
+		}
+		g.Mul(g, step).Mod(g, p)
+	}
+	return 0, false
+}
+
 func RandBSGS() ([]int64, int64, int64, int64) {
 	a := GeneratePrime(2, 1000)
 	p := GeneratePrime(2, 1000)
@@ -257,3 +292,238 @@ func ReadBigInt(prompt string) *big.Int {
 		fmt.Println("Ошибка: введите целое число")
 	}
 }
+
+// ParseBigInt reads one decimal number of a text ciphertext or key file.
+func ParseBigInt(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("error while parsing number: %s", s)
+	}
+	return n, nil
+}
+
+// ParseBigInts reads decimal numbers separated by white space.
+func ParseBigInts(s string) ([]*big.Int, error) {
+	var nums []*big.Int
+	for _, field := range strings.Fields(s) {
+		n, err := ParseBigInt(field)
+		if err != nil {
+			return nil, err
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+func ByteLen(n *big.Int) int {
+	return (n.BitLen() + 7) / 8
+}
+
+// Root returns floor(x^(1/n)) for x >= 0 by Newton's method.
+func Root(x *big.Int, n int) *big.Int {
+	if x.Sign() == 0 || n == 1 {
+		return new(big.Int).Set(x)
+	}
+
+	bigN := big.NewInt(int64(n))
+	nMinus1 := big.NewInt(int64(n - 1))
+
+	// start above the root: 2^ceil(bits/n)
+	r := new(big.Int).Lsh(big.NewInt(1), uint((x.BitLen()+n-1)/n))
+	for {
+		// r' = ((n-1)*r + x / r^(n-1)) / n
+		t := new(big.Int).Exp(r, nMinus1, nil)
+		t.Div(x, t)
+		t.Add(t, new(big.Int).Mul(nMinus1, r))
+		t.Div(t, bigN)
+		if t.Cmp(r) >= 0 {
+			return r
+		}
+		r = t
+	}
+}
+
+// CRT solves x = residues[i] mod moduli[i] for pairwise coprime moduli and
+// returns x together with the product of the moduli.
+func CRT(residues, moduli []*big.Int) (*big.Int, *big.Int, error) {
+	if len(residues) != len(moduli) || len(moduli) == 0 {
+		return nil, nil, fmt.Errorf("crt: %d residues for %d moduli", len(residues), len(moduli))
+	}
+
+	M := big.NewInt(1)
+	for _, m := range moduli {
+		M.Mul(M, m)
+	}
+
+	x := new(big.Int)
+	for i, m := range moduli {
+		Mi := new(big.Int).Div(M, m)
+		inv := new(big.Int).ModInverse(Mi, m)
+		if inv == nil {
+			return nil, nil, fmt.Errorf("crt: moduli are not pairwise coprime")
+		}
+		t := new(big.Int).Mul(residues[i], Mi)
+		t.Mul(t, inv)
+		x.Add(x, t)
+	}
+	return x.Mod(x, M), M, nil
+}
+
+// ContinuedFraction returns the expansion [a0; a1, a2, ...] of a/b for
+// a >= 0, b > 0.
+func ContinuedFraction(a, b *big.Int) []*big.Int {
+	var cf []*big.Int
+	x, y := new(big.Int).Set(a), new(big.Int).Set(b)
+	for y.Sign() != 0 {
+		q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+		cf = append(cf, q)
+		x, y = y, r
+	}
+	return cf
+}
+
+// Convergents returns the numerators and denominators of h_i/k_i, the
+// successive approximations given by a continued fraction.
+func Convergents(cf []*big.Int) ([]*big.Int, []*big.Int) {
+	h := make([]*big.Int, len(cf))
+	k := make([]*big.Int, len(cf))
+	hPrev, kPrev := big.NewInt(1), big.NewInt(0)
+	hPrev2, kPrev2 := big.NewInt(0), big.NewInt(1)
+	for i, a := range cf {
+		// h_i = a_i*h_(i-1) + h_(i-2), same for k
+		h[i] = new(big.Int).Add(new(big.Int).Mul(a, hPrev), hPrev2)
+		k[i] = new(big.Int).Add(new(big.Int).Mul(a, kPrev), kPrev2)
+		hPrev2, kPrev2 = hPrev, kPrev
+		hPrev, kPrev = h[i], k[i]
+	}
+	return h, k
+}
+
+// Jacobi returns the Jacobi symbol (a/n) for odd n > 0. For prime n it is
+// the Legendre symbol: 1 for a square, -1 for a non-square, 0 if n | a.
+func Jacobi(a, n *big.Int) int {
+	a = new(big.Int).Mod(a, n)
+	n = new(big.Int).Set(n)
+	t := 1
+	for a.Sign() != 0 {
+		// (2/n) = -1 for n = 3, 5 mod 8
+		for a.Bit(0) == 0 {
+			a.Rsh(a, 1)
+			if r := n.Bits()[0] & 7; r == 3 || r == 5 {
+				t = -t
+			}
+		}
+		// quadratic reciprocity
+		a, n = n, a
+		if a.Bits()[0]&3 == 3 && n.Bits()[0]&3 == 3 {
+			t = -t
+		}
+		a.Mod(a, n)
+	}
+	if n.Cmp(big.NewInt(1)) == 0 {
+		return t
+	}
+	return 0
+}
+
+func Legendre(a, p *big.Int) int {
+	return Jacobi(a, p)
+}
+
+// ModSqrt returns a square root of a modulo an odd prime p: directly for
+// p = 3 mod 4, by Tonelli-Shanks otherwise.
+func ModSqrt(a, p *big.Int) (*big.Int, error) {
+	if p.Bit(1) == 1 {
+		if Legendre(a, p) == -1 {
+			return nil, fmt.Errorf("sqrt: %s is not a square modulo %s", a, p)
+		}
+		e := new(big.Int).Add(p, big.NewInt(1))
+		return new(big.Int).Exp(a, e.Rsh(e, 2), p), nil
+	}
+	return SqrtTonelliShanks(a, p)
+}
+
+func SqrtTonelliShanks(a, p *big.Int) (*big.Int, error) {
+	a = new(big.Int).Mod(a, p)
+	switch Legendre(a, p) {
+	case 0:
+		return new(big.Int), nil
+	case -1:
+		return nil, fmt.Errorf("sqrt: %s is not a square modulo %s", a, p)
+	}
+
+	// p - 1 = q * 2^s with q odd
+	one := big.NewInt(1)
+	q := new(big.Int).Sub(p, one)
+	s := 0
+	for q.Bit(0) == 0 {
+		q.Rsh(q, 1)
+		s++
+	}
+
+	z := big.NewInt(2)
+	for Legendre(z, p) != -1 {
+		z.Add(z, one)
+	}
+
+	m := s
+	c := new(big.Int).Exp(z, q, p)
+	t := new(big.Int).Exp(a, q, p)
+	r := new(big.Int).Exp(a, new(big.Int).Rsh(new(big.Int).Add(q, one), 1), p)
+	for t.Cmp(one) != 0 {
+		// least i with t^(2^i) = 1
+		i := 0
+		for t2 := new(big.Int).Set(t); t2.Cmp(one) != 0; i++ {
+			t2.Mul(t2, t2).Mod(t2, p)
+		}
+		b := new(big.Int).Exp(c, new(big.Int).Lsh(one, uint(m-i-1)), p)
+		m = i
+		c.Mul(b, b).Mod(c, p)
+		t.Mul(t, c).Mod(t, p)
+		r.Mul(r, b).Mod(r, p)
+	}
+	return r, nil
+}
+
+// SqrtCipolla computes (t + w)^((p+1)/2) in F_p[w], w^2 = t^2 - a, where
+// t is chosen so that t^2 - a is not a square.
+func SqrtCipolla(a, p *big.Int) (*big.Int, error) {
+	a = new(big.Int).Mod(a, p)
+	switch Legendre(a, p) {
+	case 0:
+		return new(big.Int), nil
+	case -1:
+		return nil, fmt.Errorf("sqrt: %s is not a square modulo %s", a, p)
+	}
+
+	one := big.NewInt(1)
+	t := big.NewInt(1)
+	w2 := new(big.Int)
+	for {
+		w2.Mul(t, t).Sub(w2, a).Mod(w2, p)
+		if Legendre(w2, p) == -1 {
+			break
+		}
+		t.Add(t, one)
+	}
+
+	// (x0 + x1 w)(y0 + y1 w) = x0 y0 + x1 y1 w^2 + (x0 y1 + x1 y0) w
+	mul := func(x0, x1, y0, y1 *big.Int) (*big.Int, *big.Int) {
+		r0 := new(big.Int).Mul(x1, y1)
+		r0.Mul(r0, w2).Add(r0, new(big.Int).Mul(x0, y0)).Mod(r0, p)
+		r1 := new(big.Int).Mul(x0, y1)
+		r1.Add(r1, new(big.Int).Mul(x1, y0)).Mod(r1, p)
+		return r0, r1
+	}
+
+	e := new(big.Int).Rsh(new(big.Int).Add(p, one), 1)
+	r0, r1 := big.NewInt(1), big.NewInt(0)
+	b0, b1 := new(big.Int).Set(t), big.NewInt(1)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r0, r1 = mul(r0, r1, r0, r1)
+		if e.Bit(i) == 1 {
+			r0, r1 = mul(r0, r1, b0, b1)
+		}
+	}
+	return r0, nil
+}
@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSqrt(t *testing.T) {
+	// 3 mod 4, 5 mod 8 and 1 mod 8 with a large power of two in p-1
+	primes := []int64{1000003, 1000037, 998244353}
+	funcs := map[string]func(a, p *big.Int) (*big.Int, error){
+		"ModSqrt":           ModSqrt,
+		"SqrtTonelliShanks": SqrtTonelliShanks,
+		"SqrtCipolla":       SqrtCipolla,
+	}
+	for _, pv := range primes {
+		p := big.NewInt(pv)
+		for x := int64(1); x < 200; x += 7 {
+			a := new(big.Int).Exp(big.NewInt(x), big.NewInt(2), p)
+			for name, f := range funcs {
+				r, err := f(a, p)
+				if err != nil {
+					t.Fatalf("%s(%s, %d): %v", name, a, pv, err)
+				}
+				if new(big.Int).Exp(r, big.NewInt(2), p).Cmp(a) != 0 {
+					t.Fatalf("%s(%s, %d) = %s is not a root", name, a, pv, r)
+				}
+			}
+		}
+		// a non-residue has no root
+		for n := int64(2); ; n++ {
+			if Legendre(big.NewInt(n), p) == -1 {
+				for name, f := range funcs {
+					if _, err := f(big.NewInt(n), p); err == nil {
+						t.Fatalf("%s(%d, %d): root of a non-residue", name, n, pv)
+					}
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestBSGSBound(t *testing.T) {
+	p := big.NewInt(1000003)
+	g := big.NewInt(2)
+	for _, m := range []int64{0, 1, 999, 1000} {
+		y := new(big.Int).Exp(g, big.NewInt(m), p)
+		got, ok := BSGSBound(g, y, p, 1000)
+		if !ok || got != m {
+			t.Fatalf("m = %d: got %d, %v", m, got, ok)
+		}
+	}
+	if _, ok := BSGSBound(g, new(big.Int).Exp(g, big.NewInt(5000), p), p, 1000); ok {
+		t.Fatal("found a logarithm above the bound")
+	}
+}
+
+func TestParseBigInts(t *testing.T) {
+	nums, err := ParseBigInts(" 1\n-22\t333 ")
+	if err != nil || len(nums) != 3 || nums[1].Int64() != -22 {
+		t.Fatalf("got %v, %v", nums, err)
+	}
+	if _, err := ParseBigInts("1 x"); err == nil {
+		t.Fatal("x accepted")
+	}
+}
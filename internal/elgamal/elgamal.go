@@ -1,10 +1,17 @@
 package elgamal
 
 import (
+	"bufio"
 	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"information-defending/internal/chunk"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/stream"
+	"io"
 	"math/big"
-	"os"
-	"strings"
+	"sync/atomic"
 )
 
 type Keys struct {
@@ -170,52 +177,165 @@ func ElGamalDecrypt(e, r, p, Cb *big.Int) *big.Int {
 	return m.Mod(m, p)
 }
 
-func EncryptFile(inputFile, outputFile string, p, g, Db, k *big.Int) error {
-	data, err := os.ReadFile(inputFile)
+// ChunkSize is the number of message bytes per block. A block encrypts
+// 1 || data, which stays below p.
+func ChunkSize(p *big.Int) int {
+	return chunk.Size(p.BitLen() - 1)
+}
+
+// ephemeralK draws k in [2, p-2], a fresh one for every block.
+func ephemeralK(p *big.Int) (*big.Int, error) {
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(p, big.NewInt(3)))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return k.Add(k, big.NewInt(2)), nil
+}
+
+func EncryptFile(inputFile, outputFile string, p, g, Db *big.Int) error {
+	return stream.File(inputFile, outputFile, func(in io.Reader, out io.Writer) error {
+		return EncryptStream(in, out, p, g, Db)
+	})
+}
 
-	result := []*big.Int{}
+func DecryptFile(inputFile, outputFile string, p, Cb *big.Int) error {
+	return stream.File(inputFile, outputFile, func(in io.Reader, out io.Writer) error {
+		return DecryptStream(in, out, p, Cb)
+	})
+}
 
-	for _, b := range data {
-		m := big.NewInt(int64(b))
-		r, e := ElGamalEncrypt(p, g, Db, k, m)
-		result = append(result, r)
-		result = append(result, e)
+// EncryptStream writes one (r, e) block per ChunkSize(p) bytes with its own
+// k, so equal chunks encrypt differently. Params hold the chunk size and
+// tell these files from the old ones with one byte per block.
+func EncryptStream(in io.Reader, out io.Writer, p, g, Db *big.Int) error {
+	chunkSize := ChunkSize(p)
+	if chunkSize < 1 {
+		return fmt.Errorf("elgamal: p = %s is too small, need at least 10 bits", p)
+	}
+	size := crypto.ByteLen(p)
+	cw, err := container.NewWriter(out, container.Header{
+		Algorithm:   container.AlgElGamal,
+		Fingerprint: container.Fingerprint(p),
+		BlockSize:   uint32(2 * size),
+		Params:      binary.BigEndian.AppendUint16(nil, uint16(chunkSize)),
+	})
+	if err != nil {
+		return err
 	}
 
-	out := ""
-	for _, val := range result {
-		out += val.String() + "\n"
+	var n uint64
+	err = stream.Map(stream.Count(stream.Blocks(in, chunkSize), &n), func(b []byte) ([]byte, error) {
+		k, err := ephemeralK(p)
+		if err != nil {
+			return nil, err
+		}
+		r, e := ElGamalEncrypt(p, g, Db, k, chunk.Encode(b))
+		block := make([]byte, 2*size)
+		r.FillBytes(block[:size])
+		e.FillBytes(block[size:])
+		return block, nil
+	}, cw.WriteBlock, 0)
+	if err != nil {
+		return err
 	}
-	return os.WriteFile(outputFile, []byte(out), 0644)
+	return cw.Close(n)
 }
 
-func DecryptFile(inputFile, outputFile string, p, Cb *big.Int) error {
-	data, err := os.ReadFile(inputFile)
+// DecryptStream leaves unverified output in out until it returns nil, see
+// container.Reader.
+func DecryptStream(in io.Reader, out io.Writer, p, Cb *big.Int) error {
+	br := bufio.NewReader(in)
+	if !container.Sniff(br) {
+		return decryptText(br, out, p, Cb)
+	}
+
+	cr, err := container.NewReader(br)
+	if err != nil {
+		return err
+	}
+	h := cr.Header()
+	if err := h.Check(container.AlgElGamal, container.Fingerprint(p)); err != nil {
+		return err
+	}
+
+	size := crypto.ByteLen(p)
+	if h.BlockSize != uint32(2*size) {
+		return container.ErrIntegrity
+	}
+	// files without params are the fixed-k format, one byte per block
+	decode := decodeByte
+	if len(h.Params) != 0 {
+		if len(h.Params) != 2 || int(binary.BigEndian.Uint16(h.Params)) != ChunkSize(p) {
+			return fmt.Errorf("elgamal: bad chunk size in the header")
+		}
+		decode = decodeChunk
+	}
+
+	var wrongKey atomic.Bool
+	var n uint64
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		r := new(big.Int).SetBytes(b[:size])
+		e := new(big.Int).SetBytes(b[size:])
+		data, ok := decode(ElGamalDecrypt(e, r, p, Cb), ChunkSize(p))
+		if !ok {
+			wrongKey.Store(true)
+			return nil, nil
+		}
+		return data, nil
+	}, stream.Writer(out, &n), 0)
 	if err != nil {
 		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	var result []byte
+	if wrongKey.Load() {
+		return container.ErrWrongKey
+	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
 
-	flag := true
-	var r, e *big.Int
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+func decodeByte(m *big.Int, _ int) ([]byte, bool) {
+	if !m.IsInt64() || m.Int64() > 255 {
+		return nil, false
+	}
+	return []byte{byte(m.Int64())}, true
+}
+
+func decodeChunk(m *big.Int, size int) ([]byte, bool) {
+	data, err := chunk.Decode(m)
+	if err != nil || len(data) > size {
+		return nil, false
+	}
+	return data, true
+}
+
+// decryptText reads the old format: r and e of every byte on alternating lines.
+func decryptText(in io.Reader, out io.Writer, p, Cb *big.Int) error {
+	lines := stream.Lines(in)
+	pairs := func() ([]byte, error) {
+		rLine, err := lines()
+		if err != nil {
+			return nil, err
 		}
-		if flag {
-			r, _ = new(big.Int).SetString(line, 10)
-		} else {
-			e, _ = new(big.Int).SetString(line, 10)
-			dec := ElGamalDecrypt(e, r, p, Cb)
-			result = append(result, byte(dec.Int64()))
+		eLine, err := lines()
+		if err == io.EOF {
+			return nil, fmt.Errorf("missing e for r = %s, expected (r, e) pairs", rLine)
 		}
-		flag = !flag
+		if err != nil {
+			return nil, err
+		}
+		return append(append(rLine, ' '), eLine...), nil
 	}
 
-	return os.WriteFile(outputFile, result, 0644)
+	var n uint64
+	return stream.Map(pairs, func(pair []byte) ([]byte, error) {
+		var r, e big.Int
+		if _, err := fmt.Sscan(string(pair), &r, &e); err != nil {
+			return nil, fmt.Errorf("error while parsing pair %q: %v", pair, err)
+		}
+		dec := ElGamalDecrypt(&e, &r, p, Cb)
+		return []byte{byte(dec.Int64())}, nil
+	}, stream.Writer(out, &n), 0)
 }
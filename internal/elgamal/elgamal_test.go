@@ -0,0 +1,155 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"information-defending/internal/container"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func testKeys(t *testing.T) *Keys {
+	t.Helper()
+	k, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func encrypt(t *testing.T, k *Keys, data []byte) []byte {
+	t.Helper()
+	var enc bytes.Buffer
+	if err := EncryptStream(bytes.NewReader(data), &enc, k.P, k.G, k.Y); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+func decrypt(enc []byte, k *Keys) ([]byte, error) {
+	var dec bytes.Buffer
+	err := DecryptStream(bytes.NewReader(enc), &dec, k.P, k.X)
+	return dec.Bytes(), err
+}
+
+// blocks returns the (r, e) blocks of a file.
+func blocks(t *testing.T, enc []byte) [][]byte {
+	t.Helper()
+	cr, err := container.NewReader(bytes.NewReader(enc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bs [][]byte
+	for {
+		b, err := cr.ReadBlock()
+		if err == io.EOF {
+			return bs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs = append(bs, bytes.Clone(b))
+	}
+}
+
+func TestStream(t *testing.T) {
+	k := testKeys(t)
+	n := ChunkSize(k.P)
+	for _, size := range []int{0, 1, n - 1, n, n + 1, 5*n + 3} {
+		data := make([]byte, size)
+		rand.Read(data)
+		if size > 0 {
+			data[0] = 0 // leading zero bytes survive
+		}
+		enc := encrypt(t, k, data)
+		if got := len(blocks(t, enc)); got != (size+n-1)/n {
+			t.Errorf("%d bytes in %d blocks, want %d", size, got, (size+n-1)/n)
+		}
+		got, err := decrypt(enc, k)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("%d bytes: decrypted %x, want %x", size, got, data)
+		}
+	}
+}
+
+// TestFreshK encrypts equal chunks: every block must have its own r.
+func TestFreshK(t *testing.T) {
+	k := testKeys(t)
+	data := bytes.Repeat([]byte{'a'}, 8*ChunkSize(k.P))
+	seen := map[string]bool{}
+	for _, b := range blocks(t, encrypt(t, k, data)) {
+		r := string(b[:len(b)/2])
+		if seen[r] {
+			t.Fatal("two blocks share r = g^k")
+		}
+		seen[r] = true
+	}
+	if a, b := encrypt(t, k, data), encrypt(t, k, data); bytes.Equal(a, b) {
+		t.Fatal("two encryptions of a file are equal")
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	k := testKeys(t)
+	enc := encrypt(t, k, []byte("attack at dawn, bring everything"))
+	other := *k
+	other.X = new(big.Int).Add(k.X, big.NewInt(1))
+	if _, err := decrypt(enc, &other); err != container.ErrWrongKey {
+		t.Errorf("wrong x: %v", err)
+	}
+	if _, err := decrypt(enc, testKeys(t)); err != container.ErrWrongKey {
+		t.Errorf("other p: %v", err)
+	}
+}
+
+// TestFixedK shows why the fixed-k format is only a demo: the first byte
+// gives Db^k and with it the second.
+func TestFixedK(t *testing.T) {
+	k := testKeys(t)
+	data := []byte("Hi")
+	var enc bytes.Buffer
+	if err := EncryptStreamUnsafeFixedK(bytes.NewReader(data), &enc, k.P, k.G, k.Y, big.NewInt(12345)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decrypt(enc.Bytes(), k)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("decrypted %q, %v", got, err)
+	}
+
+	bs := blocks(t, enc.Bytes())
+	size := len(bs[0]) / 2
+	if !bytes.Equal(bs[0][:size], bs[1][:size]) {
+		t.Fatal("fixed k gives different r")
+	}
+	s := new(big.Int).SetBytes(bs[0][size:])
+	s.Mul(s, new(big.Int).ModInverse(big.NewInt(int64(data[0])), k.P)).Mod(s, k.P)
+	m := new(big.Int).SetBytes(bs[1][size:])
+	m.Mul(m, s.ModInverse(s, k.P)).Mod(m, k.P)
+	if m.Int64() != int64(data[1]) {
+		t.Fatalf("recovered %d, want %d", m, data[1])
+	}
+}
+
+func TestLegacyText(t *testing.T) {
+	k := testKeys(t)
+	data := []byte("old\nfile")
+	var text strings.Builder
+	for _, b := range data {
+		r, e := ElGamalEncrypt(k.P, k.G, k.Y, big.NewInt(777), big.NewInt(int64(b)))
+		fmt.Fprintf(&text, "%s\n%s\n", r, e)
+	}
+	got, err := decrypt([]byte(text.String()), k)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("decrypted %q, %v", got, err)
+	}
+	lines := strings.Split(text.String(), "\n")
+	if _, err := decrypt([]byte(strings.Join(lines[:3], "\n")), k); err == nil {
+		t.Fatal("r without e accepted")
+	}
+}
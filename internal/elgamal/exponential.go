@@ -0,0 +1,156 @@
+package elgamal
+
+import (
+	"crypto/rand"
+	"errors"
+	"information-defending/internal/crypto"
+	"math/big"
+)
+
+// Exponential ElGamal encrypts h^m instead of m: (h^k, h^m * y'^k).
+// Multiplying two ciphertexts component-wise adds the plaintexts, so
+// ballots or counter increments can be summed without the private key.
+// Decryption gets back h^m and has to find m by BSGS, so m must be small.
+//
+// GenerateG gives a generator of all of Z_p^*, a non-residue, and then the
+// Legendre symbols of y, r and e reveal m mod 2. So this mode works in the
+// subgroup of quadratic residues of prime order q = (p-1)/2, with h = g^2
+// and y' = y^2 = h^x; the keys stay the same.
+
+type PublicKey struct {
+	P *big.Int
+	G *big.Int
+	Y *big.Int
+}
+
+type Ciphertext struct {
+	R *big.Int
+	E *big.Int
+}
+
+var (
+	ErrCiphertext = errors.New("elgamal: ciphertext out of range")
+	// ErrNotFound means the plaintext is above the search bound, or the
+	// ciphertext was made under another key.
+	ErrNotFound = errors.New("elgamal: plaintext not found below the bound")
+)
+
+func (k *Keys) Public() *PublicKey {
+	return &PublicKey{P: k.P, G: k.G, Y: k.Y}
+}
+
+func square(x, p *big.Int) *big.Int {
+	return new(big.Int).Exp(x, big.NewInt(2), p)
+}
+
+// order is q, the order of the subgroup of residues.
+func (pub *PublicKey) order() *big.Int {
+	q := new(big.Int).Sub(pub.P, big.NewInt(1))
+	return q.Rsh(q, 1)
+}
+
+// valid reports whether both parts of c are residues in [1, p).
+func (pub *PublicKey) valid(c Ciphertext) error {
+	for _, x := range []*big.Int{c.R, c.E} {
+		if x == nil || x.Sign() <= 0 || x.Cmp(pub.P) >= 0 || crypto.Jacobi(x, pub.P) != 1 {
+			return ErrCiphertext
+		}
+	}
+	return nil
+}
+
+func (pub *PublicKey) encryptExp(m *big.Int) (Ciphertext, error) {
+	// k in [1, q)
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(pub.order(), big.NewInt(1)))
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	k.Add(k, big.NewInt(1))
+	h := square(pub.G, pub.P)
+	hm := new(big.Int).Exp(h, m, pub.P)
+	r, e := ElGamalEncrypt(pub.P, h, square(pub.Y, pub.P), k, hm)
+	return Ciphertext{R: r, E: e}, nil
+}
+
+func (pub *PublicKey) EncryptExp(m int64) (Ciphertext, error) {
+	if m < 0 {
+		return Ciphertext{}, errors.New("elgamal: message must not be negative")
+	}
+	return pub.encryptExp(big.NewInt(m))
+}
+
+// Add returns an encryption of m1 + m2.
+func (pub *PublicKey) Add(c1, c2 Ciphertext) (Ciphertext, error) {
+	if err := pub.valid(c1); err != nil {
+		return Ciphertext{}, err
+	}
+	if err := pub.valid(c2); err != nil {
+		return Ciphertext{}, err
+	}
+	r := new(big.Int).Mul(c1.R, c2.R)
+	e := new(big.Int).Mul(c1.E, c2.E)
+	return Ciphertext{R: r.Mod(r, pub.P), E: e.Mod(e, pub.P)}, nil
+}
+
+// Sum adds up cs; with no ciphertexts it is (1, 1), the encryption of 0
+// with k = 0.
+func (pub *PublicKey) Sum(cs ...Ciphertext) (Ciphertext, error) {
+	sum := Ciphertext{R: big.NewInt(1), E: big.NewInt(1)}
+	for _, c := range cs {
+		if err := pub.valid(c); err != nil {
+			return Ciphertext{}, err
+		}
+		sum.R.Mul(sum.R, c.R).Mod(sum.R, pub.P)
+		sum.E.Mul(sum.E, c.E).Mod(sum.E, pub.P)
+	}
+	return sum, nil
+}
+
+// AddPlain returns an encryption of m + n.
+func (pub *PublicKey) AddPlain(c Ciphertext, n int64) (Ciphertext, error) {
+	if err := pub.valid(c); err != nil {
+		return Ciphertext{}, err
+	}
+	e := new(big.Int).Exp(square(pub.G, pub.P), pub.exponent(n), pub.P)
+	e.Mul(e, c.E).Mod(e, pub.P)
+	return Ciphertext{R: new(big.Int).Set(c.R), E: e}, nil
+}
+
+// Mul returns an encryption of n*m.
+func (pub *PublicKey) Mul(c Ciphertext, n int64) (Ciphertext, error) {
+	if err := pub.valid(c); err != nil {
+		return Ciphertext{}, err
+	}
+	x := pub.exponent(n)
+	return Ciphertext{R: new(big.Int).Exp(c.R, x, pub.P), E: new(big.Int).Exp(c.E, x, pub.P)}, nil
+}
+
+// Rerandomize adds a fresh encryption of 0: the plaintext stays the same
+// and the result is unlinkable to c.
+func (pub *PublicKey) Rerandomize(c Ciphertext) (Ciphertext, error) {
+	zero, err := pub.encryptExp(new(big.Int))
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	return pub.Add(c, zero)
+}
+
+// exponent maps n into [0, q), so a negative n works as q - |n|.
+func (pub *PublicKey) exponent(n int64) *big.Int {
+	return new(big.Int).Mod(big.NewInt(n), pub.order())
+}
+
+// DecryptExp recovers m in [0, max] in about sqrt(max) steps.
+func (k *Keys) DecryptExp(c Ciphertext, max int64) (int64, error) {
+	pub := k.Public()
+	if err := pub.valid(c); err != nil {
+		return 0, err
+	}
+	// r^x = h^(kx) = y'^k, whatever the order of g
+	hm := ElGamalDecrypt(c.E, c.R, k.P, k.X)
+	m, ok := crypto.BSGSBound(square(k.G, k.P), hm, k.P, max)
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return m, nil
+}
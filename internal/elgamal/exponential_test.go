@@ -0,0 +1,129 @@
+package elgamal
+
+import (
+	"information-defending/internal/crypto"
+	"math/big"
+	"testing"
+)
+
+func encryptExp(t *testing.T, pub *PublicKey, m int64) Ciphertext {
+	t.Helper()
+	c, err := pub.EncryptExp(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func decryptExp(t *testing.T, k *Keys, c Ciphertext, bound int64) int64 {
+	t.Helper()
+	m, err := k.DecryptExp(c, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestTally(t *testing.T) {
+	k := testKeys(t)
+	pub := k.Public()
+	votes := []int64{1, 0, 1, 1, 0, 1, 0, 0, 1, 1, 1, 0, 1}
+	var ballots []Ciphertext
+	var yes int64
+	for _, v := range votes {
+		ballots = append(ballots, encryptExp(t, pub, v))
+		yes += v
+	}
+	sum, err := pub.Sum(ballots...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decryptExp(t, k, sum, int64(len(votes))); got != yes {
+		t.Fatalf("tally is %d, want %d", got, yes)
+	}
+	empty, err := pub.Sum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decryptExp(t, k, empty, 0); got != 0 {
+		t.Fatalf("empty tally is %d", got)
+	}
+}
+
+func TestExpOps(t *testing.T) {
+	k := testKeys(t)
+	pub := k.Public()
+	c1, c2 := encryptExp(t, pub, 40), encryptExp(t, pub, 2)
+	c, err := pub.Add(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decryptExp(t, k, c, 1000); got != 42 {
+		t.Errorf("40 + 2 = %d", got)
+	}
+	if c, err = pub.AddPlain(c1, -15); err != nil {
+		t.Fatal(err)
+	}
+	if got := decryptExp(t, k, c, 1000); got != 25 {
+		t.Errorf("40 - 15 = %d", got)
+	}
+	if c, err = pub.Mul(c1, 7); err != nil {
+		t.Fatal(err)
+	}
+	if got := decryptExp(t, k, c, 1000); got != 280 {
+		t.Errorf("40 * 7 = %d", got)
+	}
+	if c, err = pub.Rerandomize(c1); err != nil {
+		t.Fatal(err)
+	}
+	if c.R.Cmp(c1.R) == 0 || c.E.Cmp(c1.E) == 0 {
+		t.Error("rerandomized ciphertext equals the original")
+	}
+	if got := decryptExp(t, k, c, 1000); got != 40 {
+		t.Errorf("rerandomized 40 = %d", got)
+	}
+}
+
+// TestResidues checks that ciphertexts stay in the subgroup of residues,
+// so their Legendre symbols say nothing about m mod 2.
+func TestResidues(t *testing.T) {
+	k := testKeys(t)
+	pub := k.Public()
+	for m := range int64(8) {
+		c := encryptExp(t, pub, m)
+		if crypto.Jacobi(c.R, k.P) != 1 || crypto.Jacobi(c.E, k.P) != 1 {
+			t.Fatalf("m = %d: a part of the ciphertext is a non-residue", m)
+		}
+	}
+	// g generates all of Z_p^* and is a non-residue
+	bad := Ciphertext{R: new(big.Int).Set(k.G), E: big.NewInt(1)}
+	good := encryptExp(t, pub, 1)
+	if _, err := pub.Add(good, bad); err != ErrCiphertext {
+		t.Errorf("non-residue r: %v", err)
+	}
+	if _, err := k.DecryptExp(Ciphertext{R: big.NewInt(0), E: big.NewInt(1)}, 10); err != ErrCiphertext {
+		t.Errorf("r = 0: %v", err)
+	}
+	if _, err := k.DecryptExp(Ciphertext{R: good.R, E: new(big.Int).Set(k.P)}, 10); err != ErrCiphertext {
+		t.Errorf("e = p: %v", err)
+	}
+	if _, err := pub.EncryptExp(-1); err == nil {
+		t.Error("negative message accepted")
+	}
+}
+
+func TestExpBound(t *testing.T) {
+	k := testKeys(t)
+	c := encryptExp(t, k.Public(), 1000)
+	if _, err := k.DecryptExp(c, 999); err != ErrNotFound {
+		t.Errorf("bound below m: %v", err)
+	}
+	if got := decryptExp(t, k, c, 1000); got != 1000 {
+		t.Errorf("decrypted %d, want 1000", got)
+	}
+	other := *k
+	other.X = new(big.Int).Add(k.X, big.NewInt(1))
+	if _, err := other.DecryptExp(c, 1<<20); err != ErrNotFound {
+		t.Errorf("wrong x: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package elgamal
+
+import (
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+)
+
+// EncryptFileUnsafeFixedK is the old teaching scheme: every byte is its own
+// block under the one k from the caller. All blocks share r = g^k and
+// e_i = m_i * Db^k, so one known byte gives Db^k and with it every other
+// byte (see attack.ElGamalFixedK). Use EncryptFile for anything real.
+func EncryptFileUnsafeFixedK(inputFile, outputFile string, p, g, Db, k *big.Int) error {
+	return stream.File(inputFile, outputFile, func(in io.Reader, out io.Writer) error {
+		return EncryptStreamUnsafeFixedK(in, out, p, g, Db, k)
+	})
+}
+
+func EncryptStreamUnsafeFixedK(in io.Reader, out io.Writer, p, g, Db, k *big.Int) error {
+	size := crypto.ByteLen(p)
+	cw, err := container.NewWriter(out, container.Header{
+		Algorithm:   container.AlgElGamal,
+		Fingerprint: container.Fingerprint(p),
+		BlockSize:   uint32(2 * size),
+	})
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	err = stream.Map(stream.Count(stream.Blocks(in, 1), &n), func(b []byte) ([]byte, error) {
+		r, e := ElGamalEncrypt(p, g, Db, k, big.NewInt(int64(b[0])))
+		block := make([]byte, 2*size)
+		r.FillBytes(block[:size])
+		e.FillBytes(block[size:])
+		return block, nil
+	}, cw.WriteBlock, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
@@ -0,0 +1,298 @@
+// Package gf2n implements arithmetic in the binary fields GF(2^n) in a
+// polynomial basis. An element is a polynomial of degree below n over
+// GF(2), stored as the bits of a big.Int: bit i is the coefficient of x^i.
+package gf2n
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+type Field struct {
+	N    int
+	Poly *big.Int // irreducible, degree N
+
+	terms []int // exponents below N present in Poly
+	words int
+}
+
+// NewField checks that poly is irreducible and returns GF(2)[x]/(poly).
+func NewField(poly *big.Int) (*Field, error) {
+	if poly.BitLen() < 2 {
+		return nil, errors.New("gf2n: polynomial degree must be at least 1")
+	}
+	f := newField(poly)
+	if !f.irreducible() {
+		return nil, fmt.Errorf("gf2n: %s is reducible", Format(poly))
+	}
+	return f, nil
+}
+
+// Standard returns GF(2^n) with the irreducible trinomial x^n + x^k + 1 of
+// smallest k or, when there is none, the pentanomial
+// x^n + x^a + x^b + x^c + 1 with a, b, c smallest in that order, as in
+// IEEE 1363 and the NIST curves.
+func Standard(n int) (*Field, error) {
+	if n < 2 {
+		return nil, errors.New("gf2n: n must be at least 2")
+	}
+	for k := 1; k < n; k++ {
+		if f := newField(polynomial(n, k, 0)); f.irreducible() {
+			return f, nil
+		}
+	}
+	for a := 3; a < n; a++ {
+		for b := 2; b < a; b++ {
+			for c := 1; c < b; c++ {
+				if f := newField(polynomial(n, a, b, c, 0)); f.irreducible() {
+					return f, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("gf2n: no irreducible pentanomial of degree %d", n)
+}
+
+func polynomial(exps ...int) *big.Int {
+	p := new(big.Int)
+	for _, e := range exps {
+		p.SetBit(p, e, 1)
+	}
+	return p
+}
+
+func newField(poly *big.Int) *Field {
+	n := poly.BitLen() - 1
+	f := &Field{N: n, Poly: poly, words: (n + 63) / 64}
+	for i := range n {
+		if poly.Bit(i) == 1 {
+			f.terms = append(f.terms, i)
+		}
+	}
+	return f
+}
+
+// Format prints a polynomial as x^n + ... + 1.
+func Format(poly *big.Int) string {
+	var s string
+	for i := poly.BitLen() - 1; i >= 0; i-- {
+		if poly.Bit(i) == 0 {
+			continue
+		}
+		if s != "" {
+			s += " + "
+		}
+		switch i {
+		case 0:
+			s += "1"
+		case 1:
+			s += "x"
+		default:
+			s += fmt.Sprintf("x^%d", i)
+		}
+	}
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// Order is the size of the multiplicative group, 2^n - 1.
+func (f *Field) Order() *big.Int {
+	o := new(big.Int).Lsh(big.NewInt(1), uint(f.N))
+	return o.Sub(o, big.NewInt(1))
+}
+
+// Contains reports whether a is an element, a polynomial of degree < n.
+func (f *Field) Contains(a *big.Int) bool {
+	return a.Sign() >= 0 && a.BitLen() <= f.N
+}
+
+func (f *Field) Add(a, b *big.Int) *big.Int {
+	return new(big.Int).Xor(a, b)
+}
+
+func (f *Field) Mul(a, b *big.Int) *big.Int {
+	return f.fromWords(f.mul(f.toWords(a), f.toWords(b)))
+}
+
+// Exp computes a^k by square-and-multiply.
+func (f *Field) Exp(a, k *big.Int) *big.Int {
+	if k.Sign() < 0 {
+		return f.Exp(f.Inv(a), new(big.Int).Neg(k))
+	}
+	base := f.toWords(a)
+	r := make([]uint64, f.words)
+	r[0] = 1
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = f.sqr(r)
+		if k.Bit(i) == 1 {
+			r = f.mul(r, base)
+		}
+	}
+	return f.fromWords(r)
+}
+
+// Inv returns a^-1 = a^(2^n - 2), or nil for a = 0.
+func (f *Field) Inv(a *big.Int) *big.Int {
+	if a.Sign() == 0 {
+		return nil
+	}
+	return f.Exp(a, new(big.Int).Sub(f.Order(), big.NewInt(1)))
+}
+
+func (f *Field) toWords(a *big.Int) []uint64 {
+	buf := a.FillBytes(make([]byte, 8*f.words))
+	w := make([]uint64, f.words)
+	for i := range w {
+		w[i] = binary.BigEndian.Uint64(buf[8*(f.words-1-i):])
+	}
+	return w
+}
+
+func (f *Field) fromWords(w []uint64) *big.Int {
+	buf := make([]byte, 8*len(w))
+	for i, x := range w {
+		binary.BigEndian.PutUint64(buf[8*(len(w)-1-i):], x)
+	}
+	return new(big.Int).SetBytes(buf)
+}
+
+// mul multiplies without carries and reduces modulo Poly.
+func (f *Field) mul(a, b []uint64) []uint64 {
+	prod := make([]uint64, 2*f.words)
+	for i, x := range a {
+		if x == 0 {
+			continue
+		}
+		for j, y := range b {
+			hi, lo := clmul(x, y)
+			prod[i+j] ^= lo
+			prod[i+j+1] ^= hi
+		}
+	}
+	return f.reduce(prod)
+}
+
+// clmul is the carry-less product of two words, four bits of y at a time.
+func clmul(x, y uint64) (hi, lo uint64) {
+	var tlo, thi [16]uint64
+	for k := 1; k < 16; k++ {
+		for b := range 4 {
+			if k>>b&1 == 1 {
+				tlo[k] ^= x << b
+				if b > 0 {
+					thi[k] ^= x >> (64 - b)
+				}
+			}
+		}
+	}
+	for i := 60; i >= 0; i -= 4 {
+		k := y >> i & 15
+		lo ^= tlo[k] << i
+		hi ^= thi[k] << i
+		if i > 0 {
+			hi ^= tlo[k] >> (64 - i)
+		}
+	}
+	return hi, lo
+}
+
+// sqr squares by spreading the bits apart, which is linear over GF(2).
+func (f *Field) sqr(a []uint64) []uint64 {
+	p := make([]uint64, 2*f.words)
+	for i, x := range a {
+		p[2*i] = spread(uint32(x))
+		p[2*i+1] = spread(uint32(x >> 32))
+	}
+	return f.reduce(p)
+}
+
+func spread(x uint32) uint64 {
+	v := uint64(x)
+	v = (v | v<<16) & 0x0000ffff0000ffff
+	v = (v | v<<8) & 0x00ff00ff00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f0f0f0f0f
+	v = (v | v<<2) & 0x3333333333333333
+	v = (v | v<<1) & 0x5555555555555555
+	return v
+}
+
+// reduce clears the bits at N and above a word at a time: w * x^N is
+// replaced by w times the lower terms of Poly, which moves every bit down.
+func (f *Field) reduce(p []uint64) []uint64 {
+	for i := len(p) - 1; i >= 0; i-- {
+		lo := f.N - 64*i
+		if lo >= 64 {
+			break
+		}
+		for {
+			w := p[i]
+			if lo > 0 {
+				w = w >> lo << lo
+			}
+			if w == 0 {
+				break
+			}
+			p[i] ^= w
+			for _, t := range f.terms {
+				xorAt(p, w, 64*i-f.N+t)
+			}
+		}
+	}
+	return p[:f.words]
+}
+
+// xorAt adds w placed at bit offset off; bits pushed below 0 are zero.
+func xorAt(p []uint64, w uint64, off int) {
+	if off < 0 {
+		w >>= -off
+		off = 0
+	}
+	q, r := off/64, off%64
+	p[q] ^= w << r
+	if r > 0 && q+1 < len(p) {
+		p[q+1] ^= w >> (64 - r)
+	}
+}
+
+// irreducible is Ben-Or's test: Poly of degree n is irreducible iff
+// gcd(x^(2^i) - x, Poly) = 1 for every i <= n/2.
+func (f *Field) irreducible() bool {
+	if f.Poly.Bit(0) == 0 {
+		return f.N == 1
+	}
+	x := f.toWords(big.NewInt(2))
+	if f.N == 1 {
+		return true
+	}
+	u := x
+	for range f.N / 2 {
+		u = f.sqr(u)
+		d := f.fromWords(u)
+		d.Xor(d, big.NewInt(2))
+		if polyGCD(d, f.Poly).BitLen() > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func polyMod(a, m *big.Int) *big.Int {
+	r := new(big.Int).Set(a)
+	dm := m.BitLen()
+	for r.BitLen() >= dm {
+		r.Xor(r, new(big.Int).Lsh(m, uint(r.BitLen()-dm)))
+	}
+	return r
+}
+
+func polyGCD(a, b *big.Int) *big.Int {
+	a, b = new(big.Int).Set(a), new(big.Int).Set(b)
+	for b.Sign() != 0 {
+		a, b = b, polyMod(a, b)
+	}
+	return a
+}
@@ -0,0 +1,108 @@
+package gf2n
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// slowMul multiplies bit by bit and reduces with polyMod.
+func slowMul(a, b, poly *big.Int) *big.Int {
+	p := new(big.Int)
+	for i := range b.BitLen() {
+		if b.Bit(i) == 1 {
+			p.Xor(p, new(big.Int).Lsh(a, uint(i)))
+		}
+	}
+	return polyMod(p, poly)
+}
+
+func randElement(t *testing.T, f *Field) *big.Int {
+	t.Helper()
+	a, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(f.N)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// TestStandard compares with the AES field of FIPS 197 and the NIST
+// binary curve fields of FIPS 186.
+func TestStandard(t *testing.T) {
+	for n, want := range map[int]*big.Int{
+		8:   polynomial(8, 4, 3, 1, 0),
+		163: polynomial(163, 7, 6, 3, 0),
+		233: polynomial(233, 74, 0),
+		283: polynomial(283, 12, 7, 5, 0),
+		409: polynomial(409, 87, 0),
+		571: polynomial(571, 10, 5, 2, 0),
+	} {
+		f, err := Standard(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Poly.Cmp(want) != 0 {
+			t.Errorf("Standard(%d) = %s, want %s", n, Format(f.Poly), Format(want))
+		}
+	}
+	if got := Format(polynomial(8, 4, 3, 1, 0)); got != "x^8 + x^4 + x^3 + x + 1" {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestNewField(t *testing.T) {
+	// (x + 1)^2 and x^4 + x^2 + 1 = (x^2 + x + 1)^2
+	for _, poly := range []*big.Int{polynomial(2, 0), polynomial(4, 2, 0), big.NewInt(1)} {
+		if _, err := NewField(poly); err == nil {
+			t.Errorf("%s accepted", Format(poly))
+		}
+	}
+	if _, err := NewField(polynomial(127, 1, 0)); err != nil {
+		t.Errorf("x^127 + x + 1: %v", err)
+	}
+}
+
+func TestAES(t *testing.T) {
+	f, err := NewField(polynomial(8, 4, 3, 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// FIPS 197 section 4.2 and the inverse used by the S-box
+	if got := f.Mul(big.NewInt(0x57), big.NewInt(0x83)); got.Int64() != 0xc1 {
+		t.Errorf("{57} * {83} = %x", got)
+	}
+	if got := f.Inv(big.NewInt(0x53)); got.Int64() != 0xca {
+		t.Errorf("{53}^-1 = %x", got)
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	for _, n := range []int{8, 64, 65, 127, 163, 571} {
+		f, err := Standard(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		one := big.NewInt(1)
+		for range 20 {
+			a, b, c := randElement(t, f), randElement(t, f), randElement(t, f)
+			if got, want := f.Mul(a, b), slowMul(a, b, f.Poly); got.Cmp(want) != 0 {
+				t.Fatalf("n = %d: %x * %x = %x, want %x", n, a, b, got, want)
+			}
+			if f.Mul(a, f.Add(b, c)).Cmp(f.Add(f.Mul(a, b), f.Mul(a, c))) != 0 {
+				t.Fatalf("n = %d: not distributive", n)
+			}
+			if a.Sign() == 0 {
+				continue
+			}
+			if f.Mul(a, f.Inv(a)).Cmp(one) != 0 || f.Exp(a, f.Order()).Cmp(one) != 0 {
+				t.Fatalf("n = %d: a = %x has no inverse", n, a)
+			}
+			if f.Exp(a, big.NewInt(-1)).Cmp(f.Inv(a)) != 0 {
+				t.Fatalf("n = %d: a^-1 differs from Inv(a)", n)
+			}
+		}
+		if f.Inv(new(big.Int)) != nil {
+			t.Errorf("n = %d: 0 has an inverse", n)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package gost3412
+
+import "crypto/cipher"
+
+// NewBlock returns the constructor of the cipher called name, or nil.
+func NewBlock(name string) func([]byte) (cipher.Block, error) {
+	switch name {
+	case "kuznyechik":
+		return func(key []byte) (cipher.Block, error) { return NewKuznyechik(key) }
+	case "magma":
+		return func(key []byte) (cipher.Block, error) { return NewMagma(key) }
+	}
+	return nil
+}
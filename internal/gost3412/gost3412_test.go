@@ -0,0 +1,95 @@
+package gost3412
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ftomza/gogost/gost3412128"
+	"github.com/ftomza/gogost/gost341264"
+)
+
+// Examples from GOST R 34.12-2015, appendices A.1 and A.2.
+var vectors = []struct {
+	name                  string
+	key, plain, encrypted string
+}{
+	{
+		"kuznyechik",
+		"8899aabbccddeeff0011223344556677fedcba98765432100123456789abcdef",
+		"1122334455667700ffeeddccbbaa9988",
+		"7f679d90bebc24305a468d42b9d4edcd",
+	},
+	{
+		"magma",
+		"ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		"fedcba9876543210",
+		"4ee901e5c2d8ca3d",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			key, _ := hex.DecodeString(v.key)
+			plain, _ := hex.DecodeString(v.plain)
+			want, _ := hex.DecodeString(v.encrypted)
+			b, err := NewBlock(v.name)(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, len(plain))
+			b.Encrypt(got, plain)
+			if !bytes.Equal(got, want) {
+				t.Errorf("encrypts to %x, want %x", got, want)
+			}
+			b.Decrypt(got, want)
+			if !bytes.Equal(got, plain) {
+				t.Errorf("decrypts to %x, want %x", got, plain)
+			}
+		})
+	}
+}
+
+// TestGogost compares both ciphers with gogost on random keys and blocks.
+func TestGogost(t *testing.T) {
+	for _, name := range []string{"kuznyechik", "magma"} {
+		t.Run(name, func(t *testing.T) {
+			for range 100 {
+				key := make([]byte, KeySize)
+				rand.Read(key)
+				b, err := NewBlock(name)(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var ref interface{ Encrypt(dst, src []byte) }
+				if name == "magma" {
+					ref = gost341264.NewCipher(key)
+				} else {
+					ref = gost3412128.NewCipher(key)
+				}
+				plain := make([]byte, b.BlockSize())
+				rand.Read(plain)
+				got := make([]byte, len(plain))
+				want := make([]byte, len(plain))
+				b.Encrypt(got, plain)
+				ref.Encrypt(want, plain)
+				if !bytes.Equal(got, want) {
+					t.Fatalf("key %x block %x: %x, gogost %x", key, plain, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestKeySize(t *testing.T) {
+	for _, name := range []string{"kuznyechik", "magma"} {
+		if _, err := NewBlock(name)(make([]byte, KeySize-1)); err == nil {
+			t.Errorf("%s takes a %d-byte key", name, KeySize-1)
+		}
+	}
+	if NewBlock("aes") != nil {
+		t.Error("NewBlock knows aes")
+	}
+}
@@ -0,0 +1,206 @@
+package gost3412
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const KuznyechikBlockSize = 16
+
+// kuzPi is the substitution of GOST R 34.12-2015 section 4.1.1.
+var kuzPi = [256]byte{
+	252, 238, 221, 17, 207, 110, 49, 22, 251, 196, 250, 218, 35, 197, 4, 77,
+	233, 119, 240, 219, 147, 46, 153, 186, 23, 54, 241, 187, 20, 205, 95, 193,
+	249, 24, 101, 90, 226, 92, 239, 33, 129, 28, 60, 66, 139, 1, 142, 79,
+	5, 132, 2, 174, 227, 106, 143, 160, 6, 11, 237, 152, 127, 212, 211, 31,
+	235, 52, 44, 81, 234, 200, 72, 171, 242, 42, 104, 162, 253, 58, 206, 204,
+	181, 112, 14, 86, 8, 12, 118, 18, 191, 114, 19, 71, 156, 183, 93, 135,
+	21, 161, 150, 41, 16, 123, 154, 199, 243, 145, 120, 111, 157, 158, 178, 177,
+	50, 117, 25, 61, 255, 53, 138, 126, 109, 84, 198, 128, 195, 189, 13, 87,
+	223, 245, 36, 169, 62, 168, 67, 201, 215, 121, 214, 246, 124, 34, 185, 3,
+	224, 15, 236, 222, 122, 148, 176, 188, 220, 232, 40, 80, 78, 51, 10, 74,
+	167, 151, 96, 115, 30, 0, 98, 68, 26, 184, 56, 130, 100, 159, 38, 65,
+	173, 69, 70, 146, 39, 94, 85, 47, 140, 163, 165, 125, 105, 213, 149, 59,
+	7, 88, 179, 64, 134, 172, 29, 247, 48, 55, 107, 228, 136, 217, 231, 137,
+	225, 27, 131, 73, 76, 63, 248, 254, 141, 83, 170, 144, 202, 216, 133, 97,
+	32, 113, 103, 164, 45, 43, 9, 91, 203, 155, 37, 208, 190, 229, 108, 82,
+	89, 166, 116, 210, 230, 244, 180, 192, 209, 102, 175, 194, 57, 75, 99, 182,
+}
+
+// kuzL are the coefficients of the linear function ℓ, a15 first.
+var kuzL = [16]byte{148, 32, 133, 16, 194, 192, 1, 251, 1, 192, 194, 16, 133, 32, 148, 1}
+
+type block [KuznyechikBlockSize]byte
+
+// half is a block as two big-endian words, for the table rounds.
+type half [2]uint64
+
+var (
+	kuzPiInv [256]byte
+	// kuzLS[i][b] is L(S(x)) for x zero except b at byte i; L is linear,
+	// so a round is the XOR of sixteen rows. kuzLInv is the same for L⁻¹.
+	kuzLS   [16][256]half
+	kuzLInv [16][256]half
+	kuzC    [32]block
+)
+
+func (a *block) half() half {
+	return half{binary.BigEndian.Uint64(a[:8]), binary.BigEndian.Uint64(a[8:])}
+}
+
+// gfMul multiplies in GF(2^8) modulo x^8 + x^7 + x^6 + x + 1.
+func gfMul(a, b byte) byte {
+	var c byte
+	for b > 0 {
+		if b&1 != 0 {
+			c ^= a
+		}
+		if a&0x80 != 0 {
+			a = a<<1 ^ 0xc3
+		} else {
+			a <<= 1
+		}
+		b >>= 1
+	}
+	return c
+}
+
+// kuzR is one step of L: the register shifts towards a0 and ℓ comes in
+// as a15. Byte 0 of the array is a15, as the standard writes blocks.
+func kuzR(a *block) {
+	t := a[15]
+	for i := 14; i >= 0; i-- {
+		a[i+1] = a[i]
+		t ^= gfMul(a[i], kuzL[i])
+	}
+	a[0] = t
+}
+
+func kuzRInv(a *block) {
+	t := a[0]
+	for i := range 15 {
+		a[i] = a[i+1]
+		t ^= gfMul(a[i], kuzL[i])
+	}
+	a[15] = t
+}
+
+func kuzLinear(a *block) {
+	for range 16 {
+		kuzR(a)
+	}
+}
+
+func kuzLinearInv(a *block) {
+	for range 16 {
+		kuzRInv(a)
+	}
+}
+
+func init() {
+	for i, p := range kuzPi {
+		kuzPiInv[p] = byte(i)
+	}
+	for i := range 16 {
+		for b := range 256 {
+			var x block
+			x[i] = kuzPi[b]
+			kuzLinear(&x)
+			kuzLS[i][b] = x.half()
+			x = block{}
+			x[i] = byte(b)
+			kuzLinearInv(&x)
+			kuzLInv[i][b] = x.half()
+		}
+	}
+	for i := range kuzC {
+		kuzC[i][15] = byte(i + 1)
+		kuzLinear(&kuzC[i])
+	}
+}
+
+func (a half) xor(b half) half {
+	return half{a[0] ^ b[0], a[1] ^ b[1]}
+}
+
+// rows XORs the table rows picked by the sixteen bytes of a.
+func rows(t *[16][256]half, a half) half {
+	var out half
+	for i := range 8 {
+		x, y := &t[i][byte(a[0]>>(56-8*i))], &t[8+i][byte(a[1]>>(56-8*i))]
+		out[0] ^= x[0] ^ y[0]
+		out[1] ^= x[1] ^ y[1]
+	}
+	return out
+}
+
+func kuzLSX(a half) half {
+	return rows(&kuzLS, a)
+}
+
+func kuzSInvLInv(a half) half {
+	a = rows(&kuzLInv, a)
+	for j := range a {
+		var w uint64
+		for i := range 8 {
+			w |= uint64(kuzPiInv[byte(a[j]>>(56-8*i))]) << (56 - 8*i)
+		}
+		a[j] = w
+	}
+	return a
+}
+
+// Kuznyechik is the 128-bit block cipher of GOST R 34.12-2015.
+type Kuznyechik struct {
+	k [10]half
+}
+
+func NewKuznyechik(key []byte) (*Kuznyechik, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("gost3412: Kuznyechik key must be %d bytes, not %d", KeySize, len(key))
+	}
+	c := &Kuznyechik{}
+	var k0, k1 block
+	copy(k0[:], key)
+	copy(k1[:], key[16:])
+	c.k[0], c.k[1] = k0.half(), k1.half()
+	// eight Feistel rounds F[C] per pair of round keys
+	for i := 1; i < 5; i++ {
+		a1, a0 := c.k[2*i-2], c.k[2*i-1]
+		for j := range 8 {
+			t := kuzLSX(a1.xor(kuzC[8*(i-1)+j].half()))
+			a1, a0 = t.xor(a0), a1
+		}
+		c.k[2*i], c.k[2*i+1] = a1, a0
+	}
+	return c, nil
+}
+
+func (c *Kuznyechik) BlockSize() int {
+	return KuznyechikBlockSize
+}
+
+func load(src []byte) half {
+	return half{binary.BigEndian.Uint64(src), binary.BigEndian.Uint64(src[8:16])}
+}
+
+func (a half) store(dst []byte) {
+	binary.BigEndian.PutUint64(dst, a[0])
+	binary.BigEndian.PutUint64(dst[8:16], a[1])
+}
+
+func (c *Kuznyechik) Encrypt(dst, src []byte) {
+	a := load(src)
+	for i := range 9 {
+		a = kuzLSX(a.xor(c.k[i]))
+	}
+	a.xor(c.k[9]).store(dst)
+}
+
+func (c *Kuznyechik) Decrypt(dst, src []byte) {
+	a := load(src)
+	for i := 9; i > 0; i-- {
+		a = kuzSInvLInv(a.xor(c.k[i]))
+	}
+	a.xor(c.k[0]).store(dst)
+}
@@ -0,0 +1,89 @@
+package gost3412
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	MagmaBlockSize = 8
+	KeySize        = 32
+)
+
+// magmaPi is the fixed substitution of GOST R 34.12-2015 section 5.1.1;
+// row i replaces the i-th nibble counting from the least significant.
+var magmaPi = [8][16]byte{
+	{12, 4, 6, 2, 10, 5, 11, 9, 14, 8, 13, 7, 0, 3, 15, 1},
+	{6, 8, 2, 3, 9, 10, 5, 12, 1, 14, 4, 7, 11, 13, 0, 15},
+	{11, 3, 5, 8, 2, 15, 10, 13, 14, 1, 7, 4, 12, 9, 6, 0},
+	{12, 8, 2, 1, 13, 4, 15, 6, 7, 0, 10, 5, 3, 14, 9, 11},
+	{7, 15, 5, 10, 8, 1, 6, 13, 0, 9, 3, 14, 11, 4, 2, 12},
+	{5, 13, 15, 6, 9, 2, 12, 10, 11, 7, 8, 1, 4, 3, 14, 0},
+	{8, 14, 2, 5, 6, 9, 1, 12, 15, 4, 11, 0, 13, 10, 3, 7},
+	{1, 7, 14, 13, 0, 5, 8, 3, 4, 15, 10, 6, 9, 12, 11, 2},
+}
+
+// magmaT[j][b] is the substitution of byte j of the word followed by the
+// rotation by 11, so g is four lookups.
+var magmaT [4][256]uint32
+
+func init() {
+	for j := range 4 {
+		for b := range 256 {
+			s := uint32(magmaPi[2*j+1][b>>4])<<4 | uint32(magmaPi[2*j][b&15])
+			magmaT[j][b] = bits.RotateLeft32(s<<(8*j), 11)
+		}
+	}
+}
+
+// Magma is the 64-bit block cipher of GOST R 34.12-2015, the former
+// GOST 28147-89 with its substitution fixed.
+type Magma struct {
+	k [8]uint32
+}
+
+func NewMagma(key []byte) (*Magma, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("gost3412: Magma key must be %d bytes, not %d", KeySize, len(key))
+	}
+	c := &Magma{}
+	for i := range c.k {
+		c.k[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	return c, nil
+}
+
+func (c *Magma) BlockSize() int {
+	return MagmaBlockSize
+}
+
+func magmaG(k, a uint32) uint32 {
+	x := a + k
+	return magmaT[0][x&0xff] ^ magmaT[1][x>>8&0xff] ^ magmaT[2][x>>16&0xff] ^ magmaT[3][x>>24]
+}
+
+// Encrypt uses K1..K8 three times forward, then K8..K1.
+func (c *Magma) Encrypt(dst, src []byte) {
+	a1, a0 := binary.BigEndian.Uint32(src), binary.BigEndian.Uint32(src[4:])
+	for i := range 24 {
+		a1, a0 = a0, magmaG(c.k[i%8], a0)^a1
+	}
+	for i := 7; i > 0; i-- {
+		a1, a0 = a0, magmaG(c.k[i], a0)^a1
+	}
+	binary.BigEndian.PutUint32(dst, magmaG(c.k[0], a0)^a1)
+	binary.BigEndian.PutUint32(dst[4:], a0)
+}
+
+func (c *Magma) Decrypt(dst, src []byte) {
+	a1, a0 := binary.BigEndian.Uint32(src), binary.BigEndian.Uint32(src[4:])
+	for i := range 8 {
+		a1, a0 = a0, magmaG(c.k[i], a0)^a1
+	}
+	for i := 23; i > 0; i-- {
+		a1, a0 = a0, magmaG(c.k[i%8], a0)^a1
+	}
+	binary.BigEndian.PutUint32(dst, magmaG(c.k[0], a0)^a1)
+	binary.BigEndian.PutUint32(dst[4:], a0)
+}
@@ -0,0 +1,347 @@
+package gost3413
+
+import (
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"information-defending/internal/container"
+	"information-defending/internal/gost3412"
+	"information-defending/internal/kdf"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+)
+
+// Files are containers of algorithm magma or kuznyechik with parameters
+//
+//	mode[1] | salt[16] | iv
+//
+// Every file is encrypted under its own key derived from the key and the
+// salt, so the short Magma IV of CTR (32 bits) only has to be unique
+// within one file. Padded modes use padding procedure 2 and container
+// blocks of the cipher block size. The container tag is the section 5.6
+// MAC of the same cipher under a key derived from the key
+// (encrypt-then-MAC). Password files also carry the key derivation
+// parameters.
+const (
+	chunkSize = 4096
+	saltSize  = 16
+)
+
+func NewCipher(alg container.Algorithm, key []byte) (cipher.Block, error) {
+	switch alg {
+	case container.AlgMagma:
+		return gost3412.NewMagma(key)
+	case container.AlgKuznyechik:
+		return gost3412.NewKuznyechik(key)
+	}
+	return nil, fmt.Errorf("gost3413: %s is not a block cipher", alg)
+}
+
+// ParseCipher maps magma and kuznyechik to their container algorithms.
+func ParseCipher(s string) (container.Algorithm, error) {
+	switch s {
+	case "magma":
+		return container.AlgMagma, nil
+	case "kuznyechik":
+		return container.AlgKuznyechik, nil
+	}
+	return 0, fmt.Errorf("gost3413: unknown cipher %q (magma, kuznyechik)", s)
+}
+
+// fingerprint is the key check value E_K(0).
+func fingerprint(b cipher.Block) [8]byte {
+	kcv := make([]byte, b.BlockSize())
+	b.Encrypt(kcv, kcv)
+	return container.Fingerprint(new(big.Int).SetBytes(kcv))
+}
+
+// fileCipher is the cipher under the per-file key.
+func fileCipher(alg container.Algorithm, key, salt []byte) (cipher.Block, error) {
+	fk, err := hkdf.Key(sha256.New, key, salt, "information-defending gost3413 key", gost3412.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipher(alg, fk)
+}
+
+// fileMAC is the full-block section 5.6 MAC of alg under a key derived
+// from key and the file parameters.
+func fileMAC(alg container.Algorithm, key, params []byte) (container.MAC, hash.Hash, error) {
+	m := container.MACKuznyechik
+	if alg == container.AlgMagma {
+		m = container.MACMagma
+	}
+	macKey, err := container.DeriveMACKey(key, params)
+	if err != nil {
+		return 0, nil, err
+	}
+	b, err := NewCipher(alg, macKey)
+	if err != nil {
+		return 0, nil, err
+	}
+	tag, err := NewMAC(b, b.BlockSize())
+	return m, tag, err
+}
+
+func EncryptFile(inputFile, outputFile string, alg container.Algorithm, m Mode, key []byte) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptStream(r, w, alg, m, key)
+	})
+}
+
+func DecryptFile(inputFile, outputFile string, key []byte) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptStream(r, w, key)
+	})
+}
+
+// EncryptPasswordFile derives the key from password, so the password
+// alone decrypts the file.
+func EncryptPasswordFile(inputFile, outputFile string, alg container.Algorithm, m Mode, password []byte, p kdf.Params) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptPasswordStream(r, w, alg, m, password, p)
+	})
+}
+
+func DecryptPasswordFile(inputFile, outputFile string, password []byte) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptPasswordStream(r, w, password)
+	})
+}
+
+func EncryptStream(r io.Reader, w io.Writer, alg container.Algorithm, m Mode, key []byte) error {
+	return encrypt(r, w, alg, m, key, nil)
+}
+
+func EncryptPasswordStream(r io.Reader, w io.Writer, alg container.Algorithm, m Mode, password []byte, p kdf.Params) error {
+	params, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	key, err := p.Key(password, gost3412.KeySize)
+	if err != nil {
+		return err
+	}
+	return encrypt(r, w, alg, m, key, params)
+}
+
+func encrypt(r io.Reader, w io.Writer, alg container.Algorithm, m Mode, key []byte, kdfParams []byte) error {
+	kb, err := NewCipher(alg, key)
+	if err != nil {
+		return err
+	}
+	if m < ModeECB || m > ModeCFB {
+		return fmt.Errorf("gost3413: unknown mode %d", byte(m))
+	}
+	n := kb.BlockSize()
+	params := make([]byte, 1+saltSize+m.IVSize(n))
+	params[0] = byte(m)
+	if _, err := rand.Read(params[1:]); err != nil {
+		return err
+	}
+	salt, iv := params[1:1+saltSize], params[1+saltSize:]
+	b, err := fileCipher(alg, key, salt)
+	if err != nil {
+		return err
+	}
+	mac, tag, err := fileMAC(alg, key, params)
+	if err != nil {
+		return err
+	}
+
+	h := container.Header{
+		Algorithm:   alg,
+		Fingerprint: fingerprint(kb),
+		BlockSize:   1,
+		Params:      params,
+		MAC:         mac,
+		KDF:         kdfParams,
+	}
+	if m.Padded() {
+		h.BlockSize = uint32(n)
+	}
+	cw, err := container.NewTagWriter(w, h, tag)
+	if err != nil {
+		return err
+	}
+
+	var total uint64
+	src := stream.Blocks(r, chunkSize)
+	if m.Padded() {
+		bm, err := NewBlockMode(b, m, iv, false)
+		if err != nil {
+			return err
+		}
+		for {
+			chunk, err := src()
+			if err != nil && err != io.EOF {
+				return err
+			}
+			total += uint64(len(chunk))
+			last := err == io.EOF || len(chunk) < chunkSize
+			if last {
+				chunk = Pad2(chunk, n)
+			}
+			bm.CryptBlocks(chunk, chunk)
+			if err := cw.WriteBlock(chunk); err != nil {
+				return err
+			}
+			if last {
+				return cw.Close(total)
+			}
+		}
+	}
+
+	s, err := NewStream(b, m, iv, false)
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := src()
+		if err == io.EOF {
+			return cw.Close(total)
+		}
+		if err != nil {
+			return err
+		}
+		total += uint64(len(chunk))
+		s.XORKeyStream(chunk, chunk)
+		if err := cw.WriteBlock(chunk); err != nil {
+			return err
+		}
+	}
+}
+
+// DecryptStream takes the cipher, mode and IV from the header. Nothing is
+// trusted before the MAC key is set, and the MAC is checked at the end.
+// The output in w is unverified until it returns nil, see
+// container.Reader.
+func DecryptStream(r io.Reader, w io.Writer, key []byte) error {
+	cr, err := container.NewReader(r)
+	if err != nil {
+		return err
+	}
+	return decrypt(cr, w, key)
+}
+
+// DecryptPasswordStream derives the key with the parameters in the header.
+// The output in w is unverified until it returns nil, see
+// container.Reader.
+func DecryptPasswordStream(r io.Reader, w io.Writer, password []byte) error {
+	cr, err := container.NewReader(r)
+	if err != nil {
+		return err
+	}
+	if len(cr.Header().KDF) == 0 {
+		return errors.New("gost3413: file is not password-protected")
+	}
+	var p kdf.Params
+	if err := p.UnmarshalBinary(cr.Header().KDF); err != nil {
+		return err
+	}
+	key, err := p.Key(password, gost3412.KeySize)
+	if err != nil {
+		return err
+	}
+	err = decrypt(cr, w, key)
+	if err == container.ErrWrongKey {
+		return kdf.ErrWrongPassword
+	}
+	return err
+}
+
+func decrypt(cr *container.Reader, w io.Writer, key []byte) error {
+	h := cr.Header()
+	kb, err := NewCipher(h.Algorithm, key)
+	if err != nil {
+		return err
+	}
+	if err := h.Check(h.Algorithm, fingerprint(kb)); err != nil {
+		return err
+	}
+	mac, tag, err := fileMAC(h.Algorithm, key, h.Params)
+	if err != nil {
+		return err
+	}
+	if h.MAC != mac {
+		return fmt.Errorf("gost3413: file is authenticated with %s, not %s", h.MAC, mac)
+	}
+	if err := cr.AuthenticateTag(tag); err != nil {
+		return err
+	}
+	n := kb.BlockSize()
+	if len(h.Params) < 1+saltSize {
+		return errors.New("gost3413: missing mode parameters")
+	}
+	m, salt, iv := Mode(h.Params[0]), h.Params[1:1+saltSize], h.Params[1+saltSize:]
+	if len(iv) != m.IVSize(n) || m.Padded() != (h.BlockSize == uint32(n)) {
+		return fmt.Errorf("gost3413: bad parameters for mode %s", m)
+	}
+	b, err := fileCipher(h.Algorithm, key, salt)
+	if err != nil {
+		return err
+	}
+
+	var written uint64
+	if m.Padded() {
+		bm, err := NewBlockMode(b, m, iv, true)
+		if err != nil {
+			return err
+		}
+		// the last block is held back to remove the padding
+		var pending []byte
+		for {
+			blk, err := cr.ReadBlock()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			bm.CryptBlocks(blk, blk)
+			if pending != nil {
+				if _, err := w.Write(pending); err != nil {
+					return err
+				}
+				written += uint64(len(pending))
+			}
+			pending = blk
+		}
+		last, err := Unpad2(pending, n)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(last); err != nil {
+			return err
+		}
+		written += uint64(len(last))
+	} else {
+		s, err := NewStream(b, m, iv, true)
+		if err != nil {
+			return err
+		}
+		for {
+			blk, err := cr.ReadBlock()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			s.XORKeyStream(blk, blk)
+			if _, err := w.Write(blk); err != nil {
+				return err
+			}
+			written += uint64(len(blk))
+		}
+	}
+	if written != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package gost3413
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"information-defending/internal/container"
+	"information-defending/internal/gost3412"
+	"information-defending/internal/kdf"
+	"testing"
+
+	gogost "github.com/ftomza/gogost/gost3413"
+)
+
+// Examples from GOST R 34.13-2015, appendix A: four blocks under the key
+// of GOST R 34.12-2015. The IV is shared by the register modes except
+// where ivs names another. The MAC examples are macVectors.
+var vectors = []struct {
+	cipher, key, plain string
+	iv                 string
+	ivs                map[Mode]string
+	modes              map[Mode]string
+}{
+	{
+		cipher: "kuznyechik",
+		key:    "8899aabbccddeeff0011223344556677fedcba98765432100123456789abcdef",
+		plain: "1122334455667700ffeeddccbbaa9988 00112233445566778899aabbcceeff0a " +
+			"112233445566778899aabbcceeff0a00 2233445566778899aabbcceeff0a0011",
+		iv:  "1234567890abcef0a1b2c3d4e5f0011223344556677889901213141516171819",
+		ivs: map[Mode]string{ModeCTR: "1234567890abcef0"},
+		modes: map[Mode]string{
+			ModeECB: "7f679d90bebc24305a468d42b9d4edcd b429912c6e0032f9285452d76718d08b " +
+				"f0ca33549d247ceef3f5a5313bd4b157 d0b09ccde830b9eb3a02c4c5aa8ada98",
+			ModeCTR: "f195d8bec10ed1dbd57b5fa240bda1b8 85eee733f6a13e5df33ce4b33c45dee4 " +
+				"a5eae88be6356ed3d5e877f13564a3a5 cb91fab1f20cbab6d1c6d15820bdba73",
+			ModeOFB: "81800a59b1842b24ff1f795e897abd95 ed5b47a7048cfab48fb521369d9326bf " +
+				"66a257ac3ca0b8b1c80fe7fc10288a13 203ebbc066138660a0292243f6903150",
+			ModeCBC: "689972d4a085fa4d90e52e3d6d7dcc27 2826e661b478eca6af1e8e448d5ea5ac " +
+				"fe7babf1e91999e85640e8b0f49d90d0 167688065a895c631a2d9a1560b63970",
+			ModeCFB: "81800a59b1842b24ff1f795e897abd95 ed5b47a7048cfab48fb521369d9326bf " +
+				"79f2a8eb5cc68d38842d264e97a238b5 4ffebecd4e922de6c75bd9dd44fbf4d1",
+		},
+	},
+	{
+		cipher: "magma",
+		key:    "ffeeddccbbaa99887766554433221100f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		plain:  "92def06b3c130a59 db54c704f8189d20 4a98fb2e67a8024c 8912409b17b57e41",
+		iv:     "1234567890abcdef234567890abcdef1",
+		ivs: map[Mode]string{
+			ModeCTR: "12345678",
+			ModeCBC: "1234567890abcdef234567890abcdef134567890abcdef12",
+		},
+		modes: map[Mode]string{
+			ModeECB: "2b073f0494f372a0 de70e715d3556e48 11d8d9e9eacfbc1e 7c68260996c67efb",
+			ModeCTR: "4e98110c97b7b93c 3e250d93d6e85d69 136d868807b2dbef 568eb680ab52a12d",
+			ModeOFB: "db37e0e266903c83 0d46644c1f9a089c a0f83062430e327e c824efb8bd4fdb05",
+			ModeCBC: "96d1b05eea683919 aff76129abb937b9 5058b4a1c4bc0019 20b78b1a7cd7e667",
+			ModeCFB: "db37e0e266903c83 0d46644c1f9a089c 24bdd2035315d38b bcc0321421075505",
+		},
+	},
+}
+
+func TestModes(t *testing.T) {
+	for _, v := range vectors {
+		b, err := gost3412.NewBlock(v.cipher)(unhex(v.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain := unhex(v.plain)
+		for m := ModeECB; m <= ModeCFB; m++ {
+			t.Run(v.cipher+"-"+m.String(), func(t *testing.T) {
+				iv := unhex(v.iv)
+				if s, ok := v.ivs[m]; ok {
+					iv = unhex(s)
+				}
+				want := unhex(v.modes[m])
+				got, err := Crypt(b, m, iv, plain, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("encrypts to %x, want %x", got, want)
+				}
+				if got, err = Crypt(b, m, iv, want, true); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, plain) {
+					t.Errorf("decrypts to %x, want %x", got, plain)
+				}
+			})
+		}
+	}
+}
+
+func TestMAC(t *testing.T) {
+	for _, v := range macVectors {
+		t.Run(v.cipher, func(t *testing.T) {
+			b, err := gost3412.NewBlock(v.cipher)(unhex(v.key))
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, want := unhex(v.plain), unhex(v.mac)
+			h, err := NewMAC(b, len(want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.Write(plain)
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("MAC is %x, want %x", got, want)
+			}
+			// the same in uneven pieces after a Reset
+			h.Reset()
+			for i := 0; i < len(plain); i += 5 {
+				h.Write(plain[i:min(i+5, len(plain))])
+			}
+			if got := h.Sum(nil); !bytes.Equal(got, want) {
+				t.Errorf("MAC in pieces is %x, want %x", got, want)
+			}
+			if _, err := NewMAC(b, b.BlockSize()+1); err == nil {
+				t.Error("MAC longer than a block")
+			}
+		})
+	}
+}
+
+func TestPadding(t *testing.T) {
+	for _, n := range []int{8, 16} {
+		for size := range 2*n + 1 {
+			data := make([]byte, size)
+			rand.Read(data)
+			padded := Pad2(data, n)
+			if want := gogost.Pad2(data, n); !bytes.Equal(padded, want) {
+				t.Fatalf("Pad2(%d bytes, %d) = %x, gogost %x", size, n, padded, want)
+			}
+			got, err := Unpad2(padded, n)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("Unpad2(Pad2(%x)) = %x, %v", data, got, err)
+			}
+		}
+		if _, err := Unpad2(make([]byte, n), n); err == nil {
+			t.Errorf("Unpad2 accepts a block of zeros")
+		}
+	}
+}
+
+// testFile encrypts data into a file under key.
+func testFile(t *testing.T, alg container.Algorithm, m Mode, key, data []byte) []byte {
+	t.Helper()
+	var enc bytes.Buffer
+	if err := EncryptStream(bytes.NewReader(data), &enc, alg, m, key); err != nil {
+		t.Fatal(err)
+	}
+	return enc.Bytes()
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	key := make([]byte, gost3412.KeySize)
+	rand.Read(key)
+	for _, alg := range []container.Algorithm{container.AlgMagma, container.AlgKuznyechik} {
+		for m := ModeECB; m <= ModeCFB; m++ {
+			for _, size := range []int{0, 1, 15, 16, chunkSize, chunkSize + 1, 3*chunkSize - 7} {
+				t.Run(fmt.Sprintf("%s-%s-%d", alg, m, size), func(t *testing.T) {
+					data := make([]byte, size)
+					rand.Read(data)
+					enc := testFile(t, alg, m, key, data)
+					cr, err := container.NewReader(bytes.NewReader(enc))
+					if err != nil {
+						t.Fatal(err)
+					}
+					want := container.MACKuznyechik
+					if alg == container.AlgMagma {
+						want = container.MACMagma
+					}
+					if got := cr.Header().MAC; got != want {
+						t.Errorf("MAC is %s, want %s", got, want)
+					}
+					var dec bytes.Buffer
+					if err := DecryptStream(bytes.NewReader(enc), &dec, key); err != nil {
+						t.Fatal(err)
+					}
+					if !bytes.Equal(dec.Bytes(), data) {
+						t.Error("decrypted data differs")
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestFileTampered(t *testing.T) {
+	key := make([]byte, gost3412.KeySize)
+	rand.Read(key)
+	data := make([]byte, 1000)
+	for _, alg := range []container.Algorithm{container.AlgMagma, container.AlgKuznyechik} {
+		for _, m := range []Mode{ModeCBC, ModeCTR} {
+			enc := testFile(t, alg, m, key, data)
+			mac := container.MACKuznyechik
+			if alg == container.AlgMagma {
+				mac = container.MACMagma
+			}
+			// the last ciphertext byte, right before the trailer
+			enc[len(enc)-8-mac.Size()-1] ^= 1
+			err := DecryptStream(bytes.NewReader(enc), new(bytes.Buffer), key)
+			if !errors.Is(err, container.ErrAuth) {
+				t.Errorf("%s-%s: tampered file gives %v, want ErrAuth", alg, m, err)
+			}
+		}
+	}
+}
+
+func TestFileWrongKey(t *testing.T) {
+	key := make([]byte, gost3412.KeySize)
+	rand.Read(key)
+	enc := testFile(t, container.AlgKuznyechik, ModeCTR, key, []byte("secret"))
+	key[0] ^= 1
+	err := DecryptStream(bytes.NewReader(enc), new(bytes.Buffer), key)
+	if !errors.Is(err, container.ErrWrongKey) {
+		t.Errorf("wrong key gives %v, want ErrWrongKey", err)
+	}
+}
+
+// TestFileKey checks that every file gets its own cipher key: the same
+// plaintext under the same key and a zero IV would repeat otherwise.
+func TestFileKey(t *testing.T) {
+	key := make([]byte, gost3412.KeySize)
+	rand.Read(key)
+	data := bytes.Repeat([]byte("sixteen byte blk"), 4)
+	a := testFile(t, container.AlgMagma, ModeECB, key, data)
+	b := testFile(t, container.AlgMagma, ModeECB, key, data)
+	if bytes.Equal(a, b) {
+		t.Fatal("two encryptions of a file are equal")
+	}
+	kb, _ := NewCipher(container.AlgMagma, key)
+	raw, _ := Crypt(kb, ModeECB, nil, Pad2(data, kb.BlockSize()), false)
+	if bytes.Contains(a, raw[:16]) {
+		t.Error("the file is encrypted under the raw key")
+	}
+}
+
+func TestPasswordFile(t *testing.T) {
+	p, err := kdf.New(kdf.PBKDF2, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("the password alone decrypts the file")
+	var enc bytes.Buffer
+	if err := EncryptPasswordStream(bytes.NewReader(data), &enc, container.AlgKuznyechik, ModeCBC, []byte("correct horse"), p); err != nil {
+		t.Fatal(err)
+	}
+	var dec bytes.Buffer
+	if err := DecryptPasswordStream(bytes.NewReader(enc.Bytes()), &dec, []byte("correct horse")); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.Bytes(), data) {
+		t.Error("decrypted data differs")
+	}
+	err = DecryptPasswordStream(bytes.NewReader(enc.Bytes()), new(bytes.Buffer), []byte("battery staple"))
+	if !errors.Is(err, kdf.ErrWrongPassword) {
+		t.Errorf("wrong password gives %v, want ErrWrongPassword", err)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,96 @@
+package gost3413
+
+import (
+	"crypto/cipher"
+	"fmt"
+	"hash"
+)
+
+// mac is the MAC mode of section 5.6: CBC with a zero IV whose last block
+// is masked with K1, or padded and masked with K2.
+type mac struct {
+	b      cipher.Block
+	size   int
+	k1, k2 []byte
+	c      []byte
+	buf    []byte // the last block stays here until Sum
+}
+
+// NewMAC returns the MAC of b cut to size bytes, at most one block.
+func NewMAC(b cipher.Block, size int) (hash.Hash, error) {
+	n := b.BlockSize()
+	if size < 1 || size > n {
+		return nil, fmt.Errorf("gost3413: MAC size must be 1..%d bytes", n)
+	}
+	var rb byte
+	switch n {
+	case 8:
+		rb = 0x1b
+	case 16:
+		rb = 0x87
+	default:
+		return nil, fmt.Errorf("gost3413: no MAC for %d-byte blocks", n)
+	}
+	m := &mac{b: b, size: size, c: make([]byte, n)}
+	r := make([]byte, n)
+	b.Encrypt(r, r)
+	m.k1 = deriveMACKey(r, rb)
+	m.k2 = deriveMACKey(m.k1, rb)
+	return m, nil
+}
+
+// deriveMACKey shifts k left by one bit, adding B_n if a one falls out.
+func deriveMACKey(k []byte, rb byte) []byte {
+	out := make([]byte, len(k))
+	for i := range k {
+		out[i] = k[i] << 1
+		if i+1 < len(k) {
+			out[i] |= k[i+1] >> 7
+		}
+	}
+	if k[0]&0x80 != 0 {
+		out[len(out)-1] ^= rb
+	}
+	return out
+}
+
+func (m *mac) Write(p []byte) (int, error) {
+	n := len(m.c)
+	m.buf = append(m.buf, p...)
+	for len(m.buf) > n {
+		xorBytes(m.c, m.c, m.buf[:n])
+		m.b.Encrypt(m.c, m.c)
+		m.buf = m.buf[n:]
+	}
+	// keep the pending block from pinning the whole input
+	m.buf = append([]byte(nil), m.buf...)
+	return len(p), nil
+}
+
+func (m *mac) Sum(in []byte) []byte {
+	n := len(m.c)
+	last := make([]byte, n)
+	copy(last, m.buf)
+	k := m.k1
+	if len(m.buf) < n {
+		last[len(m.buf)] = 0x80
+		k = m.k2
+	}
+	xorBytes(last, last, k)
+	xorBytes(last, last, m.c)
+	m.b.Encrypt(last, last)
+	return append(in, last[:m.size]...)
+}
+
+func (m *mac) Reset() {
+	clear(m.c)
+	m.buf = nil
+}
+
+func (m *mac) Size() int {
+	return m.size
+}
+
+func (m *mac) BlockSize() int {
+	return len(m.c)
+}
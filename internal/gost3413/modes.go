@@ -0,0 +1,324 @@
+package gost3413
+
+import (
+	"crypto/cipher"
+	"fmt"
+)
+
+// Mode is one of the GOST R 34.13-2015 modes of operation. All of them
+// use the full block width n for s, the part of a block processed at once.
+type Mode byte
+
+const (
+	ModeECB Mode = iota + 1
+	ModeCBC
+	ModeCTR
+	ModeOFB
+	ModeCFB
+)
+
+func ParseMode(s string) (Mode, error) {
+	for m := ModeECB; m <= ModeCFB; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("gost3413: unknown mode %q (ecb, cbc, ctr, ofb, cfb)", s)
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeECB:
+		return "ecb"
+	case ModeCBC:
+		return "cbc"
+	case ModeCTR:
+		return "ctr"
+	case ModeOFB:
+		return "ofb"
+	case ModeCFB:
+		return "cfb"
+	}
+	return fmt.Sprintf("mode(%d)", byte(m))
+}
+
+// Padded reports whether the mode needs whole blocks; the others cut the
+// last gamma block to the length of the text.
+func (m Mode) Padded() bool {
+	return m == ModeECB || m == ModeCBC
+}
+
+// IVSize is the initial vector length for block size n: half a block for
+// CTR, and a register of two blocks (z = 2, as in the standard's examples)
+// for CBC, OFB and CFB.
+func (m Mode) IVSize(n int) int {
+	switch m {
+	case ModeECB:
+		return 0
+	case ModeCTR:
+		return n / 2
+	}
+	return 2 * n
+}
+
+// checkIV allows any register of whole blocks, z ≥ 1.
+func checkIV(b cipher.Block, iv []byte) error {
+	if n := b.BlockSize(); len(iv) == 0 || len(iv)%n != 0 {
+		return fmt.Errorf("gost3413: IV must be a multiple of %d bytes, not %d", n, len(iv))
+	}
+	return nil
+}
+
+// register is the shift register R of m = z·n bits: the cipher always
+// takes its first block, and each step shifts a new block in at the end.
+type register []byte
+
+func (r register) shift(in []byte) {
+	n := copy(r, r[len(in):])
+	copy(r[n:], in)
+}
+
+type ecb struct {
+	b       cipher.Block
+	decrypt bool
+}
+
+func NewECBEncrypter(b cipher.Block) cipher.BlockMode {
+	return &ecb{b: b}
+}
+
+func NewECBDecrypter(b cipher.Block) cipher.BlockMode {
+	return &ecb{b: b, decrypt: true}
+}
+
+func (e *ecb) BlockSize() int {
+	return e.b.BlockSize()
+}
+
+func (e *ecb) CryptBlocks(dst, src []byte) {
+	n := e.b.BlockSize()
+	if len(src)%n != 0 {
+		panic("gost3413: input not full blocks")
+	}
+	for i := 0; i < len(src); i += n {
+		if e.decrypt {
+			e.b.Decrypt(dst[i:i+n], src[i:i+n])
+		} else {
+			e.b.Encrypt(dst[i:i+n], src[i:i+n])
+		}
+	}
+}
+
+// cbc is C = E(P ⊕ MSB_n(R)), with C shifted into R.
+type cbc struct {
+	b       cipher.Block
+	r       register
+	decrypt bool
+}
+
+func NewCBCEncrypter(b cipher.Block, iv []byte) (cipher.BlockMode, error) {
+	if err := checkIV(b, iv); err != nil {
+		return nil, err
+	}
+	return &cbc{b: b, r: append(register(nil), iv...)}, nil
+}
+
+func NewCBCDecrypter(b cipher.Block, iv []byte) (cipher.BlockMode, error) {
+	if err := checkIV(b, iv); err != nil {
+		return nil, err
+	}
+	return &cbc{b: b, r: append(register(nil), iv...), decrypt: true}, nil
+}
+
+func (c *cbc) BlockSize() int {
+	return c.b.BlockSize()
+}
+
+func (c *cbc) CryptBlocks(dst, src []byte) {
+	n := c.b.BlockSize()
+	if len(src)%n != 0 {
+		panic("gost3413: input not full blocks")
+	}
+	t := make([]byte, n)
+	for i := 0; i < len(src); i += n {
+		if c.decrypt {
+			copy(t, src[i:i+n])
+			c.b.Decrypt(dst[i:i+n], src[i:i+n])
+			xorBytes(dst[i:i+n], dst[i:i+n], c.r[:n])
+			c.r.shift(t)
+		} else {
+			xorBytes(t, src[i:i+n], c.r[:n])
+			c.b.Encrypt(dst[i:i+n], t)
+			c.r.shift(dst[i : i+n])
+		}
+	}
+}
+
+// gamma XORs blocks from next, using the last one only in part.
+type gamma struct {
+	next func(out []byte)
+	buf  []byte
+	used int
+}
+
+func (g *gamma) XORKeyStream(dst, src []byte) {
+	for len(src) > 0 {
+		if g.used == len(g.buf) {
+			g.next(g.buf)
+			g.used = 0
+		}
+		k := xorBytes(dst, src, g.buf[g.used:])
+		g.used += k
+		dst, src = dst[k:], src[k:]
+	}
+}
+
+// NewCTR takes an IV of half a block; the counter starts at IV||0...0 and
+// is incremented modulo 2^n.
+func NewCTR(b cipher.Block, iv []byte) (cipher.Stream, error) {
+	n := b.BlockSize()
+	if len(iv) != n/2 {
+		return nil, fmt.Errorf("gost3413: CTR IV must be %d bytes, not %d", n/2, len(iv))
+	}
+	ctr := make([]byte, n)
+	copy(ctr, iv)
+	buf := make([]byte, n)
+	return &gamma{buf: buf, used: n, next: func(out []byte) {
+		b.Encrypt(out, ctr)
+		for i := n - 1; i >= 0; i-- {
+			if ctr[i]++; ctr[i] != 0 {
+				break
+			}
+		}
+	}}, nil
+}
+
+// NewOFB encrypts the first block of R and shifts the result in.
+func NewOFB(b cipher.Block, iv []byte) (cipher.Stream, error) {
+	if err := checkIV(b, iv); err != nil {
+		return nil, err
+	}
+	n := b.BlockSize()
+	r := append(register(nil), iv...)
+	return &gamma{buf: make([]byte, n), used: n, next: func(out []byte) {
+		b.Encrypt(out, r[:n])
+		r.shift(out)
+	}}, nil
+}
+
+// cfb encrypts the first block of R for the gamma and shifts in the
+// ciphertext, so it collects a whole block of it first.
+type cfb struct {
+	b       cipher.Block
+	r       register
+	out     []byte
+	c       []byte
+	used    int
+	decrypt bool
+}
+
+func NewCFBEncrypter(b cipher.Block, iv []byte) (cipher.Stream, error) {
+	return newCFB(b, iv, false)
+}
+
+func NewCFBDecrypter(b cipher.Block, iv []byte) (cipher.Stream, error) {
+	return newCFB(b, iv, true)
+}
+
+func newCFB(b cipher.Block, iv []byte, decrypt bool) (cipher.Stream, error) {
+	if err := checkIV(b, iv); err != nil {
+		return nil, err
+	}
+	n := b.BlockSize()
+	return &cfb{
+		b: b, r: append(register(nil), iv...),
+		out: make([]byte, n), c: make([]byte, n), used: n,
+		decrypt: decrypt,
+	}, nil
+}
+
+func (f *cfb) XORKeyStream(dst, src []byte) {
+	n := len(f.out)
+	for len(src) > 0 {
+		if f.used == n {
+			f.b.Encrypt(f.out, f.r[:n])
+			f.used = 0
+		}
+		k := min(n-f.used, len(src))
+		if f.decrypt {
+			copy(f.c[f.used:], src[:k])
+		}
+		xorBytes(dst, src[:k], f.out[f.used:])
+		if !f.decrypt {
+			copy(f.c[f.used:], dst[:k])
+		}
+		f.used += k
+		if f.used == n {
+			f.r.shift(f.c)
+		}
+		dst, src = dst[k:], src[k:]
+	}
+}
+
+// Crypt runs one mode over a whole message without padding, so padded
+// modes need whole blocks.
+func Crypt(b cipher.Block, m Mode, iv, src []byte, decrypt bool) ([]byte, error) {
+	dst := make([]byte, len(src))
+	if m.Padded() {
+		if len(src)%b.BlockSize() != 0 {
+			return nil, fmt.Errorf("gost3413: %s needs whole blocks", m)
+		}
+		bm, err := NewBlockMode(b, m, iv, decrypt)
+		if err != nil {
+			return nil, err
+		}
+		bm.CryptBlocks(dst, src)
+		return dst, nil
+	}
+	s, err := NewStream(b, m, iv, decrypt)
+	if err != nil {
+		return nil, err
+	}
+	s.XORKeyStream(dst, src)
+	return dst, nil
+}
+
+// NewBlockMode returns ECB or CBC.
+func NewBlockMode(b cipher.Block, m Mode, iv []byte, decrypt bool) (cipher.BlockMode, error) {
+	switch {
+	case m == ModeECB && decrypt:
+		return NewECBDecrypter(b), nil
+	case m == ModeECB:
+		return NewECBEncrypter(b), nil
+	case m == ModeCBC && decrypt:
+		return NewCBCDecrypter(b, iv)
+	case m == ModeCBC:
+		return NewCBCEncrypter(b, iv)
+	}
+	return nil, fmt.Errorf("gost3413: %s is not a block mode", m)
+}
+
+// NewStream returns CTR, OFB or CFB.
+func NewStream(b cipher.Block, m Mode, iv []byte, decrypt bool) (cipher.Stream, error) {
+	switch m {
+	case ModeCTR:
+		return NewCTR(b, iv)
+	case ModeOFB:
+		return NewOFB(b, iv)
+	case ModeCFB:
+		if decrypt {
+			return NewCFBDecrypter(b, iv)
+		}
+		return NewCFBEncrypter(b, iv)
+	}
+	return nil, fmt.Errorf("gost3413: %s is not a stream mode", m)
+}
+
+// xorBytes sets dst = a ⊕ b over the shorter of a and b and returns the length.
+func xorBytes(dst, a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		dst[i] = a[i] ^ b[i]
+	}
+	return n
+}
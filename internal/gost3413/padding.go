@@ -0,0 +1,50 @@
+package gost3413
+
+import "errors"
+
+var ErrPadding = errors.New("gost3413: invalid padding")
+
+// Pad1 is padding procedure 1: zeros up to a whole block, none if the data
+// already fills one. It cannot be undone without knowing the length.
+func Pad1(data []byte, n int) []byte {
+	if len(data)%n == 0 {
+		return data
+	}
+	return append(data, make([]byte, n-len(data)%n)...)
+}
+
+// Pad2 is padding procedure 2: a one bit and zeros, always at least the
+// one bit, so it can be removed unambiguously.
+func Pad2(data []byte, n int) []byte {
+	data = append(data, 0x80)
+	if len(data)%n != 0 {
+		data = append(data, make([]byte, n-len(data)%n)...)
+	}
+	return data
+}
+
+// Pad3 is padding procedure 3: nothing for whole blocks, otherwise Pad2.
+// The MAC pads this way.
+func Pad3(data []byte, n int) []byte {
+	if len(data)%n == 0 {
+		return data
+	}
+	return Pad2(data, n)
+}
+
+// Unpad2 removes Pad2 padding.
+func Unpad2(data []byte, n int) ([]byte, error) {
+	if len(data) == 0 || len(data)%n != 0 {
+		return nil, ErrPadding
+	}
+	for i := len(data) - 1; i >= len(data)-n; i-- {
+		switch data[i] {
+		case 0:
+			continue
+		case 0x80:
+			return data[:i], nil
+		}
+		return nil, ErrPadding
+	}
+	return nil, ErrPadding
+}
@@ -0,0 +1,168 @@
+package homomorphic
+
+import (
+	"bufio"
+	"errors"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rsa"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+	"sync/atomic"
+)
+
+// GMPublicKey encrypts bit b as Y^b * r^2 mod N for a fresh r: a square
+// for 0 and a non-square for 1. Y = N-1.
+type GMPublicKey struct {
+	N *big.Int
+	Y *big.Int
+}
+
+type GMPrivateKey struct {
+	GMPublicKey
+	P *big.Int
+}
+
+func NewGMPublicKey(k rsa.Keys) *GMPublicKey {
+	return &GMPublicKey{N: k.N, Y: new(big.Int).Sub(k.N, big.NewInt(1))}
+}
+
+func NewGMPrivateKey(k rsa.Keys) (*GMPrivateKey, error) {
+	p, q, err := primesOf(k)
+	if err != nil {
+		return nil, err
+	}
+	if !isBlum([]*big.Int{p, q}) {
+		return nil, errors.New("homomorphic: Goldwasser-Micali needs primes = 3 mod 4")
+	}
+	return &GMPrivateKey{GMPublicKey: *NewGMPublicKey(k), P: p}, nil
+}
+
+func (pub *GMPublicKey) EncryptBit(b uint) (*big.Int, error) {
+	r, err := randomUnit(pub.N)
+	if err != nil {
+		return nil, err
+	}
+	c := r.Mul(r, r)
+	if b&1 == 1 {
+		c.Mul(c, pub.Y)
+	}
+	return c.Mod(c, pub.N), nil
+}
+
+// DecryptBit tells squares from non-squares by the Legendre symbol modulo
+// P, which needs the factorization.
+func (priv *GMPrivateKey) DecryptBit(c *big.Int) uint {
+	if crypto.Legendre(c, priv.P) == 1 {
+		return 0
+	}
+	return 1
+}
+
+// Xor returns an encryption of b1 ^ b2.
+func (pub *GMPublicKey) Xor(c1, c2 *big.Int) *big.Int {
+	c := new(big.Int).Mul(c1, c2)
+	return c.Mod(c, pub.N)
+}
+
+// EncryptByte returns eight ciphertexts, most significant bit first.
+func (pub *GMPublicKey) EncryptByte(m byte) ([]*big.Int, error) {
+	cs := make([]*big.Int, 8)
+	for i := range cs {
+		c, err := pub.EncryptBit(uint(m >> (7 - i)))
+		if err != nil {
+			return nil, err
+		}
+		cs[i] = c
+	}
+	return cs, nil
+}
+
+func (priv *GMPrivateKey) DecryptByte(cs []*big.Int) byte {
+	var m byte
+	for _, c := range cs {
+		m = m<<1 | byte(priv.DecryptBit(c))
+	}
+	return m
+}
+
+func (pub *GMPublicKey) EncryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, pub.EncryptStream)
+}
+
+func (priv *GMPrivateKey) DecryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, priv.DecryptStream)
+}
+
+// EncryptStream writes one block of eight ciphertexts per plaintext byte.
+func (pub *GMPublicKey) EncryptStream(r io.Reader, w io.Writer) error {
+	size := crypto.ByteLen(pub.N)
+	cw, err := container.NewWriter(w, container.Header{
+		Algorithm:   container.AlgGM,
+		Fingerprint: container.Fingerprint(pub.N),
+		BlockSize:   uint32(8 * size),
+	})
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	err = stream.Map(stream.Blocks(r, 1), func(b []byte) ([]byte, error) {
+		cs, err := pub.EncryptByte(b[0])
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, 8*size)
+		for i, c := range cs {
+			c.FillBytes(out[i*size : (i+1)*size])
+		}
+		return out, nil
+	}, func(b []byte) error {
+		n++
+		return cw.WriteBlock(b)
+	}, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
+
+// DecryptStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func (priv *GMPrivateKey) DecryptStream(r io.Reader, w io.Writer) error {
+	cr, err := container.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return err
+	}
+	if err := cr.Header().Check(container.AlgGM, container.Fingerprint(priv.N)); err != nil {
+		return err
+	}
+
+	size := crypto.ByteLen(priv.N)
+	var wrongKey atomic.Bool
+	var n uint64
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		cs := make([]*big.Int, 8)
+		for i := range cs {
+			cs[i] = new(big.Int).SetBytes(b[i*size : (i+1)*size])
+			// every ciphertext has Jacobi symbol 1 modulo N
+			if cs[i].Cmp(priv.N) >= 0 || crypto.Jacobi(cs[i], priv.N) != 1 {
+				wrongKey.Store(true)
+				return nil, nil
+			}
+		}
+		return []byte{priv.DecryptByte(cs)}, nil
+	}, stream.Writer(w, &n), 0)
+	if err != nil {
+		return err
+	}
+
+	if wrongKey.Load() {
+		return container.ErrWrongKey
+	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
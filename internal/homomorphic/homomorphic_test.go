@@ -0,0 +1,142 @@
+package homomorphic
+
+import (
+	"bytes"
+	"information-defending/internal/container"
+	"math/big"
+	"testing"
+)
+
+func testKeys(t *testing.T) (*GMPrivateKey, *PaillierPrivateKey) {
+	t.Helper()
+	k, err := GenerateKeys(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gm, err := NewGMPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl, err := NewPaillierPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gm, pl
+}
+
+func TestGM(t *testing.T) {
+	gm, _ := testKeys(t)
+	for _, b := range []uint{0, 1} {
+		for _, b2 := range []uint{0, 1} {
+			c1, err := gm.EncryptBit(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c2, err := gm.EncryptBit(b2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := gm.DecryptBit(c1); got != b {
+				t.Fatalf("bit %d decrypted to %d", b, got)
+			}
+			if got := gm.DecryptBit(gm.Xor(c1, c2)); got != b^b2 {
+				t.Fatalf("%d xor %d = %d", b, b2, got)
+			}
+		}
+	}
+	for _, m := range []byte{0, 1, 0x5a, 0xff} {
+		cs, err := gm.EncryptByte(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := gm.DecryptByte(cs); got != m {
+			t.Fatalf("byte %#x decrypted to %#x", m, got)
+		}
+	}
+}
+
+func TestPaillier(t *testing.T) {
+	_, pl := testKeys(t)
+	enc := func(m int64) *big.Int {
+		c, err := pl.Encrypt(big.NewInt(m))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	dec := func(c *big.Int) int64 {
+		m, err := pl.Decrypt(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m.Int64()
+	}
+
+	c1, c2 := enc(1234), enc(5678)
+	if c1.Cmp(enc(1234)) == 0 {
+		t.Fatal("encryption is deterministic")
+	}
+	if got := dec(pl.Add(c1, c2)); got != 1234+5678 {
+		t.Fatalf("Add = %d", got)
+	}
+	if got := dec(pl.AddPlain(c1, big.NewInt(66))); got != 1300 {
+		t.Fatalf("AddPlain = %d", got)
+	}
+	if got := dec(pl.Mul(c1, big.NewInt(3))); got != 3702 {
+		t.Fatalf("Mul = %d", got)
+	}
+	// m2 - m1 through a negative factor
+	if got := dec(pl.Add(c2, pl.Mul(c1, big.NewInt(-1)))); got != 5678-1234 {
+		t.Fatalf("difference = %d", got)
+	}
+	r, err := pl.Rerandomize(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Cmp(c1) == 0 || dec(r) != 1234 {
+		t.Fatal("Rerandomize changed the plaintext or kept the ciphertext")
+	}
+
+	if _, err := pl.Encrypt(pl.N); err == nil {
+		t.Fatal("m = N accepted")
+	}
+	if _, err := pl.Decrypt(pl.N2); err != ErrCiphertext {
+		t.Fatalf("c = N^2: %v", err)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	gm, pl := testKeys(t)
+	_, other := testKeys(t)
+	type codec struct {
+		name    string
+		encrypt func(*bytes.Reader, *bytes.Buffer) error
+		decrypt func(*bytes.Reader, *bytes.Buffer) error
+	}
+	codecs := []codec{
+		{"gm", func(r *bytes.Reader, w *bytes.Buffer) error { return gm.EncryptStream(r, w) },
+			func(r *bytes.Reader, w *bytes.Buffer) error { return gm.DecryptStream(r, w) }},
+		{"paillier", func(r *bytes.Reader, w *bytes.Buffer) error { return pl.EncryptStream(r, w) },
+			func(r *bytes.Reader, w *bytes.Buffer) error { return pl.DecryptStream(r, w) }},
+	}
+	for _, c := range codecs {
+		for _, n := range []int{0, 1, pl.BlockSize(), 3*pl.BlockSize() + 5} {
+			msg := bytes.Repeat([]byte("homomorphic\x00"), n)[:n]
+			var ct, out bytes.Buffer
+			if err := c.encrypt(bytes.NewReader(msg), &ct); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.decrypt(bytes.NewReader(ct.Bytes()), &out); err != nil || !bytes.Equal(out.Bytes(), msg) {
+				t.Fatalf("%s, %d bytes: %v", c.name, n, err)
+			}
+		}
+	}
+
+	var ct, out bytes.Buffer
+	if err := pl.EncryptStream(bytes.NewReader([]byte("secret")), &ct); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.DecryptStream(bytes.NewReader(ct.Bytes()), &out); err != container.ErrWrongKey {
+		t.Fatalf("wrong key: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package homomorphic implements two probabilistic public-key schemes on
+// RSA moduli: Goldwasser-Micali, which encrypts bits and is homomorphic
+// for XOR, and Paillier, which is homomorphic for addition.
+//
+// Keys are ordinary rsa.Keys, so the RSA PEM encoding stores them.
+package homomorphic
+
+import (
+	"crypto/rand"
+	"errors"
+	"information-defending/internal/rsa"
+	"math/big"
+)
+
+// GenerateKeys returns RSA keys with Blum primes (3 mod 4), which both
+// schemes accept: for such N, N-1 is a non-square with Jacobi symbol 1.
+func GenerateKeys(bits int) (rsa.Keys, error) {
+	for {
+		k, err := rsa.GenerateKeysWithExponent(bits, 65537)
+		if err != nil {
+			return rsa.Keys{}, err
+		}
+		if isBlum(k.Primes) {
+			return k, nil
+		}
+	}
+}
+
+func isBlum(primes []*big.Int) bool {
+	if len(primes) != 2 {
+		return false
+	}
+	for _, p := range primes {
+		if p.Bit(1) != 1 || p.Bit(0) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func primesOf(k rsa.Keys) (*big.Int, *big.Int, error) {
+	primes := k.Primes
+	if len(primes) == 0 && k.C != nil && k.D != nil {
+		var err error
+		primes, err = rsa.RecoverPrimes(k.C, k.D, k.N)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(primes) != 2 {
+		return nil, nil, errors.New("homomorphic: need a two-prime key")
+	}
+	return primes[0], primes[1], nil
+}
+
+func randomUnit(N *big.Int) (*big.Int, error) {
+	one := big.NewInt(1)
+	for {
+		r, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, N).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
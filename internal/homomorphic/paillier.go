@@ -0,0 +1,179 @@
+package homomorphic
+
+import (
+	"bufio"
+	"errors"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rsa"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+	"sync/atomic"
+)
+
+// PaillierPublicKey encrypts m < N as (1 + m*N) * r^N mod N^2, which is
+// g^m * r^N for g = N+1.
+type PaillierPublicKey struct {
+	N  *big.Int
+	N2 *big.Int
+}
+
+type PaillierPrivateKey struct {
+	PaillierPublicKey
+	Lambda *big.Int // lcm(p-1, q-1)
+	Mu     *big.Int // Lambda^-1 mod N
+}
+
+var ErrCiphertext = errors.New("homomorphic: ciphertext out of range")
+
+func NewPaillierPublicKey(k rsa.Keys) *PaillierPublicKey {
+	return &PaillierPublicKey{N: k.N, N2: new(big.Int).Mul(k.N, k.N)}
+}
+
+func NewPaillierPrivateKey(k rsa.Keys) (*PaillierPrivateKey, error) {
+	p, q, err := primesOf(k)
+	if err != nil {
+		return nil, err
+	}
+	one := big.NewInt(1)
+	p1 := new(big.Int).Sub(p, one)
+	q1 := new(big.Int).Sub(q, one)
+	gcd := new(big.Int).GCD(nil, nil, p1, q1)
+	lambda := new(big.Int).Mul(p1, q1)
+	lambda.Div(lambda, gcd)
+
+	mu := new(big.Int).ModInverse(lambda, k.N)
+	if mu == nil {
+		return nil, errors.New("homomorphic: gcd(N, phi(N)) != 1")
+	}
+	return &PaillierPrivateKey{PaillierPublicKey: *NewPaillierPublicKey(k), Lambda: lambda, Mu: mu}, nil
+}
+
+func (pub *PaillierPublicKey) Encrypt(m *big.Int) (*big.Int, error) {
+	if m.Sign() < 0 || m.Cmp(pub.N) >= 0 {
+		return nil, errors.New("homomorphic: message must be in [0, N)")
+	}
+	c := new(big.Int).Mul(m, pub.N)
+	c.Add(c, big.NewInt(1))
+	return pub.Rerandomize(c)
+}
+
+// Decrypt computes L(c^Lambda mod N^2) * Mu mod N, L(x) = (x-1)/N.
+func (priv *PaillierPrivateKey) Decrypt(c *big.Int) (*big.Int, error) {
+	if !priv.valid(c) {
+		return nil, ErrCiphertext
+	}
+	x := new(big.Int).Exp(c, priv.Lambda, priv.N2)
+	x.Sub(x, big.NewInt(1))
+	x.Div(x, priv.N)
+	x.Mul(x, priv.Mu)
+	return x.Mod(x, priv.N), nil
+}
+
+// Add returns an encryption of m1 + m2 mod N.
+func (pub *PaillierPublicKey) Add(c1, c2 *big.Int) *big.Int {
+	c := new(big.Int).Mul(c1, c2)
+	return c.Mod(c, pub.N2)
+}
+
+// AddPlain returns an encryption of m + k mod N.
+func (pub *PaillierPublicKey) AddPlain(c, k *big.Int) *big.Int {
+	g := new(big.Int).Mod(k, pub.N)
+	g.Mul(g, pub.N)
+	g.Add(g, big.NewInt(1))
+	return pub.Add(c, g)
+}
+
+// Mul returns an encryption of k*m mod N. A negative k works as N - |k|.
+func (pub *PaillierPublicKey) Mul(c, k *big.Int) *big.Int {
+	return new(big.Int).Exp(c, new(big.Int).Mod(k, pub.N), pub.N2)
+}
+
+// Rerandomize multiplies by a fresh r^N: the plaintext stays the same and
+// the result is unlinkable to c.
+func (pub *PaillierPublicKey) Rerandomize(c *big.Int) (*big.Int, error) {
+	r, err := randomUnit(pub.N)
+	if err != nil {
+		return nil, err
+	}
+	r.Exp(r, pub.N, pub.N2)
+	r.Mul(r, c)
+	return r.Mod(r, pub.N2), nil
+}
+
+func (pub *PaillierPublicKey) valid(c *big.Int) bool {
+	return c.Sign() > 0 && c.Cmp(pub.N2) < 0
+}
+
+// BlockSize is the number of plaintext bytes per Paillier block.
+func (pub *PaillierPublicKey) BlockSize() int {
+	return crypto.ByteLen(pub.N) - 1
+}
+
+func (pub *PaillierPublicKey) EncryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, pub.EncryptStream)
+}
+
+func (priv *PaillierPrivateKey) DecryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, priv.DecryptStream)
+}
+
+func (pub *PaillierPublicKey) EncryptStream(r io.Reader, w io.Writer) error {
+	size := crypto.ByteLen(pub.N2)
+	cw, err := container.NewWriter(w, container.Header{
+		Algorithm:   container.AlgPaillier,
+		Fingerprint: container.Fingerprint(pub.N),
+		BlockSize:   uint32(size),
+	})
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	err = stream.Map(stream.Count(stream.Blocks(r, pub.BlockSize()), &n), func(b []byte) ([]byte, error) {
+		data := make([]byte, pub.BlockSize())
+		copy(data, b)
+		c, err := pub.Encrypt(new(big.Int).SetBytes(data))
+		if err != nil {
+			return nil, err
+		}
+		return c.FillBytes(make([]byte, size)), nil
+	}, cw.WriteBlock, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
+
+// DecryptStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func (priv *PaillierPrivateKey) DecryptStream(r io.Reader, w io.Writer) error {
+	cr, err := container.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return err
+	}
+	if err := cr.Header().Check(container.AlgPaillier, container.Fingerprint(priv.N)); err != nil {
+		return err
+	}
+
+	size := priv.BlockSize()
+	var wrongKey atomic.Bool
+	pw := stream.NewPadded(w)
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		m, err := priv.Decrypt(new(big.Int).SetBytes(b))
+		if err != nil || m.BitLen() > 8*size {
+			wrongKey.Store(true)
+			return make([]byte, size), nil
+		}
+		return m.FillBytes(make([]byte, size)), nil
+	}, pw.Emit, 0)
+	if err != nil {
+		return err
+	}
+
+	if wrongKey.Load() {
+		return container.ErrWrongKey
+	}
+	return pw.Finish(cr.Len())
+}
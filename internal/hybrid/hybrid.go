@@ -0,0 +1,233 @@
+package hybrid
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"information-defending/internal/gost3412"
+	"information-defending/internal/stream"
+	"io"
+
+	"github.com/ftomza/gogost/mgm"
+)
+
+type DEM byte
+
+const (
+	DEMAESGCM DEM = iota + 1
+	DEMKuznyechikMGM
+)
+
+func (d DEM) String() string {
+	switch d {
+	case DEMAESGCM:
+		return "aes-gcm"
+	case DEMKuznyechikMGM:
+		return "kuznyechik-mgm"
+	}
+	return fmt.Sprintf("dem(%d)", byte(d))
+}
+
+const (
+	Magic     = "IDHY"
+	Version   = 1
+	KeySize   = 32
+	ChunkSize = 64 * 1024
+)
+
+var ErrAuth = errors.New("hybrid: message authentication failed")
+
+func newAEAD(d DEM, key []byte) (cipher.AEAD, error) {
+	switch d {
+	case DEMAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case DEMKuznyechikMGM:
+		block, err := gost3412.NewKuznyechik(key)
+		if err != nil {
+			return nil, err
+		}
+		return mgm.NewMGM(block, gost3412.KuznyechikBlockSize)
+	}
+	return nil, fmt.Errorf("hybrid: unknown DEM %d", byte(d))
+}
+
+// Header layout:
+//
+//	magic[4] | version[1] | kem[1] | dem[1] | chunkSize[4] | wrappedLen[2] | wrapped
+//
+// The header is bound to every chunk as additional data.
+func marshalHeader(k KEM, d DEM, wrapped []byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteString(Magic)
+	buf.WriteByte(Version)
+	buf.WriteByte(byte(k))
+	buf.WriteByte(byte(d))
+	binary.Write(buf, binary.BigEndian, uint32(ChunkSize))
+	binary.Write(buf, binary.BigEndian, uint16(len(wrapped)))
+	buf.Write(wrapped)
+	return buf.Bytes()
+}
+
+type header struct {
+	kem       KEM
+	dem       DEM
+	chunkSize int
+	wrapped   []byte
+	raw       []byte
+}
+
+func readHeader(r io.Reader) (*header, error) {
+	fixed := make([]byte, len(Magic)+1+1+1+4+2)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, fmt.Errorf("hybrid: reading header: %w", err)
+	}
+	if string(fixed[:4]) != Magic {
+		return nil, errors.New("hybrid: not a hybrid ciphertext")
+	}
+	if fixed[4] != Version {
+		return nil, fmt.Errorf("hybrid: unsupported version %d", fixed[4])
+	}
+	h := &header{
+		kem:       KEM(fixed[5]),
+		dem:       DEM(fixed[6]),
+		chunkSize: int(binary.BigEndian.Uint32(fixed[7:11])),
+	}
+	if h.chunkSize == 0 || h.chunkSize > 16*ChunkSize {
+		return nil, fmt.Errorf("hybrid: bad chunk size %d", h.chunkSize)
+	}
+	h.wrapped = make([]byte, binary.BigEndian.Uint16(fixed[11:13]))
+	if _, err := io.ReadFull(r, h.wrapped); err != nil {
+		return nil, fmt.Errorf("hybrid: reading wrapped key: %w", err)
+	}
+	h.raw = append(fixed, h.wrapped...)
+	return h, nil
+}
+
+// Chunks are sealed with nonce = counter and additional data = header ||
+// final flag, so chunks cannot be reordered, dropped or truncated.
+func chunkNonce(aead cipher.AEAD, i uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], i)
+	return nonce
+}
+
+func chunkAD(hdr []byte, final bool) []byte {
+	ad := append([]byte{}, hdr...)
+	if final {
+		return append(ad, 1)
+	}
+	return append(ad, 0)
+}
+
+// readChunk fills buf and reports whether the stream ended after it.
+func readChunk(r *bufio.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, true, nil
+	}
+	if err != nil {
+		return n, false, err
+	}
+	if _, err := r.Peek(1); err == io.EOF {
+		return n, true, nil
+	} else if err != nil {
+		return n, false, err
+	}
+	return n, false, nil
+}
+
+func Encrypt(r io.Reader, w io.Writer, wrapper Wrapper, d DEM) error {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	aead, err := newAEAD(d, key)
+	if err != nil {
+		return err
+	}
+	wrapped, err := wrapper.Wrap(key)
+	if err != nil {
+		return err
+	}
+
+	hdr := marshalHeader(wrapper.KEM(), d, wrapped)
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(r, ChunkSize)
+	buf := make([]byte, ChunkSize)
+	out := make([]byte, 0, ChunkSize+aead.Overhead())
+	for i := uint64(0); ; i++ {
+		n, final, err := readChunk(br, buf)
+		if err != nil {
+			return err
+		}
+		out = aead.Seal(out[:0], chunkNonce(aead, i), buf[:n], chunkAD(hdr, final))
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func Decrypt(r io.Reader, w io.Writer, unwrapper Unwrapper) error {
+	br := bufio.NewReaderSize(r, ChunkSize)
+	h, err := readHeader(br)
+	if err != nil {
+		return err
+	}
+	if h.kem != unwrapper.KEM() {
+		return fmt.Errorf("hybrid: file is encrypted with %s, got %s key", h.kem, unwrapper.KEM())
+	}
+	key, err := unwrapper.Unwrap(h.wrapped)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(h.dem, key)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, h.chunkSize+aead.Overhead())
+	out := make([]byte, 0, h.chunkSize)
+	for i := uint64(0); ; i++ {
+		n, final, err := readChunk(br, buf)
+		if err != nil {
+			return err
+		}
+		out, err = aead.Open(out[:0], chunkNonce(aead, i), buf[:n], chunkAD(h.raw, final))
+		if err != nil {
+			return ErrAuth
+		}
+		if _, err := w.Write(out); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func EncryptFile(inputFile, outputFile string, wrapper Wrapper, d DEM) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return Encrypt(r, w, wrapper, d)
+	})
+}
+
+func DecryptFile(inputFile, outputFile string, unwrapper Unwrapper) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return Decrypt(r, w, unwrapper)
+	})
+}
@@ -0,0 +1,81 @@
+package hybrid
+
+import (
+	"bytes"
+	"information-defending/internal/elgamal"
+	"information-defending/internal/rsa"
+	"testing"
+)
+
+func encryptDecrypt(t *testing.T, msg []byte, w Wrapper, u Unwrapper, d DEM) ([]byte, error) {
+	t.Helper()
+	var ct bytes.Buffer
+	if err := Encrypt(bytes.NewReader(msg), &ct, w, d); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err := Decrypt(&ct, &out, u)
+	return out.Bytes(), err
+}
+
+func TestRoundTrip(t *testing.T) {
+	rk, err := rsa.GenerateKeysWithExponent(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ek, err := elgamal.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kems := []struct {
+		w Wrapper
+		u Unwrapper
+	}{
+		{RSAWrapper{D: rk.D, N: rk.N}, RSAUnwrapper{C: rk.C, N: rk.N}},
+		{ElGamalWrapper{P: ek.P, G: ek.G, Y: ek.Y}, ElGamalUnwrapper{P: ek.P, X: ek.X}},
+	}
+	// empty, short and more than two chunks
+	msgs := [][]byte{nil, []byte("hybrid"), bytes.Repeat([]byte("0123456789"), ChunkSize/4)}
+
+	for _, k := range kems {
+		for _, d := range []DEM{DEMAESGCM, DEMKuznyechikMGM} {
+			for _, msg := range msgs {
+				got, err := encryptDecrypt(t, msg, k.w, k.u, d)
+				if err != nil || !bytes.Equal(got, msg) {
+					t.Fatalf("%s+%s, %d bytes: %v", k.w.KEM(), d, len(msg), err)
+				}
+			}
+		}
+	}
+}
+
+func TestRejects(t *testing.T) {
+	keys, err := rsa.GenerateKeysWithExponent(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKeysWithExponent(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := RSAWrapper{D: keys.D, N: keys.N}
+	msg := []byte("attack at dawn")
+
+	if _, err := encryptDecrypt(t, msg, w, RSAUnwrapper{C: other.C, N: other.N}, DEMAESGCM); err == nil {
+		t.Fatal("decrypted with another key")
+	}
+
+	var ct bytes.Buffer
+	if err := Encrypt(bytes.NewReader(msg), &ct, w, DEMAESGCM); err != nil {
+		t.Fatal(err)
+	}
+	data := ct.Bytes()
+	data[len(data)-1] ^= 1
+	var out bytes.Buffer
+	if err := Decrypt(bytes.NewReader(data), &out, RSAUnwrapper{C: keys.C, N: keys.N}); err == nil {
+		t.Fatal("modified ciphertext accepted")
+	}
+	if _, err := (RSAWrapper{D: keys.D, N: keys.N}).Wrap(make([]byte, rsa.MaxOAEPMessage(keys.N)+1)); err != ErrKeyTooLarge {
+		t.Fatalf("oversized key: %v", err)
+	}
+}
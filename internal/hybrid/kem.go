@@ -0,0 +1,163 @@
+package hybrid
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"information-defending/internal/crypto"
+	"information-defending/internal/elgamal"
+	"information-defending/internal/rsa"
+	"information-defending/internal/timelock"
+	"math/big"
+)
+
+type KEM byte
+
+const (
+	KEMRSAOAEP KEM = iota + 1
+	KEMElGamal
+	KEMTimeLock
+)
+
+func (k KEM) String() string {
+	switch k {
+	case KEMRSAOAEP:
+		return "rsa-oaep"
+	case KEMElGamal:
+		return "elgamal"
+	case KEMTimeLock:
+		return "timelock"
+	}
+	return fmt.Sprintf("kem(%d)", byte(k))
+}
+
+// Wrapper encrypts a session key to the recipient.
+type Wrapper interface {
+	KEM() KEM
+	Wrap(key []byte) ([]byte, error)
+}
+
+// Unwrapper recovers a session key wrapped by the matching Wrapper.
+type Unwrapper interface {
+	KEM() KEM
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+var ErrKeyTooLarge = errors.New("hybrid: modulus is too small to wrap a session key")
+
+type RSAWrapper struct {
+	D *big.Int
+	N *big.Int
+}
+
+type RSAUnwrapper struct {
+	C *big.Int
+	N *big.Int
+}
+
+func (RSAWrapper) KEM() KEM   { return KEMRSAOAEP }
+func (RSAUnwrapper) KEM() KEM { return KEMRSAOAEP }
+
+// Wrap encrypts the key with RSA-OAEP; a 32-byte key needs a modulus of at
+// least 784 bits.
+func (w RSAWrapper) Wrap(key []byte) ([]byte, error) {
+	wrapped, err := rsa.EncryptOAEP(key, nil, w.D, w.N)
+	if err == rsa.ErrMessageTooLong {
+		return nil, ErrKeyTooLarge
+	}
+	return wrapped, err
+}
+
+func (u RSAUnwrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	key, err := rsa.DecryptOAEP(wrapped, nil, u.C, u.N)
+	if err != nil || len(key) != KeySize {
+		return nil, errors.New("hybrid: cannot unwrap session key, wrong private key?")
+	}
+	return key, nil
+}
+
+type ElGamalWrapper struct {
+	P *big.Int
+	G *big.Int
+	Y *big.Int
+}
+
+type ElGamalUnwrapper struct {
+	P *big.Int
+	X *big.Int
+}
+
+func (ElGamalWrapper) KEM() KEM   { return KEMElGamal }
+func (ElGamalUnwrapper) KEM() KEM { return KEMElGamal }
+
+func (w ElGamalWrapper) Wrap(key []byte) ([]byte, error) {
+	m := new(big.Int).SetBytes(key)
+	if m.Cmp(w.P) >= 0 {
+		return nil, ErrKeyTooLarge
+	}
+
+	// k in [2, p-2]
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(w.P, big.NewInt(3)))
+	if err != nil {
+		return nil, err
+	}
+	k.Add(k, big.NewInt(2))
+
+	r, e := elgamal.ElGamalEncrypt(w.P, w.G, w.Y, k, m)
+	size := crypto.ByteLen(w.P)
+	out := make([]byte, 2*size)
+	r.FillBytes(out[:size])
+	e.FillBytes(out[size:])
+	return out, nil
+}
+
+func (u ElGamalUnwrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	size := crypto.ByteLen(u.P)
+	if len(wrapped) != 2*size {
+		return nil, errors.New("hybrid: wrapped key does not match the ElGamal prime")
+	}
+	r := new(big.Int).SetBytes(wrapped[:size])
+	e := new(big.Int).SetBytes(wrapped[size:])
+	return fitKey(elgamal.ElGamalDecrypt(e, r, u.P, u.X))
+}
+
+// The time-lock wrapper needs no recipient key: the session key is hidden
+// in a puzzle that takes T squarings to open. Solve does the squarings.
+type TimeLockWrapper struct {
+	Bits int
+	T    uint64
+}
+
+type TimeLockUnwrapper struct {
+	Solve func(p *timelock.Puzzle) (*big.Int, error)
+}
+
+func (TimeLockWrapper) KEM() KEM   { return KEMTimeLock }
+func (TimeLockUnwrapper) KEM() KEM { return KEMTimeLock }
+
+func (w TimeLockWrapper) Wrap(key []byte) ([]byte, error) {
+	p, err := timelock.New(new(big.Int).SetBytes(key), w.Bits, w.T)
+	if err != nil {
+		return nil, err
+	}
+	return p.MarshalBinary()
+}
+
+func (u TimeLockUnwrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	var p timelock.Puzzle
+	if err := p.UnmarshalBinary(wrapped); err != nil {
+		return nil, err
+	}
+	b, err := u.Solve(&p)
+	if err != nil {
+		return nil, err
+	}
+	return fitKey(p.Open(b))
+}
+
+func fitKey(m *big.Int) ([]byte, error) {
+	if m.BitLen() > 8*KeySize {
+		return nil, errors.New("hybrid: cannot unwrap session key, wrong private key?")
+	}
+	return m.FillBytes(make([]byte, KeySize)), nil
+}
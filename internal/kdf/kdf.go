@@ -0,0 +1,219 @@
+package kdf
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ftomza/gogost/gost34112012512"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Alg is a password-based key derivation function.
+type Alg byte
+
+const (
+	PBKDF2 Alg = iota + 1 // PBKDF2-HMAC-SHA256, RFC 8018
+	Scrypt                // RFC 7914
+	// PBKDF2 with HMAC-Streebog-512, GOST R 50.1.111-2016
+	PBKDF2Streebog
+)
+
+func ParseAlg(s string) (Alg, error) {
+	for a := PBKDF2; a <= PBKDF2Streebog; a++ {
+		if a.String() == s {
+			return a, nil
+		}
+	}
+	return 0, fmt.Errorf("kdf: unknown function %q (pbkdf2-sha256, scrypt, pbkdf2-streebog)", s)
+}
+
+func (a Alg) String() string {
+	switch a {
+	case PBKDF2:
+		return "pbkdf2-sha256"
+	case Scrypt:
+		return "scrypt"
+	case PBKDF2Streebog:
+		return "pbkdf2-streebog"
+	}
+	return fmt.Sprintf("kdf(%d)", byte(a))
+}
+
+// DefaultCost follows current advice for each function: 600000 PBKDF2-SHA256
+// iterations and scrypt N = 2^15 with r = 8, p = 1. Nobody gives a count
+// for Streebog, and the one from gogost is far too slow to match SHA-256
+// (BenchmarkKey: about 0.7 ms an iteration against 0.3 µs), so its
+// default is the RFC 8018 minimum of 1000 and New calibrates it to
+// streebogTime instead, never going below that.
+func (a Alg) DefaultCost() uint32 {
+	switch a {
+	case PBKDF2:
+		return 600000
+	case Scrypt:
+		return 15
+	case PBKDF2Streebog:
+		return 1000
+	}
+	return 0
+}
+
+const (
+	SaltSize = 32 // GOST R 50.1.111-2016 asks for at least 32 bytes
+
+	// limits on what a header may ask for, so that a crafted file cannot
+	// make decryption take hours or gigabytes
+	maxIterations = 1 << 26
+	maxLogN       = 24
+	maxMemory     = 1 << 31
+)
+
+// streebogTime is what a default PBKDF2-Streebog key takes on this machine.
+const streebogTime = time.Second
+
+// streebogCost calibrates once per process, it takes about a second.
+var streebogCost = sync.OnceValues(func() (uint32, error) {
+	cost, err := Calibrate(PBKDF2Streebog, streebogTime)
+	return max(cost, PBKDF2Streebog.DefaultCost()), err
+})
+
+var (
+	ErrCost = errors.New("kdf: cost out of range")
+	// ErrWrongPassword is what a key check failure means for password files.
+	ErrWrongPassword = errors.New("kdf: wrong password")
+)
+
+// Params is everything besides the password needed to derive the key
+// again, and travels in the ciphertext header. Cost is the iteration count
+// for PBKDF2 and log2 N for scrypt.
+type Params struct {
+	Alg  Alg
+	Cost uint32
+	R, P uint8 // scrypt only
+	Salt []byte
+}
+
+// New picks a fresh random salt; cost 0 means the default, which for
+// PBKDF2-Streebog is calibrated, see DefaultCost.
+func New(a Alg, cost uint32) (Params, error) {
+	if cost == 0 && a == PBKDF2Streebog {
+		var err error
+		if cost, err = streebogCost(); err != nil {
+			return Params{}, err
+		}
+	}
+	if cost == 0 {
+		cost = a.DefaultCost()
+	}
+	return newParams(a, cost)
+}
+
+func newParams(a Alg, cost uint32) (Params, error) {
+	p := Params{Alg: a, Cost: cost, Salt: make([]byte, SaltSize)}
+	if a == Scrypt {
+		p.R, p.P = 8, 1
+	}
+	if err := p.check(); err != nil {
+		return Params{}, err
+	}
+	if _, err := rand.Read(p.Salt); err != nil {
+		return Params{}, err
+	}
+	return p, nil
+}
+
+func (p Params) check() error {
+	switch p.Alg {
+	case PBKDF2, PBKDF2Streebog:
+		if p.Cost < 1 || p.Cost > maxIterations {
+			return ErrCost
+		}
+	case Scrypt:
+		if p.Cost < 1 || p.Cost > maxLogN || p.R == 0 || p.P == 0 ||
+			128*uint64(p.R)<<p.Cost > maxMemory {
+			return ErrCost
+		}
+	default:
+		return fmt.Errorf("kdf: unknown function %d", byte(p.Alg))
+	}
+	return nil
+}
+
+// Key derives size bytes from password.
+func (p Params) Key(password []byte, size int) ([]byte, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	switch p.Alg {
+	case PBKDF2:
+		return pbkdf2.Key(sha256.New, string(password), p.Salt, int(p.Cost), size)
+	case PBKDF2Streebog:
+		return pbkdf2.Key(gost34112012512.New, string(password), p.Salt, int(p.Cost), size)
+	}
+	return scrypt.Key(password, p.Salt, 1<<p.Cost, int(p.R), int(p.P), size)
+}
+
+// MarshalBinary encodes alg[1] | cost[4] | r[1] | p[1] | saltLen[1] | salt.
+func (p Params) MarshalBinary() ([]byte, error) {
+	if len(p.Salt) > 255 {
+		return nil, errors.New("kdf: salt too long")
+	}
+	b := []byte{byte(p.Alg)}
+	b = binary.BigEndian.AppendUint32(b, p.Cost)
+	b = append(b, p.R, p.P, byte(len(p.Salt)))
+	return append(b, p.Salt...), nil
+}
+
+func (p *Params) UnmarshalBinary(b []byte) error {
+	if len(b) < 8 || len(b) != 8+int(b[7]) {
+		return errors.New("kdf: malformed parameters")
+	}
+	*p = Params{
+		Alg:  Alg(b[0]),
+		Cost: binary.BigEndian.Uint32(b[1:5]),
+		R:    b[5],
+		P:    b[6],
+		Salt: append([]byte(nil), b[8:]...),
+	}
+	return p.check()
+}
+
+// Calibrate finds the cost at which one derivation takes about d on this
+// machine, doubling from a cheap start.
+func Calibrate(a Alg, d time.Duration) (uint32, error) {
+	p, err := newParams(a, a.DefaultCost())
+	if err != nil {
+		return 0, err
+	}
+	switch a {
+	case Scrypt:
+		p.Cost = 10
+	default:
+		p.Cost = 1000
+	}
+	for {
+		start := time.Now()
+		if _, err := p.Key([]byte("calibrate"), 32); err != nil {
+			return 0, err
+		}
+		took := time.Since(start)
+		if a == Scrypt {
+			// time grows with N, one step doubles it
+			if took*2 > d || p.Cost == maxLogN {
+				return p.Cost, nil
+			}
+			p.Cost++
+			continue
+		}
+		if took >= d/4 || p.Cost*2 > maxIterations {
+			cost := uint64(p.Cost) * uint64(d) / uint64(max(took, 1))
+			return uint32(min(max(cost, 1), maxIterations)), nil
+		}
+		p.Cost *= 2
+	}
+}
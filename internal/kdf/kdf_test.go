@@ -0,0 +1,169 @@
+package kdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Examples from RFC 7914 section 11 (PBKDF2-HMAC-SHA256 and scrypt) and
+// GOST R 50.1.111-2016 (PBKDF2 with HMAC-Streebog-512).
+var vectors = []struct {
+	p             Params
+	password, key string
+}{
+	{
+		Params{Alg: PBKDF2, Cost: 1, Salt: []byte("salt")}, "passwd",
+		"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+			"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+	},
+	{
+		Params{Alg: Scrypt, Cost: 10, R: 8, P: 16, Salt: []byte("NaCl")}, "password",
+		"fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b373162" +
+			"2eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+	},
+	{
+		Params{Alg: PBKDF2Streebog, Cost: 1, Salt: []byte("salt")}, "password",
+		"64770af7f748c3b1c9ac831dbcfd85c26111b30a8a657ddc3056b80ca73e040d" +
+			"2854fd36811f6d825cc4ab66ec0a68a490a9e5cf5156b3a2b7eecddbf9a16b47",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		t.Run(v.p.Alg.String(), func(t *testing.T) {
+			want, _ := hex.DecodeString(v.key)
+			got, err := v.p.Key([]byte(v.password), len(want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("key is %x, want %x", got, want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	for a := PBKDF2; a <= PBKDF2Streebog; a++ {
+		if b, err := ParseAlg(a.String()); err != nil || b != a {
+			t.Errorf("ParseAlg(%q) = %v, %v", a, b, err)
+		}
+		p, err := New(a, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := New(a, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Salt) != SaltSize || bytes.Equal(p.Salt, q.Salt) {
+			t.Errorf("%s: salts %x and %x", a, p.Salt, q.Salt)
+		}
+		kp, err := p.Key([]byte("password"), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kq, err := q.Key([]byte("password"), 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(kp, kq) {
+			t.Errorf("%s: the same key under two salts", a)
+		}
+	}
+	if p, err := New(Scrypt, 0); err != nil || p.Cost != 15 || p.R != 8 || p.P != 1 {
+		t.Errorf("default scrypt: %+v, %v", p, err)
+	}
+	if _, err := New(PBKDF2, maxIterations+1); err != ErrCost {
+		t.Errorf("too many iterations: %v", err)
+	}
+	if _, err := New(Scrypt, maxLogN+1); err != ErrCost {
+		t.Errorf("too much memory: %v", err)
+	}
+	if _, err := New(Alg(9), 1); err == nil {
+		t.Error("unknown function accepted")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	for a := PBKDF2; a <= PBKDF2Streebog; a++ {
+		p, err := New(a, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var q Params
+		if err := q.UnmarshalBinary(b); err != nil {
+			t.Fatal(err)
+		}
+		if q.Alg != p.Alg || q.Cost != p.Cost || q.R != p.R || q.P != p.P || !bytes.Equal(q.Salt, p.Salt) {
+			t.Errorf("read back %+v, want %+v", q, p)
+		}
+		if err := q.UnmarshalBinary(b[:len(b)-1]); err == nil {
+			t.Errorf("%s: truncated parameters accepted", a)
+		}
+	}
+
+	// a header asking for more than the limits is refused before any work
+	p := Params{Alg: Scrypt, Cost: 20, R: 255, P: 1, Salt: []byte("salt")}
+	b, _ := p.MarshalBinary()
+	if err := new(Params).UnmarshalBinary(b); err != ErrCost {
+		t.Errorf("4 GiB scrypt: %v", err)
+	}
+	p = Params{Alg: PBKDF2, Cost: 1 << 30, Salt: []byte("salt")}
+	b, _ = p.MarshalBinary()
+	if err := new(Params).UnmarshalBinary(b); err != ErrCost {
+		t.Errorf("2^30 iterations: %v", err)
+	}
+	if _, err := (Params{Alg: PBKDF2, Cost: 1, Salt: make([]byte, 256)}).MarshalBinary(); err == nil {
+		t.Error("256-byte salt accepted")
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	cost, err := Calibrate(PBKDF2, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost < 1 || cost > maxIterations {
+		t.Fatalf("cost %d", cost)
+	}
+	if _, err := Calibrate(Alg(9), time.Millisecond); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("unknown function: %v", err)
+	}
+}
+
+// TestStreebogDefault calibrates, which takes about a second.
+func TestStreebogDefault(t *testing.T) {
+	p, err := New(PBKDF2Streebog, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Cost < PBKDF2Streebog.DefaultCost() {
+		t.Errorf("calibrated to %d iterations, below the minimum", p.Cost)
+	}
+}
+
+// BenchmarkKey measures one key at the default cost; the Streebog count
+// in DefaultCost rests on it.
+func BenchmarkKey(b *testing.B) {
+	for a := PBKDF2; a <= PBKDF2Streebog; a++ {
+		p, err := newParams(a, a.DefaultCost())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(a.String(), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := p.Key([]byte("password"), 32); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package keystream
+
+import (
+	"errors"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rabin"
+	"math/big"
+)
+
+// BBS is the Blum-Blum-Shub generator x_(i+1) = x_i^2 mod N. Each step
+// yields the low log2(log2 N) bits of x, which stay unpredictable as long
+// as N cannot be factored.
+type BBS struct {
+	N    *big.Int
+	x    *big.Int
+	per  int // bits per step
+	bits uint64
+	have int
+}
+
+// GenerateBBSModulus returns N = pq with p, q = 3 mod 4, as for Rabin.
+func GenerateBBSModulus(bits int) (*big.Int, error) {
+	k, err := rabin.GenerateKeys(bits)
+	if err != nil {
+		return nil, err
+	}
+	return k.N, nil
+}
+
+// NewBBS starts from x_0 = seed^2 mod N; seed must be coprime to N.
+func NewBBS(N, seed *big.Int) (*BBS, error) {
+	if N == nil || N.BitLen() < 64 {
+		return nil, errors.New("keystream: BBS modulus is too small")
+	}
+	x := new(big.Int).Mod(seed, N)
+	if x.Sign() == 0 || crypto.Gcd(x, N).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("keystream: BBS seed must be coprime to N")
+	}
+	per := 1
+	for 1<<(per+1) <= N.BitLen() {
+		per++
+	}
+	x.Mul(x, x).Mod(x, N)
+	return &BBS{N: N, x: x, per: per}, nil
+}
+
+func (g *BBS) byte() byte {
+	for g.have < 8 {
+		g.x.Mul(g.x, g.x).Mod(g.x, g.N)
+		low := g.x.Bits()[0]
+		g.bits |= (uint64(low) & (1<<g.per - 1)) << g.have
+		g.have += g.per
+	}
+	b := byte(g.bits)
+	g.bits >>= 8
+	g.have -= 8
+	return b
+}
+
+func (g *BBS) XORKeyStream(dst, src []byte) {
+	for i, b := range src {
+		dst[i] = b ^ g.byte()
+	}
+}
@@ -0,0 +1,56 @@
+package keystream
+
+import (
+	"encoding/binary"
+
+	"github.com/ftomza/gogost/gost28147"
+)
+
+// GOST is GOST 28147-89 in counter ("gamma") mode with the CryptoPro A
+// S-boxes. gogost's CTR skips a gamma block after every call whose length
+// is a multiple of the block size, so the counter is kept here and every
+// gamma block is one encryption of it.
+type GOST struct {
+	c      *gost28147.Cipher
+	n1, n2 uint32 // N3 and N4 of the standard
+	buf    [gost28147.BlockSize]byte
+	used   int
+}
+
+// The gamma constants C2 and C1: N3 grows by C2 mod 2^32, N4 by C1 mod 2^32-1.
+const (
+	gammaC2 = 0x01010101
+	gammaC1 = 0x01010104
+)
+
+func NewGOST(key, iv []byte) *GOST {
+	return newGOST(key, iv, &gost28147.SboxIdGost2814789CryptoProAParamSet)
+}
+
+func newGOST(key, iv []byte, sbox *gost28147.Sbox) *GOST {
+	g := &GOST{c: gost28147.NewCipher(key, sbox), used: gost28147.BlockSize}
+	var s [gost28147.BlockSize]byte
+	g.c.Encrypt(s[:], iv)
+	g.n1 = binary.LittleEndian.Uint32(s[:4])
+	g.n2 = binary.LittleEndian.Uint32(s[4:])
+	return g
+}
+
+func (g *GOST) next() {
+	g.n1 += gammaC2
+	g.n2 = uint32((uint64(g.n2) + gammaC1) % (1<<32 - 1))
+	binary.LittleEndian.PutUint32(g.buf[:4], g.n1)
+	binary.LittleEndian.PutUint32(g.buf[4:], g.n2)
+	g.c.Encrypt(g.buf[:], g.buf[:])
+	g.used = 0
+}
+
+func (g *GOST) XORKeyStream(dst, src []byte) {
+	for i, b := range src {
+		if g.used == len(g.buf) {
+			g.next()
+		}
+		dst[i] = b ^ g.buf[g.used]
+		g.used++
+	}
+}
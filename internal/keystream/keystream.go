@@ -0,0 +1,125 @@
+// Package keystream provides generators of key bytes for the Vernam
+// cipher. Every generator is a cipher.Stream, so encryption and
+// decryption are both XORKeyStream.
+package keystream
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+type Kind byte
+
+const (
+	KindLFSR Kind = iota + 1
+	KindBBS
+	KindGOST
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindLFSR:
+		return "lfsr"
+	case KindBBS:
+		return "bbs"
+	case KindGOST:
+		return "gost"
+	}
+	return fmt.Sprintf("keystream(%d)", byte(k))
+}
+
+func ParseKind(s string) (Kind, error) {
+	for _, k := range []Kind{KindLFSR, KindBBS, KindGOST} {
+		if k.String() == s {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("keystream: unknown generator %q (lfsr, bbs, gost)", s)
+}
+
+// Spec is everything about a generator except its seed. It is public and
+// travels with the ciphertext.
+type Spec struct {
+	Kind Kind
+
+	Length int    // LFSR register length, at most 64
+	Taps   uint64 // LFSR feedback: bit i-1 is c_i in s_n = sum c_i s_(n-i)
+
+	N *big.Int // BBS modulus, a product of two primes = 3 mod 4
+}
+
+// DefaultLFSR is x^64 + x^63 + x^61 + x^60 + 1, a primitive polynomial,
+// so every non-zero seed gives the full period 2^64 - 1.
+var DefaultLFSR = Spec{Kind: KindLFSR, Length: 64, Taps: 1<<63 | 1<<62 | 1<<60 | 1<<59}
+
+// SeedSize is the number of bytes Derive produces for New.
+const SeedSize = 40
+
+// Derive turns a shared secret of any length, such as a Diffie-Hellman
+// key, and a per-file nonce into a seed with HKDF-SHA-256.
+func Derive(secret, nonce []byte, k Kind) ([]byte, error) {
+	return hkdf.Key(sha256.New, secret, nonce, "information-defending keystream "+k.String(), SeedSize)
+}
+
+// New seeds the generator described by s.
+func (s Spec) New(seed []byte) (Stream, error) {
+	if len(seed) < SeedSize {
+		return nil, errors.New("keystream: seed too short")
+	}
+	switch s.Kind {
+	case KindLFSR:
+		return NewLFSR(s.Length, s.Taps, binary.BigEndian.Uint64(seed))
+	case KindBBS:
+		return NewBBS(s.N, new(big.Int).SetBytes(seed))
+	case KindGOST:
+		return NewGOST(seed[:32], seed[32:40]), nil
+	}
+	return nil, fmt.Errorf("keystream: unknown generator %s", s.Kind)
+}
+
+// Layout: kind[1], then LFSR: length[1] | taps[8]; BBS: N; GOST: nothing.
+func (s Spec) MarshalBinary() ([]byte, error) {
+	b := []byte{byte(s.Kind)}
+	switch s.Kind {
+	case KindLFSR:
+		b = append(b, byte(s.Length))
+		b = binary.BigEndian.AppendUint64(b, s.Taps)
+	case KindBBS:
+		if s.N == nil {
+			return nil, errors.New("keystream: BBS needs a modulus")
+		}
+		b = append(b, s.N.Bytes()...)
+	case KindGOST:
+	default:
+		return nil, fmt.Errorf("keystream: unknown generator %s", s.Kind)
+	}
+	return b, nil
+}
+
+func (s *Spec) UnmarshalBinary(b []byte) error {
+	if len(b) < 1 {
+		return errors.New("keystream: empty spec")
+	}
+	*s = Spec{Kind: Kind(b[0])}
+	switch s.Kind {
+	case KindLFSR:
+		if len(b) != 10 {
+			return errors.New("keystream: malformed LFSR spec")
+		}
+		s.Length = int(b[1])
+		s.Taps = binary.BigEndian.Uint64(b[2:])
+	case KindBBS:
+		s.N = new(big.Int).SetBytes(b[1:])
+	case KindGOST:
+		if len(b) != 1 {
+			return errors.New("keystream: malformed GOST spec")
+		}
+	default:
+		return fmt.Errorf("keystream: unknown generator %s", s.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package keystream
+
+import (
+	"crypto/cipher"
+	"errors"
+	"math/bits"
+)
+
+// Stream is what every generator implements.
+type Stream = cipher.Stream
+
+// LFSR is a Fibonacci register over GF(2): s_n = sum c_i s_(n-i) for
+// i = 1..Length. Bits come out LSB first in every byte.
+type LFSR struct {
+	Length int
+	Taps   uint64
+	state  uint64 // bit j holds s_(n+j), bit 0 is the next output
+	fb     uint64 // c_i moved to bit Length-i, where s_(n+Length-i) is
+}
+
+// NewLFSR starts the register at the low Length bits of seed; the zero
+// state would only produce zeros.
+func NewLFSR(length int, taps, seed uint64) (*LFSR, error) {
+	if length < 1 || length > 64 {
+		return nil, errors.New("keystream: LFSR length must be 1..64")
+	}
+	mask := ^uint64(0) >> (64 - length)
+	if taps&mask == 0 || taps&^mask != 0 {
+		return nil, errors.New("keystream: LFSR taps must lie within the register")
+	}
+	seed &= mask
+	if seed == 0 {
+		seed = 1
+	}
+	fb := bits.Reverse64(taps) >> (64 - length)
+	return &LFSR{Length: length, Taps: taps, state: seed, fb: fb}, nil
+}
+
+// Bit returns the next output bit.
+func (l *LFSR) Bit() byte {
+	out := l.state & 1
+	fb := uint64(bits.OnesCount64(l.fb&l.state) & 1)
+	l.state = l.state>>1 | fb<<(l.Length-1)
+	return byte(out)
+}
+
+func (l *LFSR) XORKeyStream(dst, src []byte) {
+	for i, b := range src {
+		var k byte
+		for j := range 8 {
+			k |= l.Bit() << j
+		}
+		dst[i] = b ^ k
+	}
+}
@@ -0,0 +1,121 @@
+// Package masseyomura is the three-pass protocol of package shamir with
+// the field Z_p replaced by GF(2^n): messages are field elements and the
+// exponents are taken modulo 2^n - 1.
+package masseyomura
+
+import (
+	"crypto/rand"
+	"fmt"
+	"information-defending/internal/chunk"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/gf2n"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+	"sync/atomic"
+)
+
+// GenerateKeys returns exponents c, d with c*d = 1 mod 2^n - 1. It panics
+// if the random source fails; NewKeys returns the error instead.
+func GenerateKeys(f *gf2n.Field) (*big.Int, *big.Int) {
+	c, d, err := NewKeys(f)
+	if err != nil {
+		panic(err)
+	}
+	return c, d
+}
+
+func NewKeys(f *gf2n.Field) (c, d *big.Int, err error) {
+	order := f.Order()
+	for {
+		c, err = rand.Int(rand.Reader, order)
+		if err != nil {
+			return nil, nil, err
+		}
+		if c.Cmp(big.NewInt(1)) > 0 && crypto.Gcd(c, order).Cmp(big.NewInt(1)) == 0 {
+			return c, crypto.ModInverse(c, order), nil
+		}
+	}
+}
+
+func Protocol(m *big.Int, f *gf2n.Field, k1, k2 *big.Int) *big.Int {
+	return f.Exp(f.Exp(m, k1), k2)
+}
+
+func EncryptFile(inputFile, outputFile string, f *gf2n.Field, cA, cB *big.Int) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptStream(r, w, f, cA, cB)
+	})
+}
+
+func DecryptFile(inputFile, outputFile string, f *gf2n.Field, dA, dB *big.Int) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptStream(r, w, f, dA, dB)
+	})
+}
+
+// EncryptStream writes one block per chunk of ChunkSize(f) bytes; the
+// container fingerprint is that of the field polynomial.
+func EncryptStream(r io.Reader, w io.Writer, f *gf2n.Field, cA, cB *big.Int) error {
+	if ChunkSize(f) < 1 {
+		return fmt.Errorf("masseyomura: GF(2^%d) is too small, need n > 8", f.N)
+	}
+	size := (f.N + 7) / 8
+	cw, err := container.NewWriter(w, container.Header{
+		Algorithm:   container.AlgMasseyOmura,
+		Fingerprint: container.Fingerprint(f.Poly),
+		BlockSize:   uint32(size),
+	})
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	err = stream.Map(stream.Count(stream.Blocks(r, ChunkSize(f)), &n), func(b []byte) ([]byte, error) {
+		enc := Protocol(chunk.Encode(b), f, cA, cB)
+		return enc.FillBytes(make([]byte, size)), nil
+	}, cw.WriteBlock, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
+
+// DecryptStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func DecryptStream(r io.Reader, w io.Writer, f *gf2n.Field, dA, dB *big.Int) error {
+	cr, err := container.NewReader(r)
+	if err != nil {
+		return err
+	}
+	if err := cr.Header().Check(container.AlgMasseyOmura, container.Fingerprint(f.Poly)); err != nil {
+		return err
+	}
+
+	var wrongKey atomic.Bool
+	var n uint64
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		x := new(big.Int).SetBytes(b)
+		if checkValue(x, f) != nil {
+			return nil, ErrRange
+		}
+		data, err := chunk.Decode(Protocol(x, f, dA, dB))
+		if err != nil {
+			wrongKey.Store(true)
+			return nil, nil
+		}
+		return data, nil
+	}, stream.Writer(w, &n), 0)
+	if err != nil {
+		return err
+	}
+
+	if wrongKey.Load() {
+		return container.ErrWrongKey
+	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package masseyomura
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"information-defending/internal/container"
+	"information-defending/internal/gf2n"
+	"math/big"
+	"testing"
+)
+
+func testField(t *testing.T, n int) *gf2n.Field {
+	t.Helper()
+	f, err := gf2n.Standard(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParties(t *testing.T) {
+	f := testField(t, 127)
+	alice, err := NewAlice(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewBob(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("\x00\x00Massey-Omura over GF(2^127)")
+	x1, err := alice.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x2, err := bob.Encrypt(x1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x3, err := alice.Decrypt(x2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := bob.Decrypt(x3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Fatalf("Bob got %q", got)
+	}
+
+	outside := new(big.Int).Lsh(big.NewInt(1), uint(f.N))
+	for _, x := range []*big.Int{big.NewInt(0), big.NewInt(1), outside, nil} {
+		if _, err := bob.Encrypt([]*big.Int{x}); !errors.Is(err, ErrRange) {
+			t.Errorf("x = %v: %v", x, err)
+		}
+	}
+	if _, err := NewAlice(testField(t, 8)); err == nil {
+		t.Error("GF(2^8) accepted")
+	}
+}
+
+func TestStream(t *testing.T) {
+	f := testField(t, 163)
+	cA, dA, err := NewKeys(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cB, dB, err := NewKeys(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, ChunkSize(f), 4*ChunkSize(f) + 1} {
+		data := make([]byte, size)
+		rand.Read(data)
+		var enc, dec bytes.Buffer
+		if err := EncryptStream(bytes.NewReader(data), &enc, f, cA, cB); err != nil {
+			t.Fatal(err)
+		}
+		if err := DecryptStream(bytes.NewReader(enc.Bytes()), &dec, f, dA, dB); err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+		if !bytes.Equal(dec.Bytes(), data) {
+			t.Fatalf("%d bytes: decrypted %x", size, dec.Bytes())
+		}
+	}
+
+	var enc bytes.Buffer
+	if err := EncryptStream(bytes.NewReader([]byte("secret message")), &enc, f, cA, cB); err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := NewKeys(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptStream(bytes.NewReader(enc.Bytes()), new(bytes.Buffer), f, dA, other); err != container.ErrWrongKey {
+		t.Errorf("wrong key: %v", err)
+	}
+	if err := DecryptStream(bytes.NewReader(enc.Bytes()), new(bytes.Buffer), testField(t, 233), dA, dB); err != container.ErrWrongKey {
+		t.Errorf("other field: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package masseyomura
+
+import (
+	"errors"
+	"fmt"
+	"information-defending/internal/chunk"
+	"information-defending/internal/gf2n"
+	"math/big"
+)
+
+// The four steps as in shamir.Alice and shamir.Bob:
+//
+//	x1 := alice.Encrypt(msg)  // m^cA
+//	x2 := bob.Encrypt(x1)     // m^(cA*cB)
+//	x3 := alice.Decrypt(x2)   // m^cB
+//	msg := bob.Decrypt(x3)    // m
+type Alice struct {
+	f    *gf2n.Field
+	c, d *big.Int
+}
+
+type Bob struct {
+	f    *gf2n.Field
+	c, d *big.Int
+}
+
+func NewAlice(f *gf2n.Field) (*Alice, error) {
+	c, d, err := partyKeys(f)
+	if err != nil {
+		return nil, err
+	}
+	return &Alice{f: f, c: c, d: d}, nil
+}
+
+func NewBob(f *gf2n.Field) (*Bob, error) {
+	c, d, err := partyKeys(f)
+	if err != nil {
+		return nil, err
+	}
+	return &Bob{f: f, c: c, d: d}, nil
+}
+
+func (a *Alice) Encrypt(msg []byte) ([]*big.Int, error) {
+	ms, err := Split(msg, a.f)
+	if err != nil {
+		return nil, err
+	}
+	return power(ms, a.c, a.f)
+}
+
+func (b *Bob) Encrypt(x1 []*big.Int) ([]*big.Int, error) {
+	return power(x1, b.c, b.f)
+}
+
+func (a *Alice) Decrypt(x2 []*big.Int) ([]*big.Int, error) {
+	return power(x2, a.d, a.f)
+}
+
+func (b *Bob) Decrypt(x3 []*big.Int) ([]byte, error) {
+	ms, err := power(x3, b.d, b.f)
+	if err != nil {
+		return nil, err
+	}
+	return Join(ms)
+}
+
+func partyKeys(f *gf2n.Field) (*big.Int, *big.Int, error) {
+	if ChunkSize(f) < 1 {
+		return nil, nil, fmt.Errorf("masseyomura: GF(2^%d) is too small, need n > 8", f.N)
+	}
+	// NewKeys never returns c = 1, which would send the message in the clear
+	return NewKeys(f)
+}
+
+func power(xs []*big.Int, k *big.Int, f *gf2n.Field) ([]*big.Int, error) {
+	out := make([]*big.Int, len(xs))
+	for i, x := range xs {
+		if err := checkValue(x, f); err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i, err)
+		}
+		out[i] = f.Exp(x, k)
+	}
+	return out, nil
+}
+
+var ErrRange = errors.New("masseyomura: value must be a field element other than 0 and 1")
+
+func checkValue(x *big.Int, f *gf2n.Field) error {
+	if x == nil || x.Cmp(big.NewInt(1)) <= 0 || !f.Contains(x) {
+		return ErrRange
+	}
+	return nil
+}
+
+// ChunkSize is the number of message bytes per chunk. A chunk is the
+// polynomial 1 || data of degree 8*ChunkSize, below n.
+func ChunkSize(f *gf2n.Field) int {
+	return chunk.Size(f.N)
+}
+
+// Split cuts msg into chunks for f; the last one may be shorter.
+func Split(msg []byte, f *gf2n.Field) ([]*big.Int, error) {
+	k := ChunkSize(f)
+	if k < 1 {
+		return nil, fmt.Errorf("masseyomura: GF(2^%d) is too small for chunks", f.N)
+	}
+	return chunk.Split(msg, k), nil
+}
+
+func Join(ms []*big.Int) ([]byte, error) {
+	return chunk.Join(ms)
+}
@@ -0,0 +1,281 @@
+// Package rabin implements the Rabin cryptosystem and Rabin-Williams
+// signatures. Recovering plaintexts or forging signatures is as hard as
+// factoring N.
+package rabin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+	"sync/atomic"
+)
+
+// TagSize bytes of SHA-256 follow each plaintext block; of the four square
+// roots only the right one carries a matching tag.
+const TagSize = 8
+
+// Keys use Blum primes with P = 3 mod 8 and Q = 7 mod 8, the Williams
+// form, so the same key encrypts and signs.
+type Keys struct {
+	N *big.Int
+	P *big.Int
+	Q *big.Int
+}
+
+func GenerateKeys(bits int) (Keys, error) {
+	p, err := blumPrime(bits-bits/2, 3)
+	if err != nil {
+		return Keys{}, err
+	}
+	for {
+		q, err := blumPrime(bits/2, 7)
+		if err != nil {
+			return Keys{}, err
+		}
+		N := new(big.Int).Mul(p, q)
+		if N.BitLen() == bits {
+			return Keys{N: N, P: p, Q: q}, nil
+		}
+	}
+}
+
+func blumPrime(bits int, mod8 uint) (*big.Int, error) {
+	for {
+		p, err := rand.Prime(rand.Reader, bits)
+		if err != nil {
+			return nil, err
+		}
+		if uint(p.Bits()[0]&7) == mod8 {
+			return p, nil
+		}
+	}
+}
+
+func Encrypt(m, N *big.Int) *big.Int {
+	if m.Cmp(N) != -1 {
+		return nil
+	}
+	e := new(big.Int).Mul(m, m)
+	return e.Mod(e, N)
+}
+
+// Decrypt returns the four square roots of e modulo N = p*q.
+func Decrypt(e, p, q *big.Int) ([4]*big.Int, error) {
+	var roots [4]*big.Int
+	mp, err := crypto.ModSqrt(e, p)
+	if err != nil {
+		return roots, err
+	}
+	mq, err := crypto.ModSqrt(e, q)
+	if err != nil {
+		return roots, err
+	}
+
+	// yp*p + yq*q = 1
+	N := new(big.Int).Mul(p, q)
+	yp, yq := new(big.Int), new(big.Int)
+	new(big.Int).GCD(yp, yq, p, q)
+	a := new(big.Int).Mul(yp, p)
+	a.Mul(a, mq)
+	b := new(big.Int).Mul(yq, q)
+	b.Mul(b, mp)
+
+	r := new(big.Int).Add(a, b)
+	r.Mod(r, N)
+	s := new(big.Int).Sub(a, b)
+	s.Mod(s, N)
+	roots[0] = r
+	roots[1] = new(big.Int).Sub(N, r)
+	roots[2] = s
+	roots[3] = new(big.Int).Sub(N, s)
+	return roots, nil
+}
+
+// BlockSize is the number of plaintext bytes per block: the encoded block
+// data || tag must stay below N.
+func BlockSize(N *big.Int) int {
+	return crypto.ByteLen(N) - 1 - TagSize
+}
+
+func encodeBlock(data []byte) *big.Int {
+	tag := sha256.Sum256(data)
+	return new(big.Int).SetBytes(append(data, tag[:TagSize]...))
+}
+
+func decodeBlock(roots [4]*big.Int, size int) []byte {
+	for _, r := range roots {
+		if r.BitLen() > 8*(size+TagSize) {
+			continue
+		}
+		em := r.FillBytes(make([]byte, size+TagSize))
+		data := em[:size]
+		tag := sha256.Sum256(data)
+		if bytes.Equal(tag[:TagSize], em[size:]) {
+			return data
+		}
+	}
+	return nil
+}
+
+func EncryptFile(inputFile, outputFile string, N *big.Int) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptStream(r, w, N)
+	})
+}
+
+func DecryptFile(inputFile, outputFile string, p, q *big.Int) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptStream(r, w, p, q)
+	})
+}
+
+func EncryptStream(r io.Reader, w io.Writer, N *big.Int) error {
+	size := crypto.ByteLen(N)
+	if BlockSize(N) < 1 {
+		return errors.New("rabin: modulus is too small")
+	}
+	cw, err := container.NewWriter(w, container.Header{
+		Algorithm:   container.AlgRabin,
+		Fingerprint: container.Fingerprint(N),
+		BlockSize:   uint32(size),
+	})
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	err = stream.Map(stream.Count(stream.Blocks(r, BlockSize(N)), &n), func(b []byte) ([]byte, error) {
+		data := make([]byte, BlockSize(N))
+		copy(data, b)
+		e := Encrypt(encodeBlock(data), N)
+		return e.FillBytes(make([]byte, size)), nil
+	}, cw.WriteBlock, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
+
+// DecryptStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func DecryptStream(r io.Reader, w io.Writer, p, q *big.Int) error {
+	N := new(big.Int).Mul(p, q)
+	br := bufio.NewReader(r)
+	cr, err := container.NewReader(br)
+	if err != nil {
+		return err
+	}
+	if err := cr.Header().Check(container.AlgRabin, container.Fingerprint(N)); err != nil {
+		return err
+	}
+
+	size := BlockSize(N)
+	var wrongKey atomic.Bool
+	pw := stream.NewPadded(w)
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		e := new(big.Int).SetBytes(b)
+		if e.Cmp(N) >= 0 {
+			wrongKey.Store(true)
+			return make([]byte, size), nil
+		}
+		roots, err := Decrypt(e, p, q)
+		var data []byte
+		if err == nil {
+			data = decodeBlock(roots, size)
+		}
+		if data == nil {
+			wrongKey.Store(true)
+			return make([]byte, size), nil
+		}
+		return data, nil
+	}, pw.Emit, 0)
+	if err != nil {
+		return err
+	}
+
+	if wrongKey.Load() {
+		return container.ErrWrongKey
+	}
+	return pw.Finish(cr.Len())
+}
+
+// Signature is a Rabin-Williams signature: E*F*S^2 = H(m) mod N with the
+// tweaks E in {1, -1} and F in {1, 2} making H(m) a square.
+type Signature struct {
+	E int
+	F int
+	S *big.Int
+}
+
+var ErrVerification = errors.New("rabin: verification error")
+
+// hashToInt is a full-domain hash: SHA-256 in counter mode, one byte
+// shorter than N.
+func hashToInt(msg []byte, N *big.Int) *big.Int {
+	size := crypto.ByteLen(N) - 1
+	digest := sha256.Sum256(msg)
+	out := make([]byte, 0, size+sha256.Size)
+	var counter [4]byte
+	for i := uint32(0); len(out) < size; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h := sha256.New()
+		h.Write(counter[:])
+		h.Write(digest[:])
+		out = h.Sum(out)
+	}
+	return new(big.Int).SetBytes(out[:size])
+}
+
+// Sign always returns the same root for a message: two different roots
+// of one value would reveal a factor of N.
+func Sign(msg []byte, k Keys) (Signature, error) {
+	h := hashToInt(msg, k.N)
+
+	// -1 is a non-square modulo both primes, 2 only modulo P
+	sig := Signature{E: 1, F: 1}
+	if crypto.Jacobi(h, k.Q) == -1 {
+		sig.E = -1
+	}
+	v := new(big.Int).Mul(h, big.NewInt(int64(sig.E)))
+	if crypto.Jacobi(v, k.P) == -1 {
+		sig.F = 2
+	}
+	// S^2 = E*h/F = E*F*h/F^2
+	v.Mul(v, big.NewInt(int64(sig.F)))
+	v.Mod(v, k.N)
+	if sig.F == 2 {
+		v.Mul(v, new(big.Int).ModInverse(big.NewInt(4), k.N))
+		v.Mod(v, k.N)
+	}
+
+	roots, err := Decrypt(v, k.P, k.Q)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig.S = roots[0]
+	if err := Verify(msg, sig, k.N); err != nil {
+		return Signature{}, errors.New("rabin: signing failure")
+	}
+	return sig, nil
+}
+
+func Verify(msg []byte, sig Signature, N *big.Int) error {
+	if (sig.E != 1 && sig.E != -1) || (sig.F != 1 && sig.F != 2) || sig.S == nil || sig.S.Cmp(N) >= 0 {
+		return ErrVerification
+	}
+	v := new(big.Int).Mul(sig.S, sig.S)
+	v.Mul(v, big.NewInt(int64(sig.E*sig.F)))
+	v.Mod(v, N)
+	if v.Cmp(hashToInt(msg, N)) != 0 {
+		return ErrVerification
+	}
+	return nil
+}
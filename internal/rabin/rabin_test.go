@@ -0,0 +1,83 @@
+package rabin
+
+import (
+	"bytes"
+	"information-defending/internal/container"
+	"math/big"
+	"testing"
+)
+
+func TestDecryptRoots(t *testing.T) {
+	k, err := GenerateKeys(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := big.NewInt(987654321)
+	roots, err := Decrypt(Encrypt(m, k.N), k.P, k.Q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range roots {
+		if Encrypt(r, k.N).Cmp(Encrypt(m, k.N)) != 0 {
+			t.Fatalf("%s is not a square root", r)
+		}
+		found = found || r.Cmp(m) == 0
+	}
+	if !found {
+		t.Fatal("m is not among the roots")
+	}
+}
+
+func TestStream(t *testing.T) {
+	k, err := GenerateKeys(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := BlockSize(k.N)
+	for _, n := range []int{0, 1, size, size + 1, 5*size - 3} {
+		msg := bytes.Repeat([]byte{0, 'r'}, n)[:n]
+		var ct, out bytes.Buffer
+		if err := EncryptStream(bytes.NewReader(msg), &ct, k.N); err != nil {
+			t.Fatal(err)
+		}
+		if err := DecryptStream(bytes.NewReader(ct.Bytes()), &out, k.P, k.Q); err != nil || !bytes.Equal(out.Bytes(), msg) {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+	}
+
+	other, err := GenerateKeys(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ct, out bytes.Buffer
+	if err := EncryptStream(bytes.NewReader([]byte("secret")), &ct, k.N); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptStream(bytes.NewReader(ct.Bytes()), &out, other.P, other.Q); err != container.ErrWrongKey {
+		t.Fatalf("wrong key: %v", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	k, err := GenerateKeys(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b", "c", "d", "e", "f"} {
+		sig, err := Sign([]byte(msg), k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify([]byte(msg), sig, k.N); err != nil {
+			t.Fatalf("%q: %v", msg, err)
+		}
+		if err := Verify([]byte(msg+"!"), sig, k.N); err != ErrVerification {
+			t.Fatalf("%q: other message: %v", msg, err)
+		}
+		again, _ := Sign([]byte(msg), k)
+		if again.S.Cmp(sig.S) != 0 {
+			t.Fatalf("%q: two different roots", msg)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"information-defending/internal/crypto"
+	"math/big"
+)
+
+// Chaum's blind signature: the requester sends m * r^d, the signer raises it
+// to c, and dividing by r leaves m^c, an ordinary signature on m.
+
+func Blind(m, d, N *big.Int) (*big.Int, *big.Int, error) {
+	if m.Sign() <= 0 || m.Cmp(N) >= 0 {
+		return nil, nil, errors.New("rsa: message must be in [1, N)")
+	}
+	r, err := randomUnit(N)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinded := new(big.Int).Exp(r, d, N)
+	blinded.Mul(blinded, m)
+	blinded.Mod(blinded, N)
+	return blinded, r, nil
+}
+
+func BlindSign(blinded, c, N *big.Int) *big.Int {
+	return Decrypt(blinded, c, N)
+}
+
+func Unblind(s, r, N *big.Int) *big.Int {
+	rInv := new(big.Int).ModInverse(r, N)
+	if rInv == nil {
+		return nil
+	}
+	sig := new(big.Int).Mul(s, rInv)
+	return sig.Mod(sig, N)
+}
+
+func randomUnit(N *big.Int) (*big.Int, error) {
+	one := big.NewInt(1)
+	for {
+		r, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			return nil, err
+		}
+		if r.Cmp(one) > 0 && new(big.Int).GCD(nil, nil, r, N).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
+
+// BlindVariant is one of the RSABSSA variants from RFC 9474. All of them use
+// SHA-384 with MGF1-SHA-384.
+type BlindVariant struct {
+	SaltLength int
+	Randomized bool
+}
+
+var (
+	RSABSSASHA384PSSRandomized        = BlindVariant{SaltLength: sha512.Size384, Randomized: true}
+	RSABSSASHA384PSSZERORandomized    = BlindVariant{SaltLength: 0, Randomized: true}
+	RSABSSASHA384PSSDeterministic     = BlindVariant{SaltLength: sha512.Size384, Randomized: false}
+	RSABSSASHA384PSSZERODeterministic = BlindVariant{SaltLength: 0, Randomized: false}
+)
+
+var ErrVerification = errors.New("rsa: verification error")
+
+// Prepare prepends a random 32-byte prefix for the randomized variants. The
+// result is the message that is blinded, finalized and verified.
+func (v BlindVariant) Prepare(msg []byte) ([]byte, error) {
+	if !v.Randomized {
+		return msg, nil
+	}
+	prefix := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	return append(prefix, msg...), nil
+}
+
+func (v BlindVariant) Blind(msg []byte, d, N *big.Int) ([]byte, *big.Int, error) {
+	encoded, err := emsaPSSEncode(msg, N.BitLen()-1, v.SaltLength, sha512.New384())
+	if err != nil {
+		return nil, nil, err
+	}
+	m := new(big.Int).SetBytes(encoded)
+	if new(big.Int).GCD(nil, nil, m, N).Cmp(big.NewInt(1)) != 0 {
+		return nil, nil, errors.New("rsa: invalid input")
+	}
+
+	r, err := randomUnit(N)
+	if err != nil {
+		return nil, nil, err
+	}
+	z := new(big.Int).Exp(r, d, N)
+	z.Mul(z, m)
+	z.Mod(z, N)
+
+	inv := new(big.Int).ModInverse(r, N)
+	return z.FillBytes(make([]byte, crypto.ByteLen(N))), inv, nil
+}
+
+// BlindSign checks its own result so that a fault cannot leak the key.
+func (v BlindVariant) BlindSign(blinded []byte, k Keys) ([]byte, error) {
+	if len(blinded) != crypto.ByteLen(k.N) {
+		return nil, errors.New("rsa: unexpected input size")
+	}
+	m := new(big.Int).SetBytes(blinded)
+	if m.Cmp(k.N) >= 0 {
+		return nil, errors.New("rsa: message representative out of range")
+	}
+	s := Decrypt(m, k.C, k.N)
+	if Encrypt(s, k.D, k.N).Cmp(m) != 0 {
+		return nil, errors.New("rsa: signing failure")
+	}
+	return s.FillBytes(make([]byte, crypto.ByteLen(k.N))), nil
+}
+
+func (v BlindVariant) Finalize(msg, blindSig []byte, inv, d, N *big.Int) ([]byte, error) {
+	if len(blindSig) != crypto.ByteLen(N) {
+		return nil, errors.New("rsa: unexpected input size")
+	}
+	s := new(big.Int).SetBytes(blindSig)
+	s.Mul(s, inv)
+	s.Mod(s, N)
+	sig := s.FillBytes(make([]byte, crypto.ByteLen(N)))
+	if err := v.Verify(msg, sig, d, N); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Verify is RSASSA-PSS-VERIFY, so finalized signatures also verify with
+// crypto/rsa.VerifyPSS when the exponent is small.
+func (v BlindVariant) Verify(msg, sig []byte, d, N *big.Int) error {
+	if len(sig) != crypto.ByteLen(N) {
+		return ErrVerification
+	}
+	s := new(big.Int).SetBytes(sig)
+	m := Encrypt(s, d, N)
+	if m == nil {
+		return ErrVerification
+	}
+	emBits := N.BitLen() - 1
+	em := m.FillBytes(make([]byte, (emBits+7)/8))
+	return emsaPSSVerify(msg, em, emBits, v.SaltLength, sha512.New384())
+}
+
+// EMSA-PSS-ENCODE, RFC 8017 section 9.1.1.
+func emsaPSSEncode(msg []byte, emBits, sLen int, h hash.Hash) ([]byte, error) {
+	hLen := h.Size()
+	emLen := (emBits + 7) / 8
+	if emLen < hLen+sLen+2 {
+		return nil, errors.New("rsa: encoding error, modulus too small")
+	}
+
+	h.Reset()
+	h.Write(msg)
+	mHash := h.Sum(nil)
+
+	salt := make([]byte, sLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	h.Reset()
+	h.Write(make([]byte, 8))
+	h.Write(mHash)
+	h.Write(salt)
+	H := h.Sum(nil)
+
+	// DB = PS || 0x01 || salt
+	db := make([]byte, emLen-hLen-1)
+	db[emLen-sLen-hLen-2] = 0x01
+	copy(db[emLen-sLen-hLen-1:], salt)
+	mgf1XOR(db, h, H)
+	db[0] &= 0xFF >> (8*emLen - emBits)
+
+	em := append(db, H...)
+	return append(em, 0xBC), nil
+}
+
+// EMSA-PSS-VERIFY, RFC 8017 section 9.1.2.
+func emsaPSSVerify(msg, em []byte, emBits, sLen int, h hash.Hash) error {
+	hLen := h.Size()
+	emLen := (emBits + 7) / 8
+	if len(em) != emLen || emLen < hLen+sLen+2 || em[emLen-1] != 0xBC {
+		return ErrVerification
+	}
+
+	h.Reset()
+	h.Write(msg)
+	mHash := h.Sum(nil)
+
+	db := append([]byte(nil), em[:emLen-hLen-1]...)
+	H := em[emLen-hLen-1 : emLen-1]
+	mask := byte(0xFF >> (8*emLen - emBits))
+	if db[0]&^mask != 0 {
+		return ErrVerification
+	}
+	mgf1XOR(db, h, H)
+	db[0] &= mask
+
+	ps := db[:emLen-hLen-sLen-2]
+	if !bytes.Equal(ps, make([]byte, len(ps))) || db[emLen-hLen-sLen-2] != 0x01 {
+		return ErrVerification
+	}
+	salt := db[len(db)-sLen:]
+
+	h.Reset()
+	h.Write(make([]byte, 8))
+	h.Write(mHash)
+	h.Write(salt)
+	if subtle.ConstantTimeCompare(h.Sum(nil), H) != 1 {
+		return ErrVerification
+	}
+	return nil
+}
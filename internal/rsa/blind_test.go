@@ -0,0 +1,69 @@
+package rsa
+
+import (
+	"crypto"
+	stdrsa "crypto/rsa"
+	"crypto/sha512"
+	"math/big"
+	"testing"
+)
+
+func TestChaumBlind(t *testing.T) {
+	keys := testKeys(t)
+	m := big.NewInt(123456789)
+	blinded, r, err := Blind(m, keys.D, keys.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := Unblind(BlindSign(blinded, keys.C, keys.N), r, keys.N)
+	if sig.Cmp(Decrypt(m, keys.C, keys.N)) != 0 {
+		t.Fatal("unblinded signature differs from m^c")
+	}
+}
+
+func TestBlindVariants(t *testing.T) {
+	keys, err := GenerateKeysWithExponent(2048, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := &stdrsa.PublicKey{N: keys.N, E: int(keys.D.Int64())}
+	variants := []BlindVariant{
+		RSABSSASHA384PSSRandomized,
+		RSABSSASHA384PSSZERORandomized,
+		RSABSSASHA384PSSDeterministic,
+		RSABSSASHA384PSSZERODeterministic,
+	}
+	for _, v := range variants {
+		msg, err := v.Prepare([]byte("ballot"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		blinded, inv, err := v.Blind(msg, keys.D, keys.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blindSig, err := v.BlindSign(blinded, keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := v.Finalize(msg, blindSig, inv, keys.D, keys.N)
+		if err != nil {
+			t.Fatalf("%+v: %v", v, err)
+		}
+
+		if err := v.Verify(msg, sig, keys.D, keys.N); err != nil {
+			t.Fatalf("%+v: %v", v, err)
+		}
+		digest := sha512.Sum384(msg)
+		if err := stdrsa.VerifyPSS(pub, crypto.SHA384, digest[:], sig, &stdrsa.PSSOptions{SaltLength: stdrsa.PSSSaltLengthAuto}); err != nil {
+			t.Fatalf("%+v: crypto/rsa: %v", v, err)
+		}
+		if err := v.Verify(append(msg, '!'), sig, keys.D, keys.N); err != ErrVerification {
+			t.Fatalf("%+v: other message: %v", v, err)
+		}
+		sig[len(sig)-1] ^= 1
+		if err := v.Verify(msg, sig, keys.D, keys.N); err != ErrVerification {
+			t.Fatalf("%+v: modified signature: %v", v, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package rsa
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// ReadPublicKeyFile reads a demo8 .pub file: either N and D on two lines,
+// or a PEM public key.
+func ReadPublicKeyFile(filename string) (Keys, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Keys{}, err
+	}
+
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return DecodePEM(data)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return Keys{}, fmt.Errorf("%s: expected N and D, got %d values", filename, len(fields))
+	}
+	N, ok := new(big.Int).SetString(fields[0], 10)
+	if !ok {
+		return Keys{}, fmt.Errorf("%s: error while reading N", filename)
+	}
+	D, ok := new(big.Int).SetString(fields[1], 10)
+	if !ok {
+		return Keys{}, fmt.Errorf("%s: error while reading D", filename)
+	}
+	return Keys{D: D, N: N}, nil
+}
+
+func WritePublicKeyFile(filename string, k Keys) error {
+	return os.WriteFile(filename, fmt.Appendf(nil, "%s\n%s", k.N, k.D), 0644)
+}
+
+// ReadPrivateKeyFile reads a demo8 .priv file: either N and C on two lines,
+// or a PEM private key. Text files carry no public exponent.
+func ReadPrivateKeyFile(filename string) (Keys, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Keys{}, err
+	}
+
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		k, err := DecodePEM(data)
+		if err == nil && k.C == nil {
+			return Keys{}, fmt.Errorf("%s contains a public key", filename)
+		}
+		return k, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return Keys{}, fmt.Errorf("%s: expected N and C, got %d values", filename, len(fields))
+	}
+	N, ok := new(big.Int).SetString(fields[0], 10)
+	if !ok {
+		return Keys{}, fmt.Errorf("%s: error while reading N", filename)
+	}
+	C, ok := new(big.Int).SetString(fields[1], 10)
+	if !ok {
+		return Keys{}, fmt.Errorf("%s: error while reading C", filename)
+	}
+	return Keys{C: C, N: N}, nil
+}
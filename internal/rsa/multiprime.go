@@ -0,0 +1,109 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// GenerateMultiPrimeKeys generates keys whose modulus is a product of
+// nprimes distinct primes (RFC 8017 section 3). Decryption with
+// Precompute gets faster as nprimes grows.
+func GenerateMultiPrimeKeys(bits, nprimes int, d int64) (Keys, error) {
+	if nprimes < 2 {
+		return Keys{}, errors.New("rsa: at least two primes are required")
+	}
+	if bits/nprimes < 64 {
+		return Keys{}, errors.New("rsa: too many primes for the modulus size")
+	}
+
+	D := big.NewInt(d)
+	one := big.NewInt(1)
+next:
+	for {
+		primes := make([]*big.Int, nprimes)
+		N := big.NewInt(1)
+		phi := big.NewInt(1)
+		todo := bits
+		for i := range primes {
+			// the last prime takes what the others leave over
+			size := todo / (nprimes - i)
+			p, err := rand.Prime(rand.Reader, size)
+			if err != nil {
+				return Keys{}, err
+			}
+			for _, q := range primes[:i] {
+				if p.Cmp(q) == 0 {
+					continue next
+				}
+			}
+			primes[i] = p
+			todo -= size
+			N.Mul(N, p)
+			phi.Mul(phi, new(big.Int).Sub(p, one))
+		}
+		if N.BitLen() != bits {
+			continue
+		}
+
+		c := new(big.Int).ModInverse(D, phi)
+		if c == nil || HasSmallPrivateExponent(Keys{C: c, D: D, N: N}) {
+			continue
+		}
+		return Keys{C: c, D: D, N: N, Primes: primes}, nil
+	}
+}
+
+// Precomputed holds the per-prime values for CRT decryption: c mod (r_i - 1)
+// and the Garner coefficients (r_1 * ... * r_(i-1))^-1 mod r_i.
+type Precomputed struct {
+	N      *big.Int
+	Primes []*big.Int
+	Exps   []*big.Int
+	Coeffs []*big.Int
+}
+
+func (k Keys) Precompute() (*Precomputed, error) {
+	primes, err := k.primes()
+	if err != nil {
+		return nil, err
+	}
+
+	one := big.NewInt(1)
+	pc := &Precomputed{N: k.N, Primes: primes}
+	r := big.NewInt(1)
+	for _, p := range primes {
+		pc.Exps = append(pc.Exps, new(big.Int).Mod(k.C, new(big.Int).Sub(p, one)))
+		coeff := new(big.Int).ModInverse(r, p)
+		if coeff == nil {
+			return nil, errors.New("rsa: primes are not distinct")
+		}
+		pc.Coeffs = append(pc.Coeffs, coeff)
+		r = new(big.Int).Mul(r, p)
+	}
+	return pc, nil
+}
+
+// Decrypt is Decrypt(e, c, N) computed as one exponentiation per prime with
+// exponents and moduli of 1/len(Primes) the size, recombined by Garner's
+// formula.
+func (pc *Precomputed) Decrypt(e *big.Int) *big.Int {
+	if e.Cmp(pc.N) != -1 {
+		return nil
+	}
+
+	m := new(big.Int)
+	r := big.NewInt(1)
+	h := new(big.Int)
+	for i, p := range pc.Primes {
+		mi := new(big.Int).Exp(e, pc.Exps[i], p)
+
+		// m += r * ((m_i - m) * coeff_i mod p_i)
+		h.Sub(mi, m)
+		h.Mul(h, pc.Coeffs[i])
+		h.Mod(h, p)
+		m.Add(m, h.Mul(h, r))
+		r.Mul(r, p)
+	}
+	return m
+}
@@ -0,0 +1,66 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestDecryptCRT(t *testing.T) {
+	for n := 2; n <= 4; n++ {
+		keys, err := GenerateMultiPrimeKeys(1024, n, 65537)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crt, err := keys.Precompute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 0, 1, a multiple of the first prime and random values
+		ys := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Mul(keys.Primes[0], big.NewInt(3))}
+		for range 20 {
+			y, err := rand.Int(rand.Reader, keys.N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ys = append(ys, y)
+		}
+		for _, y := range ys {
+			want := new(big.Int).Exp(y, keys.C, keys.N)
+			if got := crt.Decrypt(y); got.Cmp(want) != 0 {
+				t.Fatalf("%d primes, y = %s: got %s, want %s", n, y, got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkDecrypt compares plain decryption with CRT decryption for keys
+// of two to four primes.
+func BenchmarkDecrypt(b *testing.B) {
+	y := new(big.Int).Lsh(big.NewInt(1), 255)
+	for n := 2; n <= 4; n++ {
+		keys, err := GenerateMultiPrimeKeys(2048, n, 65537)
+		if err != nil {
+			b.Fatal(err)
+		}
+		crt, err := keys.Precompute()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if crt.Decrypt(y).Cmp(Decrypt(y, keys.C, keys.N)) != 0 {
+			b.Fatalf("CRT result differs for %d primes", n)
+		}
+
+		b.Run(fmt.Sprintf("primes=%d/plain", n), func(b *testing.B) {
+			for b.Loop() {
+				Decrypt(y, keys.C, keys.N)
+			}
+		})
+		b.Run(fmt.Sprintf("primes=%d/crt", n), func(b *testing.B) {
+			for b.Loop() {
+				crt.Decrypt(y)
+			}
+		})
+	}
+}
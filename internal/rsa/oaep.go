@@ -0,0 +1,112 @@
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"information-defending/internal/crypto"
+	"math/big"
+)
+
+// RSAES-OAEP, RFC 8017 section 7.1, with SHA-256 for both the hash and
+// MGF1. Unlike Encrypt it is randomized and padded, so a short message
+// under a small d is no longer a small integer to take roots of.
+
+var (
+	ErrMessageTooLong = errors.New("rsa: message too long for OAEP with this modulus")
+	// ErrDecryption is the only error DecryptOAEP gives for a bad
+	// ciphertext, so its failures tell an attacker nothing about why.
+	ErrDecryption = errors.New("rsa: OAEP decryption error")
+)
+
+// MaxOAEPMessage is the longest message EncryptOAEP takes under N.
+func MaxOAEPMessage(N *big.Int) int {
+	return crypto.ByteLen(N) - 2*sha256.Size - 2
+}
+
+func EncryptOAEP(msg, label []byte, d, N *big.Int) ([]byte, error) {
+	k := crypto.ByteLen(N)
+	if len(msg) > MaxOAEPMessage(N) {
+		return nil, ErrMessageTooLong
+	}
+	h := sha256.New()
+	hLen := h.Size()
+
+	// EM = 0x00 || maskedSeed || maskedDB, DB = lHash || PS || 0x01 || M
+	em := make([]byte, k)
+	seed, db := em[1:1+hLen], em[1+hLen:]
+	h.Write(label)
+	h.Sum(db[:0])
+	db[len(db)-len(msg)-1] = 1
+	copy(db[len(db)-len(msg):], msg)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	mgf1XOR(db, h, seed)
+	mgf1XOR(seed, h, db)
+
+	c := Encrypt(new(big.Int).SetBytes(em), d, N)
+	if c == nil {
+		return nil, ErrMessageTooLong
+	}
+	return c.FillBytes(make([]byte, k)), nil
+}
+
+func DecryptOAEP(ciphertext, label []byte, c, N *big.Int) ([]byte, error) {
+	k := crypto.ByteLen(N)
+	h := sha256.New()
+	hLen := h.Size()
+	if len(ciphertext) != k || k < 2*hLen+2 {
+		return nil, ErrDecryption
+	}
+	m := Decrypt(new(big.Int).SetBytes(ciphertext), c, N)
+	if m == nil {
+		return nil, ErrDecryption
+	}
+	em := m.FillBytes(make([]byte, k))
+
+	seed, db := em[1:1+hLen], em[1+hLen:]
+	mgf1XOR(seed, h, db)
+	mgf1XOR(db, h, seed)
+
+	h.Reset()
+	h.Write(label)
+	good := subtle.ConstantTimeCompare(h.Sum(nil), db[:hLen])
+	good &= subtle.ConstantTimeByteEq(em[0], 0)
+
+	// find the 0x01 after PS without branching on the data
+	rest := db[hLen:]
+	lookingForIndex, index, invalid := 1, 0, 0
+	for i, b := range rest {
+		equals0 := subtle.ConstantTimeByteEq(b, 0)
+		equals1 := subtle.ConstantTimeByteEq(b, 1)
+		index = subtle.ConstantTimeSelect(lookingForIndex&equals1, i, index)
+		lookingForIndex = subtle.ConstantTimeSelect(equals1, 0, lookingForIndex)
+		invalid = subtle.ConstantTimeSelect(lookingForIndex&^equals0, 1, invalid)
+	}
+	if good&^invalid&^lookingForIndex != 1 {
+		return nil, ErrDecryption
+	}
+	return rest[index+1:], nil
+}
+
+// mgf1XOR xors out with MGF1(seed), RFC 8017 appendix B.2.1.
+func mgf1XOR(out []byte, h hash.Hash, seed []byte) {
+	var counter [4]byte
+	var digest []byte
+	done := 0
+	for i := uint32(0); done < len(out); i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter[:])
+		digest = h.Sum(digest[:0])
+		for j := 0; j < len(digest) && done < len(out); j++ {
+			out[done] ^= digest[j]
+			done++
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/sha256"
+	"testing"
+)
+
+func testKeys(t *testing.T) Keys {
+	t.Helper()
+	keys, err := GenerateKeysWithExponent(1024, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestOAEPRoundTrip(t *testing.T) {
+	keys := testKeys(t)
+	for _, n := range []int{0, 1, 32, MaxOAEPMessage(keys.N)} {
+		msg := bytes.Repeat([]byte{0xA5}, n)
+		ct, err := EncryptOAEP(msg, []byte("label"), keys.D, keys.N)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		got, err := DecryptOAEP(ct, []byte("label"), keys.C, keys.N)
+		if err != nil || !bytes.Equal(got, msg) {
+			t.Fatalf("%d bytes: got %x, %v", n, got, err)
+		}
+	}
+}
+
+func TestOAEPRejects(t *testing.T) {
+	keys := testKeys(t)
+	if _, err := EncryptOAEP(make([]byte, MaxOAEPMessage(keys.N)+1), nil, keys.D, keys.N); err != ErrMessageTooLong {
+		t.Fatalf("long message: %v", err)
+	}
+
+	ct, err := EncryptOAEP([]byte("secret"), nil, keys.D, keys.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptOAEP(ct, []byte("other"), keys.C, keys.N); err != ErrDecryption {
+		t.Fatalf("wrong label: %v", err)
+	}
+	ct[len(ct)-1] ^= 1
+	if _, err := DecryptOAEP(ct, nil, keys.C, keys.N); err != ErrDecryption {
+		t.Fatalf("modified ciphertext: %v", err)
+	}
+}
+
+// TestOAEPCompatible checks both directions against crypto/rsa.
+func TestOAEPCompatible(t *testing.T) {
+	keys := testKeys(t)
+	std := &stdrsa.PrivateKey{
+		PublicKey: stdrsa.PublicKey{N: keys.N, E: int(keys.D.Int64())},
+		D:         keys.C,
+		Primes:    keys.Primes,
+	}
+	msg := []byte("session key")
+
+	ct, err := EncryptOAEP(msg, nil, keys.D, keys.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := stdrsa.DecryptOAEP(sha256.New(), nil, std, ct, nil)
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Fatalf("crypto/rsa decrypt: %q, %v", got, err)
+	}
+
+	ct, err = stdrsa.EncryptOAEP(sha256.New(), rand.Reader, &std.PublicKey, msg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = DecryptOAEP(ct, nil, keys.C, keys.N)
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Fatalf("decrypt crypto/rsa output: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,367 @@
+package rsa
+
+import (
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// The encodings are written with encoding/asn1 rather than crypto/x509
+// because GenerateKeys picks a 1024-bit public exponent, which does not fit
+// into crypto/rsa.PublicKey.E. Keys with a small exponent round-trip through
+// crypto/x509 unchanged.
+
+type Format int
+
+const (
+	FormatPKCS1 Format = iota // RSA PRIVATE KEY / RSA PUBLIC KEY
+	FormatPKCS8               // PRIVATE KEY / PUBLIC KEY (SubjectPublicKeyInfo)
+)
+
+var oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+
+type pkcs1PrivateKey struct {
+	Version int
+	N       *big.Int
+	E       *big.Int
+	D       *big.Int
+	P       *big.Int
+	Q       *big.Int
+	Dp      *big.Int
+	Dq      *big.Int
+	Qinv    *big.Int
+
+	AdditionalPrimes []pkcs1AdditionalPrime `asn1:"optional,omitempty"`
+}
+
+type pkcs1AdditionalPrime struct {
+	Prime *big.Int
+	Exp   *big.Int
+	Coeff *big.Int
+}
+
+type pkcs1PublicKey struct {
+	N *big.Int
+	E *big.Int
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+func rsaAlgorithm() pkix.AlgorithmIdentifier {
+	return pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}
+}
+
+// RecoverPrimes factors N from the public and private exponents
+// (RFC 8017 does not require it, NIST SP 800-56B appendix C does).
+func RecoverPrimes(C, D, N *big.Int) ([]*big.Int, error) {
+	one := big.NewInt(1)
+	nMinus1 := new(big.Int).Sub(N, one)
+
+	// c*d - 1 = 2^t * r, r odd
+	k := new(big.Int).Mul(C, D)
+	k.Sub(k, one)
+	if k.Sign() <= 0 || k.Bit(0) != 0 {
+		return nil, errors.New("rsa: c*d - 1 must be even and positive")
+	}
+	t := k.TrailingZeroBits()
+	r := new(big.Int).Rsh(k, t)
+
+	for range 100 {
+		g, err := rand.Int(rand.Reader, new(big.Int).Sub(N, big.NewInt(3)))
+		if err != nil {
+			return nil, err
+		}
+		g.Add(g, big.NewInt(2))
+
+		x := new(big.Int).Exp(g, r, N)
+		if x.Cmp(one) == 0 || x.Cmp(nMinus1) == 0 {
+			continue
+		}
+		for range t {
+			y := new(big.Int).Exp(x, big.NewInt(2), N)
+			if y.Cmp(one) == 0 {
+				p := new(big.Int).GCD(nil, nil, new(big.Int).Sub(x, one), N)
+				q := new(big.Int).Div(N, p)
+				return []*big.Int{p, q}, nil
+			}
+			if y.Cmp(nMinus1) == 0 {
+				break
+			}
+			x = y
+		}
+	}
+	return nil, errors.New("rsa: cannot factor N, the exponents do not match")
+}
+
+func (k Keys) primes() ([]*big.Int, error) {
+	if len(k.Primes) >= 2 {
+		return k.Primes, nil
+	}
+	if k.C == nil || k.D == nil {
+		return nil, errors.New("rsa: both exponents are needed to recover the primes")
+	}
+	return RecoverPrimes(k.C, k.D, k.N)
+}
+
+func (k Keys) crtValues(primes []*big.Int) (dp, dq, qinv *big.Int, extra []pkcs1AdditionalPrime) {
+	one := big.NewInt(1)
+	exp := func(p *big.Int) *big.Int {
+		return new(big.Int).Mod(k.C, new(big.Int).Sub(p, one))
+	}
+
+	p, q := primes[0], primes[1]
+	dp, dq = exp(p), exp(q)
+	qinv = new(big.Int).ModInverse(q, p)
+
+	// r_i * coeff_i = 1 mod prime_i, r_i = product of the previous primes
+	r := new(big.Int).Mul(p, q)
+	for _, prime := range primes[2:] {
+		extra = append(extra, pkcs1AdditionalPrime{
+			Prime: prime,
+			Exp:   exp(prime),
+			Coeff: new(big.Int).ModInverse(r, prime),
+		})
+		r = new(big.Int).Mul(r, prime)
+	}
+	return dp, dq, qinv, extra
+}
+
+func MarshalPKCS1PrivateKey(k Keys) ([]byte, error) {
+	primes, err := k.primes()
+	if err != nil {
+		return nil, err
+	}
+	dp, dq, qinv, extra := k.crtValues(primes)
+
+	version := 0
+	if len(extra) > 0 {
+		version = 1
+	}
+	return asn1.Marshal(pkcs1PrivateKey{
+		Version:          version,
+		N:                k.N,
+		E:                k.D,
+		D:                k.C,
+		P:                primes[0],
+		Q:                primes[1],
+		Dp:               dp,
+		Dq:               dq,
+		Qinv:             qinv,
+		AdditionalPrimes: extra,
+	})
+}
+
+func ParsePKCS1PrivateKey(der []byte) (Keys, error) {
+	var priv pkcs1PrivateKey
+	rest, err := asn1.Unmarshal(der, &priv)
+	if err != nil {
+		return Keys{}, fmt.Errorf("rsa: parsing PKCS#1 private key: %w", err)
+	}
+	if len(rest) > 0 {
+		return Keys{}, errors.New("rsa: trailing data after PKCS#1 private key")
+	}
+	if priv.Version > 1 {
+		return Keys{}, fmt.Errorf("rsa: unsupported PKCS#1 version %d", priv.Version)
+	}
+
+	primes := []*big.Int{priv.P, priv.Q}
+	for _, a := range priv.AdditionalPrimes {
+		primes = append(primes, a.Prime)
+	}
+
+	k := Keys{C: priv.D, D: priv.E, N: priv.N, Primes: primes}
+	return k, k.validate()
+}
+
+func MarshalPKCS1PublicKey(k Keys) ([]byte, error) {
+	return asn1.Marshal(pkcs1PublicKey{N: k.N, E: k.D})
+}
+
+func ParsePKCS1PublicKey(der []byte) (Keys, error) {
+	var pub pkcs1PublicKey
+	rest, err := asn1.Unmarshal(der, &pub)
+	if err != nil {
+		return Keys{}, fmt.Errorf("rsa: parsing PKCS#1 public key: %w", err)
+	}
+	if len(rest) > 0 {
+		return Keys{}, errors.New("rsa: trailing data after PKCS#1 public key")
+	}
+	if pub.N.Sign() <= 0 || pub.E.Sign() <= 0 {
+		return Keys{}, errors.New("rsa: public key has a non-positive modulus or exponent")
+	}
+	return Keys{D: pub.E, N: pub.N}, nil
+}
+
+func MarshalPKCS8PrivateKey(k Keys) ([]byte, error) {
+	inner, err := MarshalPKCS1PrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8PrivateKey{Algo: rsaAlgorithm(), PrivateKey: inner})
+}
+
+func ParsePKCS8PrivateKey(der []byte) (Keys, error) {
+	var priv pkcs8PrivateKey
+	if _, err := asn1.Unmarshal(der, &priv); err != nil {
+		return Keys{}, fmt.Errorf("rsa: parsing PKCS#8 private key: %w", err)
+	}
+	if !priv.Algo.Algorithm.Equal(oidRSAEncryption) {
+		return Keys{}, fmt.Errorf("rsa: PKCS#8 key is not an RSA key (%s)", priv.Algo.Algorithm)
+	}
+	return ParsePKCS1PrivateKey(priv.PrivateKey)
+}
+
+func MarshalPKIXPublicKey(k Keys) ([]byte, error) {
+	inner, err := MarshalPKCS1PublicKey(k)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: rsaAlgorithm(),
+		PublicKey: asn1.BitString{Bytes: inner, BitLength: 8 * len(inner)},
+	})
+}
+
+func ParsePKIXPublicKey(der []byte) (Keys, error) {
+	var spki subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return Keys{}, fmt.Errorf("rsa: parsing SubjectPublicKeyInfo: %w", err)
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidRSAEncryption) {
+		return Keys{}, fmt.Errorf("rsa: public key is not an RSA key (%s)", spki.Algorithm.Algorithm)
+	}
+	return ParsePKCS1PublicKey(spki.PublicKey.RightAlign())
+}
+
+func EncodePrivateKeyPEM(k Keys, f Format) ([]byte, error) {
+	var der []byte
+	var err error
+	var blockType string
+	switch f {
+	case FormatPKCS1:
+		der, err = MarshalPKCS1PrivateKey(k)
+		blockType = "RSA PRIVATE KEY"
+	case FormatPKCS8:
+		der, err = MarshalPKCS8PrivateKey(k)
+		blockType = "PRIVATE KEY"
+	default:
+		return nil, fmt.Errorf("rsa: unknown key format %d", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
+}
+
+func EncodePublicKeyPEM(k Keys, f Format) ([]byte, error) {
+	var der []byte
+	var err error
+	var blockType string
+	switch f {
+	case FormatPKCS1:
+		der, err = MarshalPKCS1PublicKey(k)
+		blockType = "RSA PUBLIC KEY"
+	case FormatPKCS8:
+		der, err = MarshalPKIXPublicKey(k)
+		blockType = "PUBLIC KEY"
+	default:
+		return nil, fmt.Errorf("rsa: unknown key format %d", f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
+}
+
+// DecodePEM parses the first PEM block in data. Public keys come back
+// with C == nil.
+func DecodePEM(data []byte) (Keys, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Keys{}, errors.New("rsa: no PEM block found")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return ParsePKIXPublicKey(block.Bytes)
+	}
+	return Keys{}, fmt.Errorf("rsa: unsupported PEM block %q", block.Type)
+}
+
+func (k Keys) validate() error {
+	if k.N == nil || k.D == nil || k.C == nil || k.N.Sign() <= 0 {
+		return errors.New("rsa: incomplete key")
+	}
+	prod := big.NewInt(1)
+	for _, p := range k.Primes {
+		prod.Mul(prod, p)
+	}
+	if prod.Cmp(k.N) != 0 {
+		return errors.New("rsa: primes do not multiply to N")
+	}
+
+	// m^(c*d) = m mod N
+	m := big.NewInt(2)
+	if Decrypt(Encrypt(m, k.D, k.N), k.C, k.N).Cmp(m) != 0 {
+		return errors.New("rsa: exponents do not match")
+	}
+	return nil
+}
+
+// PublicKey converts to crypto/rsa. It fails for exponents that do not
+// fit into an int, such as those from GenerateKeys.
+func (k Keys) PublicKey() (*stdrsa.PublicKey, error) {
+	if !k.D.IsInt64() || k.D.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("rsa: %d-bit public exponent is too large for crypto/rsa", k.D.BitLen())
+	}
+	return &stdrsa.PublicKey{N: k.N, E: int(k.D.Int64())}, nil
+}
+
+func (k Keys) PrivateKey() (*stdrsa.PrivateKey, error) {
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	primes, err := k.primes()
+	if err != nil {
+		return nil, err
+	}
+	priv := &stdrsa.PrivateKey{PublicKey: *pub, D: k.C, Primes: primes}
+	if err := priv.Validate(); err != nil {
+		return nil, err
+	}
+	priv.Precompute()
+	return priv, nil
+}
+
+func FromPublicKey(pub *stdrsa.PublicKey) Keys {
+	return Keys{D: big.NewInt(int64(pub.E)), N: pub.N}
+}
+
+func FromPrivateKey(priv *stdrsa.PrivateKey) Keys {
+	return Keys{
+		C:      priv.D,
+		D:      big.NewInt(int64(priv.E)),
+		N:      priv.N,
+		Primes: priv.Primes,
+	}
+}
@@ -0,0 +1,115 @@
+package rsa
+
+import (
+	stdrsa "crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func sameKey(a, b Keys) bool {
+	if a.N.Cmp(b.N) != 0 || a.D.Cmp(b.D) != 0 || (a.C == nil) != (b.C == nil) {
+		return false
+	}
+	return a.C == nil || a.C.Cmp(b.C) == 0
+}
+
+func TestPEMRoundTrip(t *testing.T) {
+	for _, n := range []int{2, 3} {
+		keys, err := GenerateMultiPrimeKeys(1024, n, 65537)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range []Format{FormatPKCS1, FormatPKCS8} {
+			priv, err := EncodePrivateKeyPEM(keys, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecodePEM(priv)
+			if err != nil || !sameKey(got, keys) || len(got.Primes) != n {
+				t.Fatalf("%d primes, format %d: private key read back as %+v, %v", n, f, got, err)
+			}
+
+			pub, err := EncodePublicKeyPEM(keys, f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err = DecodePEM(pub)
+			if err != nil || !sameKey(got, Keys{D: keys.D, N: keys.N}) {
+				t.Fatalf("%d primes, format %d: public key read back as %+v, %v", n, f, got, err)
+			}
+		}
+	}
+}
+
+// TestPEMCompatible parses our encodings with crypto/x509 and back.
+func TestPEMCompatible(t *testing.T) {
+	keys := testKeys(t)
+
+	priv, _ := EncodePrivateKeyPEM(keys, FormatPKCS1)
+	block, _ := pem.Decode(priv)
+	std, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil || !sameKey(FromPrivateKey(std), keys) {
+		t.Fatalf("x509 PKCS#1: %v", err)
+	}
+	priv, _ = EncodePrivateKeyPEM(keys, FormatPKCS8)
+	block, _ = pem.Decode(priv)
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil || !sameKey(FromPrivateKey(k.(*stdrsa.PrivateKey)), keys) {
+		t.Fatalf("x509 PKCS#8: %v", err)
+	}
+	pub, _ := EncodePublicKeyPEM(keys, FormatPKCS8)
+	block, _ = pem.Decode(pub)
+	if k, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil || !sameKey(FromPublicKey(k.(*stdrsa.PublicKey)), Keys{D: keys.D, N: keys.N}) {
+		t.Fatalf("x509 SPKI: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(std)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ParsePKCS8PrivateKey(der); err != nil || !sameKey(got, keys) {
+		t.Fatalf("parse x509 PKCS#8: %v", err)
+	}
+}
+
+func TestRecoverPrimes(t *testing.T) {
+	keys := testKeys(t)
+	primes, err := RecoverPrimes(keys.C, keys.D, keys.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(primes) != 2 || new(big.Int).Mul(primes[0], primes[1]).Cmp(keys.N) != 0 {
+		t.Fatalf("got %v", primes)
+	}
+}
+
+func TestKeyFiles(t *testing.T) {
+	keys := testKeys(t)
+	dir := t.TempDir()
+
+	pub := filepath.Join(dir, "k.pub")
+	if err := WritePublicKeyFile(pub, keys); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadPublicKeyFile(pub); err != nil || !sameKey(got, Keys{D: keys.D, N: keys.N}) {
+		t.Fatalf("text public key: %v", err)
+	}
+
+	data, err := EncodePublicKeyPEM(keys, FormatPKCS8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemPub := filepath.Join(dir, "pem.pub")
+	if err := os.WriteFile(pemPub, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadPublicKeyFile(pemPub); err != nil || !sameKey(got, Keys{D: keys.D, N: keys.N}) {
+		t.Fatalf("PEM public key: %v", err)
+	}
+	if _, err := ReadPrivateKeyFile(pemPub); err == nil {
+		t.Fatal("public key read as a private key")
+	}
+}
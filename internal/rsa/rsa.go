@@ -1,17 +1,24 @@
 package rsa
 
 import (
+	"bufio"
 	"crypto/rand"
+	"fmt"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/stream"
+	"io"
 	"log"
 	"math/big"
-	"os"
-	"strings"
+	"sync/atomic"
 )
 
 type Keys struct {
 	C *big.Int
 	D *big.Int
 	N *big.Int
+
+	Primes []*big.Int // may be nil for keys loaded from text files
 }
 
 func GenerateKeys() Keys {
@@ -28,21 +35,44 @@ func GenerateKeys() Keys {
 		log.Fatalf("Something went wrong:%s", err.Error())
 	}
 	N := new(big.Int).Mul(P, Q)
-	phi := new(big.Int).Mul(P.Sub(P, big.NewInt(1)), Q.Sub(Q, big.NewInt(1)))
+	phi := new(big.Int).Mul(new(big.Int).Sub(P, big.NewInt(1)), new(big.Int).Sub(Q, big.NewInt(1)))
 	gcd := new(big.Int)
+	var c *big.Int
 	for {
 		gcd.GCD(nil, nil, d, phi)
 		if gcd.Cmp(big.NewInt(1)) == 0 {
-			break
+			c = new(big.Int).ModInverse(d, phi)
+			if !HasSmallPrivateExponent(Keys{C: c, D: d, N: N}) {
+				break
+			}
 		}
 		d, err = rand.Prime(rand.Reader, 1024)
 		if err != nil {
 			log.Fatalf("Something went wrong:%s", err.Error())
 		}
 	}
-	c := new(big.Int).ModInverse(d, phi)
 
-	return Keys{c, d, N}
+	return Keys{C: c, D: d, N: N, Primes: []*big.Int{P, Q}}
+}
+
+// GenerateKeysWithExponent generates keys with a fixed public exponent such
+// as 65537, which is what crypto/rsa and OpenSSL expect.
+func GenerateKeysWithExponent(bits int, d int64) (Keys, error) {
+	return GenerateMultiPrimeKeys(bits, 2, d)
+}
+
+// IsWienerVulnerable reports whether c < N^(1/4)/3, the bound under which
+// Wiener's attack recovers c from the public key alone.
+func IsWienerVulnerable(k Keys) bool {
+	c4 := new(big.Int).Exp(k.C, big.NewInt(4), nil)
+	return c4.Mul(c4, big.NewInt(81)).Cmp(k.N) < 0
+}
+
+// HasSmallPrivateExponent reports whether c < N^0.292. Boneh and Durfee
+// showed that lattice methods break such keys, so it is the bound keygen
+// enforces; it includes the Wiener range.
+func HasSmallPrivateExponent(k Keys) bool {
+	return k.C.BitLen()*1000 < k.N.BitLen()*292
 }
 
 func Encrypt(m, d, N *big.Int) *big.Int {
@@ -64,43 +94,94 @@ func Decrypt(e, c, N *big.Int) *big.Int {
 }
 
 func EncryptFile(inputFile, outputFile string, d, N *big.Int) error {
-	data, err := os.ReadFile(inputFile)
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptStream(r, w, d, N)
+	})
+}
+
+func DecryptFile(inputFile, outputFile string, c, N *big.Int) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptStream(r, w, c, N)
+	})
+}
+
+func EncryptStream(r io.Reader, w io.Writer, d, N *big.Int) error {
+	size := crypto.ByteLen(N)
+	cw, err := container.NewWriter(w, container.Header{
+		Algorithm:   container.AlgRSA,
+		Fingerprint: container.Fingerprint(N),
+		BlockSize:   uint32(size),
+	})
 	if err != nil {
 		return err
 	}
 
-	result := []*big.Int{}
+	var n uint64
+	err = stream.Map(stream.Blocks(r, 1), func(b []byte) ([]byte, error) {
+		e := Encrypt(big.NewInt(int64(b[0])), d, N)
+		return e.FillBytes(make([]byte, size)), nil
+	}, func(b []byte) error {
+		n++
+		return cw.WriteBlock(b)
+	}, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
 
-	for _, b := range data {
-		m := big.NewInt(int64(b))
-		e := Encrypt(m, d, N)
-		result = append(result, e)
+// DecryptStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func DecryptStream(r io.Reader, w io.Writer, c, N *big.Int) error {
+	br := bufio.NewReader(r)
+	if !container.Sniff(br) {
+		return decryptText(br, w, c, N)
 	}
 
-	out := ""
-	for _, val := range result {
-		out += val.String() + "\n"
+	cr, err := container.NewReader(br)
+	if err != nil {
+		return err
+	}
+	if err := cr.Header().Check(container.AlgRSA, container.Fingerprint(N)); err != nil {
+		return err
 	}
-	return os.WriteFile(outputFile, []byte(out), 0644)
-}
 
-func DecryptFile(inputFile, outputFile string, c, N *big.Int) error {
-	data, err := os.ReadFile(inputFile)
+	var wrongKey atomic.Bool
+	var n uint64
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		m := Decrypt(new(big.Int).SetBytes(b), c, N)
+		// keep reading so that corruption is reported before a wrong key
+		if m == nil || !m.IsInt64() || m.Int64() > 255 {
+			wrongKey.Store(true)
+			return nil, nil
+		}
+		return []byte{byte(m.Int64())}, nil
+	}, stream.Writer(w, &n), 0)
 	if err != nil {
 		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	var result []byte
+	if wrongKey.Load() {
+		return container.ErrWrongKey
+	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+// decryptText reads the old format: one decimal number per line.
+func decryptText(r io.Reader, w io.Writer, c, N *big.Int) error {
+	var n uint64
+	return stream.Map(stream.Lines(r), func(line []byte) ([]byte, error) {
+		e, err := crypto.ParseBigInt(string(line))
+		if err != nil {
+			return nil, err
 		}
-		e, _ := new(big.Int).SetString(line, 10)
 		m := Decrypt(e, c, N)
-		result = append(result, byte(m.Int64()))
-	}
-
-	return os.WriteFile(outputFile, result, 0644)
+		if m == nil {
+			return nil, fmt.Errorf("ciphertext is not less than N: %s", line)
+		}
+		return []byte{byte(m.Int64())}, nil
+	}, stream.Writer(w, &n), 0)
 }
@@ -0,0 +1,265 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"information-defending/internal/crypto"
+	"io"
+	"math/big"
+	"net"
+	"time"
+)
+
+// Wire protocol for the three-pass exchange between two processes. Every
+// message is a frame:
+//
+//	type[1] | length[4] | payload
+//
+// The sender opens with hello (size[8] | nameLen[2] | name | p), the
+// receiver accepts or rejects the prime, then every block goes
+// x1 -> x2 <- x3 -> and the sender closes with done (SHA-256 of the file),
+// which the receiver acknowledges with accept. Each side generates its own
+// exponents for p and never sends them. Nothing is authenticated, so the
+// protocol is open to a man in the middle like the original.
+const (
+	msgHello byte = iota + 1
+	msgAccept
+	msgReject
+	msgX1
+	msgX2
+	msgX3
+	msgDone
+
+	maxFrame = 1 << 20
+
+	DefaultTimeout = 30 * time.Second
+	DefaultMinBits = 512
+)
+
+var ErrRejected = errors.New("shamir: rejected by peer")
+
+type conn struct {
+	c       net.Conn
+	timeout time.Duration
+}
+
+func (c *conn) write(typ byte, payload []byte) error {
+	if err := c.c.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = typ
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	_, err := c.c.Write(append(frame, payload...))
+	return err
+}
+
+func (c *conn) read(want byte) ([]byte, error) {
+	if err := c.c.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	var hdr [5]byte
+	if _, err := io.ReadFull(c.c, hdr[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxFrame {
+		return nil, fmt.Errorf("shamir: frame of %d bytes is too large", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.c, payload); err != nil {
+		return nil, err
+	}
+	if hdr[0] == msgReject {
+		return nil, fmt.Errorf("%w: %s", ErrRejected, payload)
+	}
+	if hdr[0] != want {
+		return nil, fmt.Errorf("shamir: unexpected message %d, want %d", hdr[0], want)
+	}
+	return payload, nil
+}
+
+func (c *conn) reject(err error) error {
+	c.write(msgReject, []byte(err.Error()))
+	return err
+}
+
+// readValue reads an x_i and checks 1 < x < p-1: 0, 1 and p-1 are fixed
+// by exponentiation and would leak the block.
+func (c *conn) readValue(want byte, p *big.Int) (*big.Int, error) {
+	payload, err := c.read(want)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(payload)
+	if len(payload) != crypto.ByteLen(p) || x.Cmp(big.NewInt(1)) <= 0 || x.Cmp(new(big.Int).Sub(p, big.NewInt(1))) >= 0 {
+		return nil, c.reject(errors.New("shamir: value out of range"))
+	}
+	return x, nil
+}
+
+func (c *conn) writeValue(typ byte, x, p *big.Int) error {
+	return c.write(typ, x.FillBytes(make([]byte, crypto.ByteLen(p))))
+}
+
+// Sender transfers files with the prime P.
+type Sender struct {
+	P       *big.Int
+	Timeout time.Duration
+}
+
+func (s *Sender) Send(nc net.Conn, name string, r io.Reader, size int64) error {
+	c := &conn{c: nc, timeout: timeout(s.Timeout)}
+	p := s.P
+	alice, err := NewAlice(p)
+	if err != nil {
+		return err
+	}
+
+	hello := binary.BigEndian.AppendUint64(nil, uint64(size))
+	hello = binary.BigEndian.AppendUint16(hello, uint16(len(name)))
+	hello = append(hello, name...)
+	hello = append(hello, p.Bytes()...)
+	if err := c.write(msgHello, hello); err != nil {
+		return err
+	}
+	if _, err := c.read(msgAccept); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	k := ChunkSize(p)
+	buf := make([]byte, k)
+	for sent := int64(0); sent < size; {
+		n, err := io.ReadFull(r, buf[:min(int64(k), size-sent)])
+		if err != nil {
+			return err
+		}
+		h.Write(buf[:n])
+		sent += int64(n)
+
+		x1, err := alice.Encrypt(buf[:n])
+		if err != nil {
+			return err
+		}
+		if err := c.writeValue(msgX1, x1[0], p); err != nil {
+			return err
+		}
+		x2, err := c.readValue(msgX2, p)
+		if err != nil {
+			return err
+		}
+		x3, err := alice.Decrypt([]*big.Int{x2})
+		if err != nil {
+			return err
+		}
+		if err := c.writeValue(msgX3, x3[0], p); err != nil {
+			return err
+		}
+	}
+
+	if err := c.write(msgDone, h.Sum(nil)); err != nil {
+		return err
+	}
+	_, err = c.read(msgAccept)
+	return err
+}
+
+// Receiver accepts files whose prime has at least MinBits bits.
+type Receiver struct {
+	MinBits int
+	Timeout time.Duration
+}
+
+// Receive writes the file to w and returns the name the sender gave.
+func (rc *Receiver) Receive(nc net.Conn, w io.Writer) (string, error) {
+	c := &conn{c: nc, timeout: timeout(rc.Timeout)}
+	hello, err := c.read(msgHello)
+	if err != nil {
+		return "", err
+	}
+	if len(hello) < 10 {
+		return "", c.reject(errors.New("shamir: malformed hello"))
+	}
+	size := int64(binary.BigEndian.Uint64(hello))
+	nameLen := int(binary.BigEndian.Uint16(hello[8:]))
+	if len(hello) < 10+nameLen || size < 0 {
+		return "", c.reject(errors.New("shamir: malformed hello"))
+	}
+	name := string(hello[10 : 10+nameLen])
+	p := new(big.Int).SetBytes(hello[10+nameLen:])
+
+	minBits := rc.MinBits
+	if minBits == 0 {
+		minBits = DefaultMinBits
+	}
+	if p.BitLen() < minBits {
+		return "", c.reject(fmt.Errorf("shamir: %d-bit prime, need at least %d", p.BitLen(), minBits))
+	}
+	bob, err := NewBob(p)
+	if err != nil {
+		return "", c.reject(err)
+	}
+	if err := c.write(msgAccept, nil); err != nil {
+		return "", err
+	}
+
+	k := ChunkSize(p)
+	h := sha256.New()
+	for got := int64(0); got < size; {
+		x1, err := c.readValue(msgX1, p)
+		if err != nil {
+			return "", err
+		}
+		x2, err := bob.Encrypt([]*big.Int{x1})
+		if err != nil {
+			return "", c.reject(err)
+		}
+		if err := c.writeValue(msgX2, x2[0], p); err != nil {
+			return "", err
+		}
+		x3, err := c.readValue(msgX3, p)
+		if err != nil {
+			return "", err
+		}
+
+		n := min(int64(k), size-got)
+		block, err := bob.Decrypt([]*big.Int{x3})
+		if err != nil {
+			return "", c.reject(err)
+		}
+		if int64(len(block)) != n {
+			return "", c.reject(errors.New("shamir: block has the wrong length"))
+		}
+		h.Write(block)
+		if _, err := w.Write(block); err != nil {
+			return "", c.reject(err)
+		}
+		got += n
+	}
+
+	digest, err := c.read(msgDone)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(digest, h.Sum(nil)) {
+		return "", c.reject(errors.New("shamir: file hash mismatch"))
+	}
+	return name, c.write(msgAccept, nil)
+}
+
+// GeneratePrime returns a prime for Sender.P.
+func GeneratePrime(bits int) (*big.Int, error) {
+	return rand.Prime(rand.Reader, bits)
+}
+
+func timeout(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultTimeout
+	}
+	return d
+}
@@ -0,0 +1,57 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// transfer runs a sender and a receiver over an in-memory connection.
+func transfer(t *testing.T, p *big.Int, rc *Receiver, data []byte) (name string, got []byte, sendErr, recvErr error) {
+	t.Helper()
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	sent := make(chan error, 1)
+	go func() {
+		s := &Sender{P: p, Timeout: 5 * time.Second}
+		sent <- s.Send(a, "notes.txt", bytes.NewReader(data), int64(len(data)))
+	}()
+	var out bytes.Buffer
+	name, recvErr = rc.Receive(b, &out)
+	b.Close()
+	return name, out.Bytes(), <-sent, recvErr
+}
+
+func TestTransfer(t *testing.T) {
+	p, err := GeneratePrime(DefaultMinBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, ChunkSize(p), 3*ChunkSize(p) + 5} {
+		data := make([]byte, size)
+		rand.Read(data)
+		name, got, sendErr, recvErr := transfer(t, p, &Receiver{Timeout: 5 * time.Second}, data)
+		if sendErr != nil || recvErr != nil {
+			t.Fatalf("%d bytes: send %v, receive %v", size, sendErr, recvErr)
+		}
+		if name != "notes.txt" || !bytes.Equal(got, data) {
+			t.Fatalf("%d bytes: received %q, %x", size, name, got)
+		}
+	}
+}
+
+func TestRejectSmallPrime(t *testing.T) {
+	p, err := GeneratePrime(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, got, sendErr, recvErr := transfer(t, p, &Receiver{Timeout: 5 * time.Second}, []byte("secret"))
+	if !errors.Is(sendErr, ErrRejected) || recvErr == nil || len(got) != 0 {
+		t.Fatalf("256-bit prime: send %v, receive %v, got %q", sendErr, recvErr, got)
+	}
+}
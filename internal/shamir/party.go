@@ -0,0 +1,118 @@
+package shamir
+
+import (
+	"errors"
+	"fmt"
+	"information-defending/internal/chunk"
+	"math/big"
+)
+
+// The four steps of the three-pass protocol with each party's exponents
+// kept inside its own value:
+//
+//	x1 := alice.Encrypt(msg)  // m^cA
+//	x2 := bob.Encrypt(x1)     // m^(cA*cB)
+//	x3 := alice.Decrypt(x2)   // m^cB
+//	msg := bob.Decrypt(x3)    // m
+type Alice struct {
+	p, c, d *big.Int
+}
+
+type Bob struct {
+	p, c, d *big.Int
+}
+
+func NewAlice(p *big.Int) (*Alice, error) {
+	c, d, err := partyKeys(p)
+	if err != nil {
+		return nil, err
+	}
+	return &Alice{p: p, c: c, d: d}, nil
+}
+
+func NewBob(p *big.Int) (*Bob, error) {
+	c, d, err := partyKeys(p)
+	if err != nil {
+		return nil, err
+	}
+	return &Bob{p: p, c: c, d: d}, nil
+}
+
+func (a *Alice) Encrypt(msg []byte) ([]*big.Int, error) {
+	ms, err := Split(msg, a.p)
+	if err != nil {
+		return nil, err
+	}
+	return power(ms, a.c, a.p)
+}
+
+func (b *Bob) Encrypt(x1 []*big.Int) ([]*big.Int, error) {
+	return power(x1, b.c, b.p)
+}
+
+func (a *Alice) Decrypt(x2 []*big.Int) ([]*big.Int, error) {
+	return power(x2, a.d, a.p)
+}
+
+func (b *Bob) Decrypt(x3 []*big.Int) ([]byte, error) {
+	ms, err := power(x3, b.d, b.p)
+	if err != nil {
+		return nil, err
+	}
+	return Join(ms)
+}
+
+func partyKeys(p *big.Int) (*big.Int, *big.Int, error) {
+	if ChunkSize(p) < 1 || !p.ProbablyPrime(20) {
+		return nil, nil, fmt.Errorf("shamir: p = %s must be a prime above 511", p)
+	}
+	for {
+		c, d, err := NewKeys(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		// c = 1 would send the message in the clear
+		if c.Cmp(big.NewInt(1)) != 0 {
+			return c, d, nil
+		}
+	}
+}
+
+func power(xs []*big.Int, k, p *big.Int) ([]*big.Int, error) {
+	out := make([]*big.Int, len(xs))
+	for i, x := range xs {
+		if err := checkValue(x, p); err != nil {
+			return nil, fmt.Errorf("chunk %d: %w", i, err)
+		}
+		out[i] = new(big.Int).Exp(x, k, p)
+	}
+	return out, nil
+}
+
+var ErrRange = errors.New("shamir: value must satisfy 1 < x < p")
+
+func checkValue(x, p *big.Int) error {
+	if x == nil || x.Cmp(big.NewInt(1)) <= 0 || x.Cmp(p) >= 0 {
+		return ErrRange
+	}
+	return nil
+}
+
+// ChunkSize is the number of message bytes per chunk. A chunk is the
+// number 1 || data, which is above 1 and, for this size, below p.
+func ChunkSize(p *big.Int) int {
+	return chunk.Size(p.BitLen() - 1)
+}
+
+// Split cuts msg into chunks for p; the last one may be shorter.
+func Split(msg []byte, p *big.Int) ([]*big.Int, error) {
+	k := ChunkSize(p)
+	if k < 1 {
+		return nil, fmt.Errorf("shamir: p = %s is too small for chunks", p)
+	}
+	return chunk.Split(msg, k), nil
+}
+
+func Join(ms []*big.Int) ([]byte, error) {
+	return chunk.Join(ms)
+}
@@ -0,0 +1,63 @@
+package shamir
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParties(t *testing.T) {
+	p, err := GeneratePrime(DefaultMinBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, err := NewAlice(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewBob(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := bytes.Repeat([]byte("\x00three-pass "), 20)
+	x1, err := alice.Encrypt(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (len(msg) + ChunkSize(p) - 1) / ChunkSize(p); len(x1) != want {
+		t.Fatalf("%d chunks, want %d", len(x1), want)
+	}
+	x2, err := bob.Encrypt(x1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x3, err := alice.Decrypt(x2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := bob.Decrypt(x3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Fatalf("Bob got %q", got)
+	}
+
+	for _, x := range []*big.Int{big.NewInt(0), big.NewInt(1), p, nil} {
+		if _, err := bob.Encrypt([]*big.Int{x}); !errors.Is(err, ErrRange) {
+			t.Errorf("x = %v: %v", x, err)
+		}
+	}
+}
+
+func TestSmallPrime(t *testing.T) {
+	for _, p := range []int64{257, 509, 1000} {
+		if _, err := NewAlice(big.NewInt(p)); err == nil {
+			t.Errorf("p = %d accepted", p)
+		}
+	}
+	if _, err := NewBob(big.NewInt(521)); err != nil {
+		t.Errorf("p = 521: %v", err)
+	}
+}
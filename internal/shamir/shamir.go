@@ -1,26 +1,41 @@
 package shamir
 
 import (
+	"bufio"
 	"crypto/rand"
 	"fmt"
+	"information-defending/internal/chunk"
+	"information-defending/internal/container"
 	"information-defending/internal/crypto"
+	"information-defending/internal/stream"
+	"io"
 	"math/big"
-	"os"
-	"strings"
+	"sync/atomic"
 )
 
+// GenerateKeys returns exponents c, d with c*d = 1 mod p-1. It panics if
+// the random source fails; NewKeys returns the error instead.
 func GenerateKeys(p *big.Int) (*big.Int, *big.Int) {
+	c, d, err := NewKeys(p)
+	if err != nil {
+		panic(err)
+	}
+	return c, d
+}
+
+func NewKeys(p *big.Int) (c, d *big.Int, err error) {
 	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
-	var c *big.Int
 	for {
-		cand, _ := rand.Int(rand.Reader, pMinus1)
-		if crypto.Gcd(cand, pMinus1).Cmp(big.NewInt(1)) == 0 {
-			c = cand
+		c, err = rand.Int(rand.Reader, pMinus1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if crypto.Gcd(c, pMinus1).Cmp(big.NewInt(1)) == 0 {
 			break
 		}
 	}
-	d := crypto.ModInverse(c, pMinus1)
-	return c, d
+	d = crypto.ModInverse(c, pMinus1)
+	return c, d, nil
 }
 
 func Protocol(m, p, k1, k2 *big.Int) *big.Int {
@@ -30,50 +45,96 @@ func Protocol(m, p, k1, k2 *big.Int) *big.Int {
 }
 
 func EncryptFile(inputFile, outputFile string, p, cA, cB *big.Int) error {
-	data, err := os.ReadFile(inputFile)
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptStream(r, w, p, cA, cB)
+	})
+}
+
+func DecryptFile(inputFile, outputFile string, p, dA, dB *big.Int) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptStream(r, w, p, dA, dB)
+	})
+}
+
+// EncryptStream writes one block per chunk of ChunkSize(p) bytes.
+func EncryptStream(r io.Reader, w io.Writer, p, cA, cB *big.Int) error {
+	if ChunkSize(p) < 1 {
+		return fmt.Errorf("shamir: p = %s is too small, need p > 511", p)
+	}
+	size := crypto.ByteLen(p)
+	cw, err := container.NewWriter(w, container.Header{
+		Algorithm:   container.AlgShamir,
+		Fingerprint: container.Fingerprint(p),
+		BlockSize:   uint32(size),
+	})
 	if err != nil {
 		return err
 	}
 
-	pMinus1 := new(big.Int).Sub(p, big.NewInt(1))
-	result := []*big.Int{}
-
-	for _, b := range data {
-		m := big.NewInt(int64(b))
-		if m.Cmp(pMinus1) >= 0 {
-			return fmt.Errorf("байт %d >= p, увеличьте простое число p", b)
-		}
-		enc := Protocol(m, p, cA, cB)
-		result = append(result, enc)
+	var n uint64
+	err = stream.Map(stream.Count(stream.Blocks(r, ChunkSize(p)), &n), func(b []byte) ([]byte, error) {
+		enc := Protocol(chunk.Encode(b), p, cA, cB)
+		return enc.FillBytes(make([]byte, size)), nil
+	}, cw.WriteBlock, 0)
+	if err != nil {
+		return err
 	}
+	return cw.Close(n)
+}
 
-	out := ""
-	for _, val := range result {
-		out += val.String() + "\n"
+// DecryptStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func DecryptStream(r io.Reader, w io.Writer, p, dA, dB *big.Int) error {
+	br := bufio.NewReader(r)
+	if !container.Sniff(br) {
+		return decryptText(br, w, p, dA, dB)
 	}
-	return os.WriteFile(outputFile, []byte(out), 0644)
-}
 
-func DecryptFile(inputFile, outputFile string, p, dA, dB *big.Int) error {
-	data, err := os.ReadFile(inputFile)
+	cr, err := container.NewReader(br)
 	if err != nil {
 		return err
 	}
+	if err := cr.Header().Check(container.AlgShamir, container.Fingerprint(p)); err != nil {
+		return err
+	}
 
-	lines := strings.Split(string(data), "\n")
-	var result []byte
-
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+	var wrongKey atomic.Bool
+	var n uint64
+	err = stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		dec := Protocol(new(big.Int).SetBytes(b), p, dA, dB)
+		// files written before chunking hold one byte per block
+		if dec.IsInt64() && dec.Int64() <= 255 {
+			return []byte{byte(dec.Int64())}, nil
 		}
-		val, ok := new(big.Int).SetString(line, 10)
-		if !ok {
-			return fmt.Errorf("ошибка парсинга числа: %s", line)
+		data, err := chunk.Decode(dec)
+		if err != nil {
+			wrongKey.Store(true)
+			return nil, nil
 		}
-		dec := Protocol(val, p, dA, dB)
-		result = append(result, byte(dec.Int64()))
+		return data, nil
+	}, stream.Writer(w, &n), 0)
+	if err != nil {
+		return err
+	}
+
+	if wrongKey.Load() {
+		return container.ErrWrongKey
 	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
 
-	return os.WriteFile(outputFile, result, 0644)
+// decryptText reads the old format: one decimal number per line.
+func decryptText(r io.Reader, w io.Writer, p, dA, dB *big.Int) error {
+	var n uint64
+	return stream.Map(stream.Lines(r), func(line []byte) ([]byte, error) {
+		val, err := crypto.ParseBigInt(string(line))
+		if err != nil {
+			return nil, err
+		}
+		dec := Protocol(val, p, dA, dB)
+		return []byte{byte(dec.Int64())}, nil
+	}, stream.Writer(w, &n), 0)
 }
@@ -0,0 +1,66 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"information-defending/internal/container"
+	"math/big"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	p, err := GeneratePrime(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cA, dA, err := NewKeys(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cB, dB, err := NewKeys(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, ChunkSize(p), 4*ChunkSize(p) + 1} {
+		data := make([]byte, size)
+		rand.Read(data)
+		var enc, dec bytes.Buffer
+		if err := EncryptStream(bytes.NewReader(data), &enc, p, cA, cB); err != nil {
+			t.Fatal(err)
+		}
+		if err := DecryptStream(bytes.NewReader(enc.Bytes()), &dec, p, dA, dB); err != nil {
+			t.Fatalf("%d bytes: %v", size, err)
+		}
+		if !bytes.Equal(dec.Bytes(), data) {
+			t.Fatalf("%d bytes: decrypted %x", size, dec.Bytes())
+		}
+	}
+
+	var enc bytes.Buffer
+	if err := EncryptStream(bytes.NewReader([]byte("a longer secret message")), &enc, p, cA, cB); err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := NewKeys(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := DecryptStream(bytes.NewReader(enc.Bytes()), new(bytes.Buffer), p, dA, other); err != container.ErrWrongKey {
+		t.Errorf("wrong key: %v", err)
+	}
+}
+
+// TestKeys checks c*d = 1 mod p-1 for both key constructors.
+func TestKeys(t *testing.T) {
+	p := big.NewInt(1000003)
+	pMinus1 := big.NewInt(1000002)
+	c, d := GenerateKeys(p)
+	c2, d2, err := NewKeys(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range [][2]*big.Int{{c, d}, {c2, d2}} {
+		if new(big.Int).Mod(new(big.Int).Mul(k[0], k[1]), pMinus1).Int64() != 1 {
+			t.Errorf("c = %s, d = %s", k[0], k[1])
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package sss
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"information-defending/internal/crypto"
+	"math/big"
+	"os"
+)
+
+// Files hold decimal numbers, one per line, like the demo8 key files.
+//
+//	commitments:  P, Q, G, t, k, then t commitments for each of k elements
+//	share:        x, y_1 ... y_k, then the blinding values r_1 ... r_k
+//	byte share:   x, t, hex of the bytes, hex of their checksum
+
+func WriteCommitments(filename string, g *Group, comm Commitments) error {
+	var b bytes.Buffer
+	t := 0
+	if len(comm) > 0 {
+		t = len(comm[0])
+	}
+	fmt.Fprintf(&b, "%s\n%s\n%s\n%d\n%d\n", g.P, g.Q, g.G, t, len(comm))
+	for _, cs := range comm {
+		for _, c := range cs {
+			fmt.Fprintf(&b, "%s\n", c)
+		}
+	}
+	return os.WriteFile(filename, b.Bytes(), 0644)
+}
+
+func ReadCommitments(filename string) (*Group, Commitments, error) {
+	nums, err := readNumbers(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nums) < 5 || !nums[3].IsInt64() || !nums[4].IsInt64() {
+		return nil, nil, fmt.Errorf("%s: truncated commitments", filename)
+	}
+	g := &Group{P: nums[0], Q: nums[1], G: nums[2]}
+	t, k := nums[3].Int64(), nums[4].Int64()
+	if t < 1 || t > 255 || int64(len(nums)-5) != t*k {
+		return nil, nil, fmt.Errorf("%s: inconsistent commitments", filename)
+	}
+	comm := make(Commitments, k)
+	for i := range comm {
+		comm[i] = nums[5+int64(i)*t : 5+int64(i+1)*t]
+	}
+	if err := g.Check(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return g, comm, nil
+}
+
+func WriteShare(filename string, s Share) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d\n", s.X)
+	for _, y := range append(append([]*big.Int(nil), s.Y...), s.Blind...) {
+		fmt.Fprintf(&b, "%s\n", y)
+	}
+	return os.WriteFile(filename, b.Bytes(), 0600)
+}
+
+func ReadShare(filename string) (Share, error) {
+	nums, err := readNumbers(filename)
+	if err != nil {
+		return Share{}, err
+	}
+	if len(nums) < 1 || !nums[0].IsInt64() {
+		return Share{}, fmt.Errorf("%s: empty share", filename)
+	}
+	if len(nums)%2 != 1 {
+		return Share{}, fmt.Errorf("%s: share without blinding values", filename)
+	}
+	k := (len(nums) - 1) / 2
+	return Share{X: int(nums[0].Int64()), Y: nums[1 : 1+k], Blind: nums[1+k:]}, nil
+}
+
+func WriteByteShare(filename string, s ByteShare) error {
+	return os.WriteFile(filename, fmt.Appendf(nil, "%d\n%d\n%x\n%x\n", s.X, s.Threshold, s.Y, s.checksum()), 0600)
+}
+
+// checksum catches a damaged share file; a share made up to fool Combine
+// still fails the digest of the secret.
+func (s ByteShare) checksum() []byte {
+	h := sha256.New()
+	h.Write([]byte{s.X, byte(s.Threshold)})
+	h.Write(s.Y)
+	return h.Sum(nil)[:8]
+}
+
+func ReadByteShare(filename string) (ByteShare, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return ByteShare{}, err
+	}
+	var x, t int
+	var y, sum string
+	n, _ := fmt.Sscan(string(data), &x, &t, &y, &sum)
+	if n < 4 || x < 1 || x > 255 || t < 1 || t > 255 {
+		return ByteShare{}, fmt.Errorf("%s: malformed byte share", filename)
+	}
+	raw, err := hex.DecodeString(y)
+	if err != nil {
+		return ByteShare{}, fmt.Errorf("%s: %w: %v", filename, ErrInvalidShare, err)
+	}
+	s := ByteShare{X: byte(x), Threshold: t, Y: raw}
+	if fmt.Sprintf("%x", s.checksum()) != sum {
+		return ByteShare{}, fmt.Errorf("%s: %w: bad checksum", filename, ErrInvalidShare)
+	}
+	return s, nil
+}
+
+func readNumbers(filename string) ([]*big.Int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	nums, err := crypto.ParseBigInts(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return nums, nil
+}
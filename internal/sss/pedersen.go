@@ -0,0 +1,291 @@
+package sss
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Group is a subgroup of prime order Q in Z_P^*, generated by G. Shares
+// live in Z_Q and commitments in the subgroup.
+type Group struct {
+	P, Q, G *big.Int
+}
+
+// Share holds the values at X of one polynomial per secret element, and
+// in Blind those of the blinding polynomials.
+type Share struct {
+	X     int
+	Y     []*big.Int
+	Blind []*big.Int
+}
+
+// Commitments[k][j] = G^a_kj * H^b_kj for coefficient j of the polynomial
+// a of element k and its blinding polynomial b, so len(Commitments[k]) = t.
+// These are Pedersen commitments: Feldman's G^a_k0 alone would give away a
+// secret chunk of a few digits to anyone running BSGS, while with the
+// random b_k0 it says nothing about the secret.
+type Commitments [][]*big.Int
+
+// GenerateGroup finds a qBits prime Q and a pBits prime P = bQ + 1, as
+// for the GOST signature parameters.
+func GenerateGroup(qBits, pBits int) (*Group, error) {
+	if qBits < 16 || pBits < qBits+16 {
+		return nil, errors.New("sss: need qBits >= 16 and pBits >= qBits + 16")
+	}
+	q, err := rand.Prime(rand.Reader, qBits)
+	if err != nil {
+		return nil, err
+	}
+	one := big.NewInt(1)
+	bound := new(big.Int).Lsh(one, uint(pBits-qBits+1))
+	p := new(big.Int)
+	for {
+		b, err := rand.Int(rand.Reader, bound)
+		if err != nil {
+			return nil, err
+		}
+		p.Mul(b.SetBit(b, 0, 0), q).Add(p, one)
+		if p.BitLen() == pBits && p.ProbablyPrime(20) {
+			break
+		}
+	}
+
+	e := new(big.Int).Div(new(big.Int).Sub(p, one), q)
+	for h := big.NewInt(2); ; h.Add(h, one) {
+		g := new(big.Int).Exp(h, e, p)
+		if g.Cmp(one) != 0 {
+			return &Group{P: p, Q: q, G: g}, nil
+		}
+	}
+}
+
+// Check verifies the structure of parameters read from a file.
+func (g *Group) Check() error {
+	one := big.NewInt(1)
+	if !g.Q.ProbablyPrime(20) || !g.P.ProbablyPrime(20) {
+		return errors.New("sss: P and Q must be prime")
+	}
+	if new(big.Int).Mod(new(big.Int).Sub(g.P, one), g.Q).Sign() != 0 {
+		return errors.New("sss: Q does not divide P-1")
+	}
+	if g.G.Cmp(one) <= 0 || g.G.Cmp(g.P) >= 0 || new(big.Int).Exp(g.G, g.Q, g.P).Cmp(one) != 0 {
+		return errors.New("sss: G does not generate the subgroup of order Q")
+	}
+	return nil
+}
+
+// H is the second generator, hashed from the group so that nobody knows
+// log_G H; who knew it could open a commitment to another secret.
+func (g *Group) H() *big.Int {
+	one := big.NewInt(1)
+	e := new(big.Int).Div(new(big.Int).Sub(g.P, one), g.Q)
+	for ctr := uint32(0); ; ctr++ {
+		// 64 bits more than P, so the value mod P is close to uniform
+		var buf []byte
+		for i := uint32(0); len(buf)*8 < g.P.BitLen()+64; i++ {
+			h := sha256.New()
+			h.Write([]byte("information-defending pedersen"))
+			for _, x := range []*big.Int{g.P, g.Q, g.G} {
+				binary.Write(h, binary.BigEndian, uint32(len(x.Bytes())))
+				h.Write(x.Bytes())
+			}
+			binary.Write(h, binary.BigEndian, ctr)
+			binary.Write(h, binary.BigEndian, i)
+			buf = h.Sum(buf)
+		}
+		x := new(big.Int).SetBytes(buf)
+		x.Mod(x, g.P).Exp(x, e, g.P)
+		if x.Cmp(one) != 0 {
+			return x
+		}
+	}
+}
+
+// commit is G^a * H^b.
+func (g *Group) commit(a, b, h *big.Int) *big.Int {
+	c := new(big.Int).Exp(g.G, a, g.P)
+	return c.Mul(c, new(big.Int).Exp(h, b, g.P)).Mod(c, g.P)
+}
+
+// eval is the polynomial coeffs at x mod Q, by Horner's rule.
+func (g *Group) eval(coeffs []*big.Int, x int) *big.Int {
+	bx := big.NewInt(int64(x))
+	y := new(big.Int)
+	for j := len(coeffs) - 1; j >= 0; j-- {
+		y.Mul(y, bx).Add(y, coeffs[j]).Mod(y, g.Q)
+	}
+	return y
+}
+
+func (g *Group) randomCoeffs(t int) ([]*big.Int, error) {
+	coeffs := make([]*big.Int, t)
+	for j := range coeffs {
+		a, err := rand.Int(rand.Reader, g.Q)
+		if err != nil {
+			return nil, err
+		}
+		coeffs[j] = a
+	}
+	return coeffs, nil
+}
+
+// Split shares every secret, which must lie in [0, Q), with threshold t.
+func (g *Group) Split(secrets []*big.Int, t, n int) ([]Share, Commitments, error) {
+	if t < 1 || t > n || n > 255 {
+		return nil, nil, ErrThreshold
+	}
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{X: i + 1, Y: make([]*big.Int, len(secrets)), Blind: make([]*big.Int, len(secrets))}
+	}
+	comm := make(Commitments, len(secrets))
+	h := g.H()
+
+	for k, s := range secrets {
+		if s.Sign() < 0 || s.Cmp(g.Q) >= 0 {
+			return nil, nil, fmt.Errorf("sss: secret %d is outside Z_q", k)
+		}
+		a, err := g.randomCoeffs(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		a[0] = s
+		b, err := g.randomCoeffs(t)
+		if err != nil {
+			return nil, nil, err
+		}
+		comm[k] = make([]*big.Int, t)
+		for j := range a {
+			comm[k][j] = g.commit(a[j], b[j], h)
+		}
+		for i := range shares {
+			shares[i].Y[k] = g.eval(a, shares[i].X)
+			shares[i].Blind[k] = g.eval(b, shares[i].X)
+		}
+	}
+	return shares, comm, nil
+}
+
+// Verify checks G^y_k * H^r_k = prod_j C_kj^(x^j) for every element of
+// the share, r_k being its blinding value.
+func (g *Group) Verify(s Share, comm Commitments) error {
+	if len(s.Y) != len(comm) || len(s.Blind) != len(comm) || s.X < 1 {
+		return ErrInvalidShare
+	}
+	x := big.NewInt(int64(s.X))
+	h := g.H()
+	for k, y := range s.Y {
+		lhs := g.commit(y, s.Blind[k], h)
+		rhs := big.NewInt(1)
+		xj := big.NewInt(1)
+		for _, c := range comm[k] {
+			rhs.Mul(rhs, new(big.Int).Exp(c, xj, g.P)).Mod(rhs, g.P)
+			xj.Mul(xj, x).Mod(xj, g.Q)
+		}
+		if lhs.Cmp(rhs) != 0 {
+			return fmt.Errorf("%w: share %d, element %d", ErrInvalidShare, s.X, k)
+		}
+	}
+	return nil
+}
+
+// Combine verifies the shares, skips the invalid ones, interpolates the
+// first t valid ones and their blinding values at 0 and checks both
+// against Commitments[k][0].
+func (g *Group) Combine(shares []Share, comm Commitments) ([]*big.Int, error) {
+	if len(comm) == 0 {
+		return nil, nil
+	}
+	t := len(comm[0])
+	h := g.H()
+	var used []Share
+	invalid := 0
+	seen := map[int]bool{}
+	for _, s := range shares {
+		if seen[s.X] {
+			continue
+		}
+		if g.Verify(s, comm) != nil {
+			invalid++
+			continue
+		}
+		seen[s.X] = true
+		used = append(used, s)
+		if len(used) == t {
+			break
+		}
+	}
+	if len(used) < t {
+		return nil, fmt.Errorf("%w: have %d valid and %d invalid, need %d", ErrNotEnough, len(used), invalid, t)
+	}
+
+	basis := make([]*big.Int, t)
+	for i, si := range used {
+		num, den := big.NewInt(1), big.NewInt(1)
+		xi := big.NewInt(int64(si.X))
+		for j, sj := range used {
+			if i != j {
+				xj := big.NewInt(int64(sj.X))
+				num.Mul(num, xj).Mod(num, g.Q)
+				den.Mul(den, new(big.Int).Sub(xj, xi)).Mod(den, g.Q)
+			}
+		}
+		basis[i] = num.Mul(num, den.ModInverse(den, g.Q)).Mod(num, g.Q)
+	}
+
+	secrets := make([]*big.Int, len(comm))
+	for k := range secrets {
+		s, r := new(big.Int), new(big.Int)
+		for i, sh := range used {
+			s.Add(s, new(big.Int).Mul(sh.Y[k], basis[i]))
+			r.Add(r, new(big.Int).Mul(sh.Blind[k], basis[i]))
+		}
+		s.Mod(s, g.Q)
+		r.Mod(r, g.Q)
+		if g.commit(s, r, h).Cmp(comm[k][0]) != 0 {
+			return nil, fmt.Errorf("sss: element %d does not match its commitment", k)
+		}
+		secrets[k] = s
+	}
+	return secrets, nil
+}
+
+// ChunkSize is the number of bytes per element in SplitBytes; a chunk is
+// the number 1 || data, which keeps leading zeros and stays below Q.
+func (g *Group) ChunkSize() int {
+	return (g.Q.BitLen() - 2) / 8
+}
+
+func (g *Group) SplitBytes(secret []byte, t, n int) ([]Share, Commitments, error) {
+	k := g.ChunkSize()
+	if k < 1 {
+		return nil, nil, errors.New("sss: Q is too small for byte chunks")
+	}
+	var secrets []*big.Int
+	for len(secret) > 0 {
+		m := min(k, len(secret))
+		secrets = append(secrets, new(big.Int).SetBytes(append([]byte{1}, secret[:m]...)))
+		secret = secret[m:]
+	}
+	return g.Split(secrets, t, n)
+}
+
+func (g *Group) CombineBytes(shares []Share, comm Commitments) ([]byte, error) {
+	secrets, err := g.Combine(shares, comm)
+	if err != nil {
+		return nil, err
+	}
+	var secret []byte
+	for _, s := range secrets {
+		b := s.Bytes()
+		if len(b) < 2 || b[0] != 1 {
+			return nil, errors.New("sss: malformed chunk")
+		}
+		secret = append(secret, b[1:]...)
+	}
+	return secret, nil
+}
@@ -0,0 +1,124 @@
+// Package sss is Shamir's secret sharing: the secret is the constant term
+// of a random polynomial of degree t-1 and share i is its value at x = i,
+// so any t shares recover the secret by Lagrange interpolation at 0 and
+// fewer reveal nothing.
+//
+// Byte strings are shared over GF(256), one polynomial per byte (Split,
+// Combine), together with their SHA-256 digest so that Combine can tell a
+// wrong set of shares from the secret. Integers are shared over Z_q with Pedersen commitments that
+// let every holder check their share (Group).
+package sss
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrThreshold    = errors.New("sss: need 1 <= t <= n <= 255")
+	ErrInvalidShare = errors.New("sss: invalid share")
+	ErrNotEnough    = errors.New("sss: not enough shares")
+)
+
+// ByteShare holds the values at X for the secret and its digest, so Y is
+// sha256.Size bytes longer than the secret.
+type ByteShare struct {
+	X         byte
+	Threshold int
+	Y         []byte
+}
+
+func Split(secret []byte, t, n int) ([]ByteShare, error) {
+	if t < 1 || t > n || n > 255 {
+		return nil, ErrThreshold
+	}
+	sum := sha256.Sum256(secret)
+	secret = append(secret[:len(secret):len(secret)], sum[:]...)
+	shares := make([]ByteShare, n)
+	for i := range shares {
+		shares[i] = ByteShare{X: byte(i + 1), Threshold: t, Y: make([]byte, len(secret))}
+	}
+
+	coeffs := make([]byte, t)
+	for k, s := range secret {
+		coeffs[0] = s
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			// Horner's rule
+			var y byte
+			for j := t - 1; j >= 0; j-- {
+				y = gfMul(y, shares[i].X) ^ coeffs[j]
+			}
+			shares[i].Y[k] = y
+		}
+	}
+	return shares, nil
+}
+
+// Combine interpolates the first Threshold distinct shares at x = 0 and
+// returns ErrInvalidShare if the result does not match its digest.
+func Combine(shares []ByteShare) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnough
+	}
+	t := shares[0].Threshold
+	if t < 1 || t > 255 {
+		return nil, ErrThreshold
+	}
+	size := len(shares[0].Y)
+	if size < sha256.Size {
+		return nil, ErrInvalidShare
+	}
+	var xs []byte
+	var used []ByteShare
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.Threshold != t || len(s.Y) != size {
+			return nil, fmt.Errorf("sss: share %d is from a different split", s.X)
+		}
+		if s.X == 0 || seen[s.X] {
+			continue
+		}
+		seen[s.X] = true
+		xs = append(xs, s.X)
+		used = append(used, s)
+		if len(used) == t {
+			break
+		}
+	}
+	if len(used) < t {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrNotEnough, len(used), t)
+	}
+
+	// l_i(0) = prod x_j / (x_j - x_i), and subtraction is xor
+	basis := make([]byte, t)
+	for i, xi := range xs {
+		num, den := byte(1), byte(1)
+		for j, xj := range xs {
+			if i != j {
+				num = gfMul(num, xj)
+				den = gfMul(den, xj^xi)
+			}
+		}
+		basis[i] = gfDiv(num, den)
+	}
+
+	secret := make([]byte, size)
+	for k := range secret {
+		var s byte
+		for i, sh := range used {
+			s ^= gfMul(sh.Y[k], basis[i])
+		}
+		secret[k] = s
+	}
+	secret, sum := secret[:size-sha256.Size], secret[size-sha256.Size:]
+	if want := sha256.Sum256(secret); !bytes.Equal(sum, want[:]) {
+		return nil, fmt.Errorf("%w: the shares do not restore the secret", ErrInvalidShare)
+	}
+	return secret, nil
+}
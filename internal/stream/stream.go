@@ -0,0 +1,198 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"information-defending/internal/container"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// blocks handed to one worker per batch
+const perWorker = 256
+
+// Blocks returns a source that reads r in blocks of size bytes. The last
+// block may be shorter.
+func Blocks(r io.Reader, size int) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		block := make([]byte, size)
+		n, err := io.ReadFull(r, block)
+		if err == io.ErrUnexpectedEOF {
+			return block[:n], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return block, nil
+	}
+}
+
+// Map pulls blocks from src until io.EOF, applies fn to them on a pool of
+// workers and passes the results to emit in the original order. At most
+// workers*perWorker blocks are held in memory at a time.
+func Map(src func() ([]byte, error), fn func([]byte) ([]byte, error), emit func([]byte) error, workers int) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	batch := make([][]byte, 0, workers*perWorker)
+	errs := make([]error, workers)
+	for {
+		batch = batch[:0]
+		var srcErr error
+		for len(batch) < cap(batch) {
+			block, err := src()
+			if err != nil {
+				srcErr = err
+				break
+			}
+			batch = append(batch, block)
+		}
+
+		clear(errs)
+		var wg sync.WaitGroup
+		chunk := (len(batch) + workers - 1) / workers
+		for i := range workers {
+			lo, hi := i*chunk, min((i+1)*chunk, len(batch))
+			if lo >= hi {
+				break
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := lo; j < hi; j++ {
+					out, err := fn(batch[j])
+					if err != nil {
+						errs[i] = err
+						return
+					}
+					batch[j] = out
+				}
+			}()
+		}
+		wg.Wait()
+
+		for i := range errs {
+			if errs[i] != nil {
+				return errs[i]
+			}
+		}
+		for _, out := range batch {
+			if err := emit(out); err != nil {
+				return err
+			}
+		}
+
+		if srcErr == io.EOF {
+			return nil
+		}
+		if srcErr != nil {
+			return srcErr
+		}
+	}
+}
+
+// File runs fn from inputFile to outputFile. The output is removed if fn
+// fails, so a file that fails its tag leaves no unverified plaintext behind.
+func File(inputFile, outputFile string, fn func(io.Reader, io.Writer) error) error {
+	in, err := os.Open(inputFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(out)
+	err = fn(bufio.NewReader(in), bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(outputFile)
+	}
+	return err
+}
+
+// Lines returns a source of the non-empty lines of r with surrounding
+// spaces trimmed.
+func Lines(r io.Reader) func() ([]byte, error) {
+	sc := bufio.NewScanner(r)
+	return func() ([]byte, error) {
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) > 0 {
+				return append([]byte(nil), line...), nil
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// Writer returns an emit function for Map that writes to w and counts bytes.
+func Writer(w io.Writer, n *uint64) func([]byte) error {
+	return func(b []byte) error {
+		*n += uint64(len(b))
+		_, err := w.Write(b)
+		return err
+	}
+}
+
+// Count wraps a source and adds the length of every block to n.
+func Count(src func() ([]byte, error), n *uint64) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		b, err := src()
+		*n += uint64(len(b))
+		return b, err
+	}
+}
+
+// Padded writes blocks that were zero-padded to a fixed size. It holds back
+// the latest block so that Finish can cut the last one to the real length.
+type Padded struct {
+	w       io.Writer
+	n       uint64
+	pending []byte
+}
+
+func NewPadded(w io.Writer) *Padded {
+	return &Padded{w: w}
+}
+
+// Emit is the emit function for Map.
+func (p *Padded) Emit(b []byte) error {
+	if err := p.flush(len(p.pending)); err != nil {
+		return err
+	}
+	p.pending = b
+	return nil
+}
+
+// Finish writes what is left of the last block for total bytes overall.
+// A total that does not end inside the last block is container.ErrIntegrity.
+func (p *Padded) Finish(total uint64) error {
+	if total <= p.n && p.pending != nil || total > p.n+uint64(len(p.pending)) {
+		return container.ErrIntegrity
+	}
+	return p.flush(int(total - p.n))
+}
+
+func (p *Padded) flush(size int) error {
+	if p.pending == nil {
+		return nil
+	}
+	_, err := p.w.Write(p.pending[:size])
+	p.n += uint64(size)
+	p.pending = nil
+	return err
+}
@@ -0,0 +1,99 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"information-defending/internal/container"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapOrder(t *testing.T) {
+	data := make([]byte, 3*perWorker*7+5)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, workers := range []int{1, 3, 0} {
+		var out bytes.Buffer
+		var n uint64
+		err := Map(Blocks(bytes.NewReader(data), 7), func(b []byte) ([]byte, error) {
+			for i := range b {
+				b[i] ^= 0xFF
+			}
+			return b, nil
+		}, Writer(&out, &n), workers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, b := range out.Bytes() {
+			if b != byte(i)^0xFF {
+				t.Fatalf("%d workers: byte %d out of order", workers, i)
+			}
+		}
+		if n != uint64(len(data)) {
+			t.Fatalf("%d workers: wrote %d of %d bytes", workers, n, len(data))
+		}
+	}
+}
+
+func TestMapError(t *testing.T) {
+	fail := errors.New("fail")
+	err := Map(Blocks(bytes.NewReader(make([]byte, 100)), 1), func(b []byte) ([]byte, error) {
+		return nil, fail
+	}, func([]byte) error { return nil }, 4)
+	if err != fail {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestPadded(t *testing.T) {
+	cases := []struct {
+		blocks []string
+		total  uint64
+		want   string
+		err    error
+	}{
+		{nil, 0, "", nil},
+		{[]string{"abc0", "de00"}, 6, "abc0de", nil},
+		{[]string{"abc0", "de00"}, 8, "abc0de00", nil},
+		// shorter than what was written, or longer than what was read
+		{[]string{"abc0", "de00"}, 4, "abc0", container.ErrIntegrity},
+		{[]string{"abc0", "de00"}, 3, "abc0", container.ErrIntegrity},
+		{[]string{"abc0", "de00"}, 9, "abc0", container.ErrIntegrity},
+		{nil, 1, "", container.ErrIntegrity},
+	}
+	for _, c := range cases {
+		var out bytes.Buffer
+		p := NewPadded(&out)
+		for _, b := range c.blocks {
+			if err := p.Emit([]byte(b)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := p.Finish(c.total)
+		if err != c.err || out.String() != c.want {
+			t.Fatalf("%q with total %d: got %q, %v", c.blocks, c.total, out.String(), err)
+		}
+	}
+}
+
+func TestFileRemovesOutput(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	if err := os.WriteFile(in, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fail := errors.New("fail")
+	err := File(in, out, func(r io.Reader, w io.Writer) error {
+		w.Write([]byte("unverified"))
+		return fail
+	})
+	if err != fail {
+		t.Fatalf("got %v", err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("output left behind: %v", err)
+	}
+}
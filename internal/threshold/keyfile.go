@@ -0,0 +1,89 @@
+package threshold
+
+import (
+	"bytes"
+	"fmt"
+	"information-defending/internal/crypto"
+	"math/big"
+	"os"
+)
+
+// Files hold decimal numbers, one per line, like the demo8 key files.
+//
+//	public key:      N, D, t, n, V, VK_1 ... VK_n
+//	share:           i, s_i
+//	signature share: i, X_i, c, z
+
+func WritePublicKey(filename string, pub *PublicKey) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n%s\n%d\n%d\n%s\n", pub.N, pub.D, pub.Threshold, pub.Parties, pub.V)
+	for _, vk := range pub.VK {
+		fmt.Fprintf(&b, "%s\n", vk)
+	}
+	return os.WriteFile(filename, b.Bytes(), 0644)
+}
+
+func ReadPublicKey(filename string) (*PublicKey, error) {
+	nums, err := readNumbers(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(nums) < 5 {
+		return nil, fmt.Errorf("%s: truncated public key", filename)
+	}
+	pub := &PublicKey{
+		N:         nums[0],
+		D:         nums[1],
+		Threshold: int(nums[2].Int64()),
+		Parties:   int(nums[3].Int64()),
+		V:         nums[4],
+		VK:        nums[5:],
+	}
+	if len(pub.VK) != pub.Parties || pub.Threshold < 1 || pub.Threshold > pub.Parties {
+		return nil, fmt.Errorf("%s: inconsistent public key", filename)
+	}
+	return pub, nil
+}
+
+func WriteShare(filename string, s Share) error {
+	return os.WriteFile(filename, fmt.Appendf(nil, "%d\n%s\n", s.Index, s.S), 0600)
+}
+
+func ReadShare(filename string) (Share, error) {
+	nums, err := readNumbers(filename)
+	if err != nil {
+		return Share{}, err
+	}
+	if len(nums) != 2 {
+		return Share{}, fmt.Errorf("%s: expected index and share, got %d values", filename, len(nums))
+	}
+	return Share{Index: int(nums[0].Int64()), S: nums[1]}, nil
+}
+
+func (ss SignatureShare) MarshalText() ([]byte, error) {
+	return fmt.Appendf(nil, "%d\n%s\n%s\n%s\n", ss.Index, ss.X, ss.C, ss.Z), nil
+}
+
+func (ss *SignatureShare) UnmarshalText(data []byte) error {
+	nums, err := crypto.ParseBigInts(string(data))
+	if err != nil {
+		return err
+	}
+	if len(nums) != 4 {
+		return fmt.Errorf("threshold: signature share has %d values, expected 4", len(nums))
+	}
+	*ss = SignatureShare{Index: int(nums[0].Int64()), X: nums[1], C: nums[2], Z: nums[3]}
+	return nil
+}
+
+func readNumbers(filename string) ([]*big.Int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	nums, err := crypto.ParseBigInts(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return nums, nil
+}
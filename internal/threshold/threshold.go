@@ -0,0 +1,361 @@
+// Package threshold implements Shoup's practical threshold RSA signatures
+// ("Practical Threshold Signatures", EUROCRYPT 2000): any t of n parties
+// produce a signature that verifies with the ordinary public key.
+package threshold
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rsa"
+	"math/big"
+	"runtime"
+)
+
+// L1 is the hash length of the share proofs in bits.
+const L1 = 256
+
+var (
+	ErrInvalidShare = errors.New("threshold: invalid signature share")
+	// ErrNotSafe is Deal's answer to a key whose primes are not safe: the
+	// share proofs are only sound in a group of squares of order p'q'.
+	ErrNotSafe = errors.New("threshold: the primes are not safe primes")
+)
+
+// PublicKey is what the combiner and every party know: the RSA public key,
+// the verification base V and a verification key V^s_i per party.
+type PublicKey struct {
+	N *big.Int
+	D *big.Int
+
+	Threshold int
+	Parties   int
+
+	V  *big.Int
+	VK []*big.Int
+}
+
+// Share is party Index's piece s_i = f(i) of the private exponent.
+type Share struct {
+	Index int
+	S     *big.Int
+}
+
+// SignatureShare is x^(2*Delta*s_i) with a proof that it used the same s_i
+// as the verification key.
+type SignatureShare struct {
+	Index int
+	X     *big.Int
+	C     *big.Int
+	Z     *big.Int
+}
+
+// GenerateKeys generates keys with safe primes p = 2p'+1, q = 2q'+1, which
+// Shoup's security proof requires. It takes a while for large sizes.
+func GenerateKeys(bits int, d int64) (rsa.Keys, error) {
+	D := big.NewInt(d)
+	for {
+		p, err := safePrime(bits - bits/2)
+		if err != nil {
+			return rsa.Keys{}, err
+		}
+		q, err := safePrime(bits / 2)
+		if err != nil {
+			return rsa.Keys{}, err
+		}
+		N := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || N.BitLen() != bits {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1)))
+		c := new(big.Int).ModInverse(D, phi)
+		if c == nil {
+			continue
+		}
+		return rsa.Keys{C: c, D: D, N: N, Primes: []*big.Int{p, q}}, nil
+	}
+}
+
+func safePrime(bits int) (*big.Int, error) {
+	found := make(chan *big.Int, 1)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	for range runtime.NumCPU() {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				q, err := rand.Prime(rand.Reader, bits-1)
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					return
+				}
+				p := new(big.Int).Lsh(q, 1)
+				p.Add(p, big.NewInt(1))
+				if p.ProbablyPrime(20) {
+					select {
+					case found <- p:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	select {
+	case p := <-found:
+		return p, nil
+	case err := <-errs:
+		return nil, err
+	}
+}
+
+// IsSafe reports whether both primes of the key are safe primes.
+func IsSafe(k rsa.Keys) bool {
+	if len(k.Primes) != 2 {
+		return false
+	}
+	for _, p := range k.Primes {
+		half := new(big.Int).Rsh(p, 1)
+		if !half.ProbablyPrime(20) {
+			return false
+		}
+	}
+	return true
+}
+
+// Deal splits the private exponent of a two-prime key into parties shares,
+// any threshold of which can sign. The shares live in Z_m, m = phi(N)/4,
+// the order of the group of squares p'q', so both primes must be safe
+// primes as from GenerateKeys. The public exponent must be a prime larger
+// than parties.
+func Deal(k rsa.Keys, threshold, parties int) (*PublicKey, []Share, error) {
+	if threshold < 1 || threshold > parties {
+		return nil, nil, fmt.Errorf("threshold: need 1 <= t <= n, got t = %d, n = %d", threshold, parties)
+	}
+	if !k.D.ProbablyPrime(20) || k.D.Cmp(big.NewInt(int64(parties))) <= 0 {
+		return nil, nil, errors.New("threshold: public exponent must be a prime larger than the number of parties")
+	}
+	primes := k.Primes
+	if len(primes) == 0 {
+		var err error
+		primes, err = rsa.RecoverPrimes(k.C, k.D, k.N)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(primes) != 2 {
+		return nil, nil, errors.New("threshold: only two-prime keys can be split")
+	}
+	if !IsSafe(rsa.Keys{Primes: primes}) {
+		return nil, nil, ErrNotSafe
+	}
+
+	one := big.NewInt(1)
+	m := new(big.Int).Mul(new(big.Int).Sub(primes[0], one), new(big.Int).Sub(primes[1], one))
+	m.Rsh(m, 2)
+	c := new(big.Int).ModInverse(k.D, m)
+	if c == nil {
+		return nil, nil, errors.New("threshold: public exponent is not invertible")
+	}
+
+	// f(x) = c + a_1 x + ... + a_(t-1) x^(t-1) over Z_m
+	coeffs := []*big.Int{c}
+	for range threshold - 1 {
+		a, err := rand.Int(rand.Reader, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		coeffs = append(coeffs, a)
+	}
+
+	// V is a random square, which generates Q_N with high probability
+	u, err := randomUnit(k.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub := &PublicKey{
+		N:         k.N,
+		D:         k.D,
+		Threshold: threshold,
+		Parties:   parties,
+		V:         new(big.Int).Exp(u, big.NewInt(2), k.N),
+	}
+
+	shares := make([]Share, parties)
+	for i := range shares {
+		x := big.NewInt(int64(i + 1))
+		s := new(big.Int)
+		for j := len(coeffs) - 1; j >= 0; j-- {
+			s.Mul(s, x)
+			s.Add(s, coeffs[j])
+			s.Mod(s, m)
+		}
+		shares[i] = Share{Index: i + 1, S: s}
+		pub.VK = append(pub.VK, new(big.Int).Exp(pub.V, s, k.N))
+	}
+	return pub, shares, nil
+}
+
+// Sign produces the signature share for the message representative x,
+// e.g. a hash, together with a proof that log_V VK_i = log_x~ X^2 for
+// x~ = x^(4*Delta).
+func (s Share) Sign(pub *PublicKey, x *big.Int) (SignatureShare, error) {
+	if s.Index < 1 || s.Index > pub.Parties {
+		return SignatureShare{}, fmt.Errorf("threshold: share index %d out of range", s.Index)
+	}
+	delta := pub.delta()
+	xi := new(big.Int).Exp(x, new(big.Int).Mul(big.NewInt(2), new(big.Int).Mul(delta, s.S)), pub.N)
+
+	xt := pub.xTilde(x)
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(pub.N.BitLen()+2*L1))
+	r, err := rand.Int(rand.Reader, bound)
+	if err != nil {
+		return SignatureShare{}, err
+	}
+	v1 := new(big.Int).Exp(pub.V, r, pub.N)
+	x1 := new(big.Int).Exp(xt, r, pub.N)
+
+	xi2 := new(big.Int).Exp(xi, big.NewInt(2), pub.N)
+	c := pub.challenge(xt, pub.VK[s.Index-1], xi2, v1, x1)
+	z := new(big.Int).Mul(s.S, c)
+	z.Add(z, r)
+	return SignatureShare{Index: s.Index, X: xi, C: c, Z: z}, nil
+}
+
+// VerifyShare checks the proof of a signature share.
+func (pub *PublicKey) VerifyShare(x *big.Int, ss SignatureShare) error {
+	if ss.Index < 1 || ss.Index > pub.Parties || ss.X == nil || ss.C == nil || ss.Z == nil {
+		return ErrInvalidShare
+	}
+	N := pub.N
+	xt := pub.xTilde(x)
+	xi2 := new(big.Int).Exp(ss.X, big.NewInt(2), N)
+	negC := new(big.Int).Neg(ss.C)
+
+	// v' = V^z * VK_i^-c, x' = x~^z * X_i^-2c
+	vkc := new(big.Int).Exp(pub.VK[ss.Index-1], negC, N)
+	xic := new(big.Int).Exp(xi2, negC, N)
+	if vkc == nil || xic == nil {
+		return ErrInvalidShare
+	}
+	v1 := new(big.Int).Exp(pub.V, ss.Z, N)
+	v1.Mul(v1, vkc).Mod(v1, N)
+	x1 := new(big.Int).Exp(xt, ss.Z, N)
+	x1.Mul(x1, xic).Mod(x1, N)
+
+	if pub.challenge(xt, pub.VK[ss.Index-1], xi2, v1, x1).Cmp(ss.C) != 0 {
+		return ErrInvalidShare
+	}
+	return nil
+}
+
+// Combine verifies the shares and assembles the signature y = x^c mod N
+// from the first Threshold valid ones, using integer Lagrange
+// coefficients scaled by Delta = n!.
+func (pub *PublicKey) Combine(x *big.Int, shares []SignatureShare) (*big.Int, error) {
+	var valid []SignatureShare
+	seen := make(map[int]bool)
+	for _, ss := range shares {
+		if seen[ss.Index] || pub.VerifyShare(x, ss) != nil {
+			continue
+		}
+		seen[ss.Index] = true
+		valid = append(valid, ss)
+		if len(valid) == pub.Threshold {
+			break
+		}
+	}
+	if len(valid) < pub.Threshold {
+		return nil, fmt.Errorf("threshold: %d valid shares, need %d", len(valid), pub.Threshold)
+	}
+
+	N := pub.N
+	delta := pub.delta()
+	w := big.NewInt(1)
+	for _, ss := range valid {
+		lambda := pub.lambda(ss.Index, valid, delta)
+		t := new(big.Int).Exp(ss.X, lambda.Lsh(lambda, 1), N)
+		if t == nil {
+			return nil, ErrInvalidShare
+		}
+		w.Mul(w, t).Mod(w, N)
+	}
+
+	// w^e = x^e' with e' = 4*Delta^2; a*e' + b*e = 1 gives y = w^a * x^b
+	ePrime := new(big.Int).Mul(delta, delta)
+	ePrime.Lsh(ePrime, 2)
+	a, b := new(big.Int), new(big.Int)
+	if new(big.Int).GCD(a, b, ePrime, pub.D).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("threshold: public exponent shares a factor with 4*Delta^2")
+	}
+	wa := new(big.Int).Exp(w, a, N)
+	xb := new(big.Int).Exp(x, b, N)
+	if wa == nil || xb == nil {
+		return nil, errors.New("threshold: message is not invertible modulo N")
+	}
+	y := wa.Mul(wa, xb)
+	y.Mod(y, N)
+
+	if rsa.Encrypt(y, pub.D, N).Cmp(new(big.Int).Mod(x, N)) != 0 {
+		return nil, errors.New("threshold: combined signature does not verify")
+	}
+	return y, nil
+}
+
+func (pub *PublicKey) delta() *big.Int {
+	return new(big.Int).MulRange(1, int64(pub.Parties))
+}
+
+func (pub *PublicKey) xTilde(x *big.Int) *big.Int {
+	return new(big.Int).Exp(x, new(big.Int).Lsh(pub.delta(), 2), pub.N)
+}
+
+// lambda returns Delta * prod_{j != i} j / (j - i), which is an integer.
+func (pub *PublicKey) lambda(i int, set []SignatureShare, delta *big.Int) *big.Int {
+	num := new(big.Int).Set(delta)
+	den := big.NewInt(1)
+	for _, ss := range set {
+		j := ss.Index
+		if j == i {
+			continue
+		}
+		num.Mul(num, big.NewInt(int64(j)))
+		den.Mul(den, big.NewInt(int64(j-i)))
+	}
+	return num.Quo(num, den)
+}
+
+func (pub *PublicKey) challenge(values ...*big.Int) *big.Int {
+	size := crypto.ByteLen(pub.N)
+	h := sha256.New()
+	h.Write(pub.V.FillBytes(make([]byte, size)))
+	for _, v := range values {
+		h.Write(v.FillBytes(make([]byte, size)))
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+func randomUnit(N *big.Int) (*big.Int, error) {
+	one := big.NewInt(1)
+	for {
+		r, err := rand.Int(rand.Reader, N)
+		if err != nil {
+			return nil, err
+		}
+		if r.Cmp(one) > 0 && new(big.Int).GCD(nil, nil, r, N).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
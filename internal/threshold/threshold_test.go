@@ -0,0 +1,107 @@
+package threshold
+
+import (
+	"information-defending/internal/rsa"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	keys, err := GenerateKeys(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSafe(keys) {
+		t.Fatal("GenerateKeys returned primes that are not safe")
+	}
+	pub, shares, err := Deal(keys, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := big.NewInt(0x5EED)
+	want := rsa.Decrypt(x, keys.C, keys.N)
+
+	sigs := make([]SignatureShare, len(shares))
+	for i, s := range shares {
+		sigs[i], err = s.Sign(pub, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.VerifyShare(x, sigs[i]); err != nil {
+			t.Fatalf("share %d: %v", s.Index, err)
+		}
+	}
+	for _, set := range [][]SignatureShare{sigs[:3], sigs[2:], {sigs[4], sigs[0], sigs[3]}} {
+		y, err := pub.Combine(x, set)
+		if err != nil || y.Cmp(want) != 0 {
+			t.Fatalf("got %v, %v", y, err)
+		}
+	}
+
+	// a forged share is skipped, and two good ones are not enough
+	bad := sigs[1]
+	bad.X = new(big.Int).Add(bad.X, big.NewInt(1))
+	if err := pub.VerifyShare(x, bad); err != ErrInvalidShare {
+		t.Fatalf("forged share: %v", err)
+	}
+	if y, err := pub.Combine(x, []SignatureShare{bad, sigs[0], sigs[2], sigs[3]}); err != nil || y.Cmp(want) != 0 {
+		t.Fatalf("with a forged share: %v, %v", y, err)
+	}
+	if _, err := pub.Combine(x, []SignatureShare{bad, sigs[0], sigs[0], sigs[2]}); err == nil {
+		t.Fatal("combined from two distinct valid shares")
+	}
+}
+
+func TestDealRejects(t *testing.T) {
+	keys, err := rsa.GenerateKeysWithExponent(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Deal(keys, 2, 3); err != ErrNotSafe {
+		t.Fatalf("ordinary primes: %v", err)
+	}
+	if _, _, err := Deal(keys, 4, 3); err == nil {
+		t.Fatal("t > n accepted")
+	}
+}
+
+func TestFiles(t *testing.T) {
+	keys, err := GenerateKeys(512, 65537)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, shares, err := Deal(keys, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := WritePublicKey(filepath.Join(dir, "threshold.pub"), pub); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteShare(filepath.Join(dir, "share1.priv"), shares[0]); err != nil {
+		t.Fatal(err)
+	}
+	pub2, err := ReadPublicKey(filepath.Join(dir, "threshold.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ReadShare(filepath.Join(dir, "share1.priv"))
+	if err != nil || s.S.Cmp(shares[0].S) != 0 {
+		t.Fatalf("share read back as %v, %v", s, err)
+	}
+
+	x := big.NewInt(7)
+	ss, err := s.Sign(pub2, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := ss.MarshalText()
+	var back SignatureShare
+	if err := back.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.VerifyShare(x, back); err != nil {
+		t.Fatalf("signature share read back: %v", err)
+	}
+}
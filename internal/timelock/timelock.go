@@ -0,0 +1,222 @@
+// Package timelock implements Rivest-Shamir-Wagner time-lock puzzles: a
+// secret that anyone can recover, but only after T sequential squarings
+// modulo an RSA modulus.
+package timelock
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/rsa"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Puzzle hides Secret as CK = Secret + A^(2^T) mod N.
+type Puzzle struct {
+	N  *big.Int
+	A  *big.Int
+	T  uint64
+	CK *big.Int
+}
+
+// New creates a puzzle for secret < N. Knowing phi(N), the creator reduces
+// 2^T modulo phi(N) and needs two exponentiations instead of T squarings;
+// the primes are discarded afterwards.
+func New(secret *big.Int, bits int, t uint64) (*Puzzle, error) {
+	k, err := rsa.GenerateKeysWithExponent(bits, 65537)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Sign() < 0 || secret.Cmp(k.N) >= 0 {
+		return nil, errors.New("timelock: secret does not fit the modulus")
+	}
+
+	one := big.NewInt(1)
+	phi := big.NewInt(1)
+	for _, p := range k.Primes {
+		phi.Mul(phi, new(big.Int).Sub(p, one))
+	}
+
+	a, err := rand.Int(rand.Reader, new(big.Int).Sub(k.N, big.NewInt(3)))
+	if err != nil {
+		return nil, err
+	}
+	a.Add(a, big.NewInt(2))
+
+	e := new(big.Int).Exp(big.NewInt(2), new(big.Int).SetUint64(t), phi)
+	b := new(big.Int).Exp(a, e, k.N)
+	ck := b.Add(b, secret)
+	ck.Mod(ck, k.N)
+	return &Puzzle{N: k.N, A: a, T: t, CK: ck}, nil
+}
+
+// Open returns the secret given b = A^(2^T) mod N.
+func (p *Puzzle) Open(b *big.Int) *big.Int {
+	s := new(big.Int).Sub(p.CK, b)
+	return s.Mod(s, p.N)
+}
+
+// Binary layout: T[8] | len[2] | N | A | CK, all numbers len bytes.
+func (p *Puzzle) MarshalBinary() ([]byte, error) {
+	size := crypto.ByteLen(p.N)
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, p.T)
+	binary.Write(buf, binary.BigEndian, uint16(size))
+	for _, n := range []*big.Int{p.N, p.A, p.CK} {
+		buf.Write(n.FillBytes(make([]byte, size)))
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *Puzzle) UnmarshalBinary(data []byte) error {
+	if len(data) < 10 {
+		return errors.New("timelock: truncated puzzle")
+	}
+	size := int(binary.BigEndian.Uint16(data[8:10]))
+	if len(data) != 10+3*size {
+		return errors.New("timelock: truncated puzzle")
+	}
+	nums := make([]*big.Int, 3)
+	for i := range nums {
+		nums[i] = new(big.Int).SetBytes(data[10+i*size : 10+(i+1)*size])
+	}
+	*p = Puzzle{T: binary.BigEndian.Uint64(data[:8]), N: nums[0], A: nums[1], CK: nums[2]}
+	if p.N.Sign() == 0 || p.A.Cmp(p.N) >= 0 || p.CK.Cmp(p.N) >= 0 {
+		return errors.New("timelock: malformed puzzle")
+	}
+	return nil
+}
+
+func (p *Puzzle) fingerprint() string {
+	fp := container.Fingerprint(p.N, p.A, new(big.Int).SetUint64(p.T), p.CK)
+	return hex.EncodeToString(fp[:])
+}
+
+// SquaringsPerSecond measures how fast this machine squares modulo a
+// bits-sized number, for choosing T.
+func SquaringsPerSecond(bits int, d time.Duration) (float64, error) {
+	N, err := rand.Prime(rand.Reader, bits)
+	if err != nil {
+		return 0, err
+	}
+	x, err := rand.Int(rand.Reader, N)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	start := time.Now()
+	for time.Since(start) < d {
+		for range 1000 {
+			x.Mul(x, x)
+			x.Mod(x, N)
+		}
+		count += 1000
+	}
+	return float64(count) / time.Since(start).Seconds(), nil
+}
+
+// Solver does the squarings. With Checkpoint set it saves its state there
+// every Every and when ctx is cancelled, and resumes from it.
+type Solver struct {
+	Checkpoint string
+	Every      time.Duration
+	Progress   func(done, total uint64)
+}
+
+func (s *Solver) Solve(ctx context.Context, p *Puzzle) (*big.Int, error) {
+	x := new(big.Int).Set(p.A)
+	var i uint64
+	if s.Checkpoint != "" {
+		var err error
+		i, x, err = s.load(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	every := s.Every
+	if every <= 0 {
+		every = 10 * time.Second
+	}
+	last := time.Now()
+	for i < p.T {
+		// check the clock every few thousand squarings only
+		stop := min(i+4096, p.T)
+		for ; i < stop; i++ {
+			x.Mul(x, x)
+			x.Mod(x, p.N)
+		}
+
+		cancelled := ctx.Err() != nil
+		if time.Since(last) >= every || cancelled {
+			last = time.Now()
+			if s.Progress != nil {
+				s.Progress(i, p.T)
+			}
+			if err := s.save(p, i, x); err != nil {
+				return nil, err
+			}
+		}
+		if cancelled {
+			return nil, ctx.Err()
+		}
+	}
+
+	if s.Progress != nil {
+		s.Progress(p.T, p.T)
+	}
+	if err := s.save(p, p.T, x); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Checkpoint file: puzzle fingerprint, squarings done and the current value.
+func (s *Solver) save(p *Puzzle, i uint64, x *big.Int) error {
+	if s.Checkpoint == "" {
+		return nil
+	}
+	tmp := s.Checkpoint + ".tmp"
+	data := fmt.Appendf(nil, "%s\n%d\n%s\n", p.fingerprint(), i, x)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Checkpoint)
+}
+
+func (s *Solver) load(p *Puzzle) (uint64, *big.Int, error) {
+	data, err := os.ReadFile(s.Checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, new(big.Int).Set(p.A), nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 3 {
+		return 0, nil, fmt.Errorf("%s: malformed checkpoint", s.Checkpoint)
+	}
+	if fields[0] != p.fingerprint() {
+		return 0, nil, fmt.Errorf("%s: checkpoint belongs to another puzzle", s.Checkpoint)
+	}
+	var i uint64
+	if _, err := fmt.Sscanf(fields[1], "%d", &i); err != nil || i > p.T {
+		return 0, nil, fmt.Errorf("%s: malformed checkpoint", s.Checkpoint)
+	}
+	x, ok := new(big.Int).SetString(fields[2], 10)
+	if !ok || x.Cmp(p.N) >= 0 {
+		return 0, nil, fmt.Errorf("%s: malformed checkpoint", s.Checkpoint)
+	}
+	return i, x, nil
+}
@@ -0,0 +1,86 @@
+package timelock
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testPuzzle(t *testing.T, secret int64, squarings uint64) *Puzzle {
+	t.Helper()
+	p, err := New(big.NewInt(secret), 512, squarings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSolve(t *testing.T) {
+	p := testPuzzle(t, 123456789, 20000)
+	b, err := new(Solver).Solve(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Open(b); got.Int64() != 123456789 {
+		t.Fatalf("opened %s", got)
+	}
+	if _, err := New(new(big.Int).Lsh(big.NewInt(1), 512), 512, 1); err == nil {
+		t.Fatal("secret above any 512-bit modulus accepted")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	p := testPuzzle(t, 42, 1000)
+	data, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q Puzzle
+	if err := q.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if q.T != p.T || q.N.Cmp(p.N) != 0 || q.A.Cmp(p.A) != 0 || q.CK.Cmp(p.CK) != 0 {
+		t.Fatalf("read back %+v, want %+v", q, p)
+	}
+	if err := q.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Fatal("truncated puzzle accepted")
+	}
+}
+
+// TestCheckpoint stops the solver after its first checkpoint and resumes.
+func TestCheckpoint(t *testing.T) {
+	p := testPuzzle(t, 7, 50000)
+	file := filepath.Join(t.TempDir(), "puzzle.checkpoint")
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Solver{Checkpoint: file, Every: 1, Progress: func(done, total uint64) { cancel() }}
+	if _, err := s.Solve(ctx, p); err != context.Canceled {
+		t.Fatalf("cancelled solve: %v", err)
+	}
+	i, _, err := s.load(p)
+	if err != nil || i == 0 || i >= p.T {
+		t.Fatalf("checkpoint at %d of %d, %v", i, p.T, err)
+	}
+
+	s.Progress = nil
+	b, err := s.Solve(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Open(b); got.Int64() != 7 {
+		t.Fatalf("resumed solve opened %s", got)
+	}
+
+	other := testPuzzle(t, 7, 50000)
+	if _, err := s.Solve(context.Background(), other); err == nil || !strings.Contains(err.Error(), "another puzzle") {
+		t.Fatalf("checkpoint of another puzzle: %v", err)
+	}
+	if err := os.WriteFile(file, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Solve(context.Background(), p); err == nil {
+		t.Fatal("malformed checkpoint accepted")
+	}
+}
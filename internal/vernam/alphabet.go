@@ -0,0 +1,210 @@
+package vernam
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+	"unicode"
+)
+
+// Alphabet numbers its letters 0..Size()-1. Capital letters count as
+// their lower-case form and keep their case through encryption.
+type Alphabet struct {
+	Name    string
+	letters []rune
+	index   map[rune]int
+}
+
+var (
+	Russian = mustAlphabet("ru", "абвгдеёжзийклмнопрстуфхцчшщъыьэюя")
+	Latin   = mustAlphabet("en", "abcdefghijklmnopqrstuvwxyz")
+)
+
+func NewAlphabet(name, letters string) (*Alphabet, error) {
+	a := &Alphabet{Name: name, index: map[rune]int{}}
+	for _, r := range letters {
+		if _, ok := a.index[r]; ok {
+			return nil, fmt.Errorf("vernam: letter %q repeats in the alphabet", r)
+		}
+		a.index[r] = len(a.letters)
+		a.letters = append(a.letters, r)
+	}
+	if len(a.letters) < 2 {
+		return nil, errors.New("vernam: alphabet needs at least two letters")
+	}
+	return a, nil
+}
+
+func mustAlphabet(name, letters string) *Alphabet {
+	a, err := NewAlphabet(name, letters)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// AlphabetByName returns ru or en, or the custom alphabet made of the
+// letters of name.
+func AlphabetByName(name string) (*Alphabet, error) {
+	switch name {
+	case "ru", "russian":
+		return Russian, nil
+	case "en", "latin":
+		return Latin, nil
+	}
+	return NewAlphabet("custom", name)
+}
+
+func (a *Alphabet) Size() int {
+	return len(a.letters)
+}
+
+func (a *Alphabet) Letter(i int) rune {
+	return a.letters[i]
+}
+
+// Index returns the number of r and whether r was upper case.
+func (a *Alphabet) Index(r rune) (i int, upper, ok bool) {
+	if i, ok := a.index[r]; ok {
+		return i, false, true
+	}
+	if l := unicode.ToLower(r); l != r {
+		if i, ok := a.index[l]; ok {
+			return i, true, true
+		}
+	}
+	return 0, false, false
+}
+
+// Policy says what happens to characters outside the alphabet.
+type Policy int
+
+const (
+	Keep Policy = iota // copied as is, without using a key letter
+	Drop               // removed from the output
+)
+
+func ParsePolicy(s string) (Policy, error) {
+	switch s {
+	case "keep":
+		return Keep, nil
+	case "drop":
+		return Drop, nil
+	}
+	return 0, fmt.Errorf("vernam: policy must be keep or drop, not %q", s)
+}
+
+// Vigenere adds the key letter by letter modulo the alphabet size. A key
+// as long as the message and used once is the Vernam cipher; a shorter
+// key repeats and falls to attack.BreakVigenere.
+type Vigenere struct {
+	Alphabet *Alphabet
+	Policy   Policy
+	key      []int
+}
+
+func NewVigenere(a *Alphabet, key string, p Policy) (*Vigenere, error) {
+	v := &Vigenere{Alphabet: a, Policy: p}
+	for _, r := range key {
+		i, _, ok := a.Index(r)
+		if !ok {
+			return nil, fmt.Errorf("vernam: key letter %q is not in the alphabet", r)
+		}
+		v.key = append(v.key, i)
+	}
+	if len(v.key) == 0 {
+		return nil, errors.New("vernam: empty key")
+	}
+	return v, nil
+}
+
+// RandomKey returns n letters of a chosen uniformly with crypto/rand.
+func RandomKey(a *Alphabet, n int) (string, error) {
+	key := make([]rune, n)
+	size := big.NewInt(int64(a.Size()))
+	for i := range key {
+		x, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		key[i] = a.Letter(int(x.Int64()))
+	}
+	return string(key), nil
+}
+
+func (v *Vigenere) Encrypt(text string) string {
+	return v.apply(text, 1)
+}
+
+func (v *Vigenere) Decrypt(text string) string {
+	return v.apply(text, -1)
+}
+
+func (v *Vigenere) apply(text string, sign int) string {
+	out := make([]rune, 0, len(text))
+	pos := 0
+	for _, r := range text {
+		if c, ok := v.shift(r, &pos, sign); ok {
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
+
+// shift maps one character and advances pos past the key letter it used.
+func (v *Vigenere) shift(r rune, pos *int, sign int) (rune, bool) {
+	i, upper, ok := v.Alphabet.Index(r)
+	if !ok {
+		return r, v.Policy == Keep
+	}
+	n := v.Alphabet.Size()
+	c := v.Alphabet.Letter(((i+sign*v.key[*pos%len(v.key)])%n + n) % n)
+	*pos++
+	if upper {
+		c = unicode.ToUpper(c)
+	}
+	return c, true
+}
+
+func (v *Vigenere) EncryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return v.EncryptStream(r, w)
+	})
+}
+
+func (v *Vigenere) DecryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return v.DecryptStream(r, w)
+	})
+}
+
+// EncryptStream reads UTF-8 text rune by rune.
+func (v *Vigenere) EncryptStream(r io.Reader, w io.Writer) error {
+	return v.applyStream(r, w, 1)
+}
+
+func (v *Vigenere) DecryptStream(r io.Reader, w io.Writer) error {
+	return v.applyStream(r, w, -1)
+}
+
+func (v *Vigenere) applyStream(r io.Reader, w io.Writer, sign int) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	pos := 0
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if out, ok := v.shift(c, &pos, sign); ok {
+			bw.WriteRune(out)
+		}
+	}
+}
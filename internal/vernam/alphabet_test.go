@@ -0,0 +1,57 @@
+package vernam
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestVigenere(t *testing.T) {
+	v, err := NewVigenere(Russian, "ключ", Keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := "Зашифрованное сообщение Вернама, 2024!"
+	ct := v.Encrypt(msg)
+	if ct == msg || len([]rune(ct)) != len([]rune(msg)) {
+		t.Fatalf("Encrypt = %q", ct)
+	}
+	if got := v.Decrypt(ct); got != msg {
+		t.Fatalf("Decrypt = %q", got)
+	}
+	// case and characters outside the alphabet survive
+	if r := []rune(ct); !unicode.IsUpper(r[0]) || !unicode.IsUpper(r[24]) || r[13] != ' ' || !strings.HasSuffix(ct, ", 2024!") {
+		t.Fatalf("Keep lost characters: %q", ct)
+	}
+
+	// ё and я wrap around the 33 letters: я + б = а
+	w, _ := NewVigenere(Russian, "б", Keep)
+	if got := w.Encrypt("Яё"); got != "Аж" {
+		t.Fatalf("wrap around: %q", got)
+	}
+
+	d, _ := NewVigenere(Latin, "b", Drop)
+	if got := d.Encrypt("Hi, Zed."); got != "IjAfe" {
+		t.Fatalf("Drop: %q", got)
+	}
+
+	var enc, dec bytes.Buffer
+	if err := v.EncryptStream(strings.NewReader(msg), &enc); err != nil || enc.String() != ct {
+		t.Fatalf("EncryptStream = %q, %v", enc.String(), err)
+	}
+	if err := v.DecryptStream(&enc, &dec); err != nil || dec.String() != msg {
+		t.Fatalf("DecryptStream = %q, %v", dec.String(), err)
+	}
+
+	if _, err := NewVigenere(Latin, "ключ", Keep); err == nil {
+		t.Fatal("key outside the alphabet accepted")
+	}
+	if _, err := NewAlphabet("custom", "abca"); err == nil {
+		t.Fatal("repeated letter accepted")
+	}
+	key, err := RandomKey(Latin, 50)
+	if err != nil || len(key) != 50 || strings.Trim(key, "abcdefghijklmnopqrstuvwxyz") != "" {
+		t.Fatalf("RandomKey = %q, %v", key, err)
+	}
+}
@@ -0,0 +1,236 @@
+package vernam
+
+import (
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"information-defending/internal/container"
+	"information-defending/internal/kdf"
+	"information-defending/internal/keystream"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+)
+
+// NonceSize is the random salt that makes every file's keystream differ
+// under the same secret.
+const NonceSize = 16
+
+// EncryptKeystreamFile encrypts with the keystream of spec seeded from
+// secret, so a short shared secret covers a file of any size. The nonce
+// and spec go into the container parameters, and m tags the ciphertext
+// under a second key derived from secret.
+func EncryptKeystreamFile(inputFile, outputFile string, spec keystream.Spec, secret []byte, m container.MAC) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptKeystreamStream(r, w, spec, secret, m)
+	})
+}
+
+func DecryptKeystreamFile(inputFile, outputFile string, secret []byte) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptKeystreamStream(r, w, secret)
+	})
+}
+
+// EncryptPasswordFile is EncryptKeystreamFile with the secret derived
+// from password by p, whose parameters go into the header so that the
+// password alone decrypts.
+func EncryptPasswordFile(inputFile, outputFile string, spec keystream.Spec, password []byte, p kdf.Params, m container.MAC) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptPasswordStream(r, w, spec, password, p, m)
+	})
+}
+
+func DecryptPasswordFile(inputFile, outputFile string, password []byte) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptPasswordStream(r, w, password)
+	})
+}
+
+func EncryptKeystreamStream(r io.Reader, w io.Writer, spec keystream.Spec, secret []byte, m container.MAC) error {
+	return encryptKeystream(r, w, spec, secret, m, nil)
+}
+
+func EncryptPasswordStream(r io.Reader, w io.Writer, spec keystream.Spec, password []byte, p kdf.Params, m container.MAC) error {
+	params, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	secret, err := p.Key(password, passwordSecretSize)
+	if err != nil {
+		return err
+	}
+	return encryptKeystream(r, w, spec, secret, m, params)
+}
+
+// passwordSecretSize is the secret a password is stretched into.
+const passwordSecretSize = 32
+
+func encryptKeystream(r io.Reader, w io.Writer, spec keystream.Spec, secret []byte, m container.MAC, kdfParams []byte) error {
+	if m == container.MACNone {
+		return errors.New("vernam: keystream files need a MAC")
+	}
+	nonce := make([]byte, NonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	ks, fp, err := seed(spec, secret, nonce)
+	if err != nil {
+		return err
+	}
+	s, err := spec.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	cw, err := newWriter(w, container.Header{
+		Algorithm:   container.AlgKeystream,
+		Fingerprint: fp,
+		BlockSize:   1,
+		Params:      append(nonce, s...),
+		MAC:         m,
+		KDF:         kdfParams,
+	}, secret)
+	if err != nil {
+		return err
+	}
+
+	var n uint64
+	err = XORStream(r, ks, func(b []byte) error {
+		n += uint64(len(b))
+		return cw.WriteBlock(b)
+	})
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
+
+// DecryptKeystreamStream leaves unverified output in w until it returns nil, see
+// container.Reader.
+func DecryptKeystreamStream(r io.Reader, w io.Writer, secret []byte) error {
+	cr, err := container.NewReader(r)
+	if err != nil {
+		return err
+	}
+	return decryptKeystream(cr, w, secret)
+}
+
+// DecryptPasswordStream derives the secret with the parameters in the header.
+// The output in w is unverified until it returns nil, see
+// container.Reader.
+func DecryptPasswordStream(r io.Reader, w io.Writer, password []byte) error {
+	cr, err := container.NewReader(r)
+	if err != nil {
+		return err
+	}
+	if len(cr.Header().KDF) == 0 {
+		return errors.New("vernam: file is not password-protected")
+	}
+	var p kdf.Params
+	if err := p.UnmarshalBinary(cr.Header().KDF); err != nil {
+		return err
+	}
+	secret, err := p.Key(password, passwordSecretSize)
+	if err != nil {
+		return err
+	}
+	err = decryptKeystream(cr, w, secret)
+	if err == container.ErrWrongKey {
+		return kdf.ErrWrongPassword
+	}
+	return err
+}
+
+func decryptKeystream(cr *container.Reader, w io.Writer, secret []byte) error {
+	h := cr.Header()
+	if h.Algorithm != container.AlgKeystream {
+		// reports the algorithm the file was encrypted with
+		return h.Check(container.AlgKeystream, h.Fingerprint)
+	}
+	if len(h.Params) <= NonceSize {
+		return errors.New("vernam: missing keystream parameters")
+	}
+	var spec keystream.Spec
+	if err := spec.UnmarshalBinary(h.Params[NonceSize:]); err != nil {
+		return err
+	}
+	ks, fp, err := seed(spec, secret, h.Params[:NonceSize])
+	if err != nil {
+		return err
+	}
+	if err := h.Check(container.AlgKeystream, fp); err != nil {
+		return err
+	}
+	if h.MAC == container.MACNone {
+		return ErrNoMAC
+	}
+	if err := authenticate(cr, secret); err != nil {
+		return err
+	}
+
+	var n uint64
+	err = XORStream(&blockReader{next: cr.ReadBlock}, ks, stream.Writer(w, &n))
+	if err != nil {
+		return err
+	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
+
+// XORStream applies any keystream to r block by block, in order.
+func XORStream(r io.Reader, ks keystream.Stream, emit func([]byte) error) error {
+	next := stream.Blocks(r, 4096)
+	for {
+		b, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ks.XORKeyStream(b, b)
+		if err := emit(b); err != nil {
+			return err
+		}
+	}
+}
+
+// blockReader lets container blocks feed XORStream, keeping what does not
+// fit into p for the next Read.
+type blockReader struct {
+	next func() ([]byte, error)
+	rest []byte
+}
+
+func (r *blockReader) Read(p []byte) (int, error) {
+	if len(r.rest) == 0 {
+		b, err := r.next()
+		if err != nil {
+			return 0, err
+		}
+		r.rest = b
+	}
+	n := copy(p, r.rest)
+	r.rest = r.rest[n:]
+	return n, nil
+}
+
+// seed derives the generator and a key check value for the header.
+func seed(spec keystream.Spec, secret, nonce []byte) (keystream.Stream, [8]byte, error) {
+	s, err := keystream.Derive(secret, nonce, spec.Kind)
+	if err != nil {
+		return nil, [8]byte{}, err
+	}
+	ks, err := spec.New(s)
+	if err != nil {
+		return nil, [8]byte{}, err
+	}
+	check, err := hkdf.Key(sha256.New, secret, nonce, "information-defending keystream check", 32)
+	if err != nil {
+		return nil, [8]byte{}, err
+	}
+	return ks, container.Fingerprint(new(big.Int).SetBytes(check)), nil
+}
@@ -0,0 +1,55 @@
+package vernam
+
+import (
+	"bytes"
+	"information-defending/internal/container"
+	"information-defending/internal/keystream"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBlockReader(t *testing.T) {
+	blocks := [][]byte{[]byte("0123456789"), nil, []byte("abc")}
+	r := &blockReader{next: func() ([]byte, error) {
+		if len(blocks) == 0 {
+			return nil, io.EOF
+		}
+		b := blocks[0]
+		blocks = blocks[1:]
+		return b, nil
+	}}
+	// reads smaller than a block must not drop its tail
+	got, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte("0123456789abc")) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestKeystreamRoundTrip(t *testing.T) {
+	N, err := keystream.GenerateBBSModulus(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("a short Diffie-Hellman key")
+	msg := bytes.Repeat([]byte("gamma "), 2000)
+	for _, spec := range []keystream.Spec{keystream.DefaultLFSR, {Kind: keystream.KindBBS, N: N}, {Kind: keystream.KindGOST}} {
+		var ct, out bytes.Buffer
+		if err := EncryptKeystreamStream(bytes.NewReader(msg), &ct, spec, secret, container.MACHMACSHA256); err != nil {
+			t.Fatal(err)
+		}
+		if err := DecryptKeystreamStream(bytes.NewReader(ct.Bytes()), &out, secret); err != nil || !bytes.Equal(out.Bytes(), msg) {
+			t.Fatalf("%s: %v", spec.Kind, err)
+		}
+		out.Reset()
+		if err := DecryptKeystreamStream(bytes.NewReader(ct.Bytes()), &out, []byte("another key")); err != container.ErrWrongKey {
+			t.Fatalf("%s, wrong secret: %v", spec.Kind, err)
+		}
+		if bytes.Contains(ct.Bytes(), []byte("gamma gamma")) {
+			t.Fatalf("%s: plaintext in the ciphertext", spec.Kind)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package vernam
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Party says which end of the pad a side consumes: A takes key bytes from
+// the front, B from the back, so two parties sharing one pad never need
+// to coordinate until their regions meet.
+type Party byte
+
+const (
+	PartyA Party = 'A'
+	PartyB Party = 'B'
+)
+
+func ParseParty(s string) (Party, error) {
+	switch strings.ToUpper(s) {
+	case "A":
+		return PartyA, nil
+	case "B":
+		return PartyB, nil
+	}
+	return 0, fmt.Errorf("vernam: party must be A or B, not %q", s)
+}
+
+var (
+	ErrPadExhausted = errors.New("vernam: not enough unused pad left")
+	ErrPadReuse     = errors.New("vernam: pad region was already used")
+)
+
+type entry struct {
+	sent           bool
+	offset, length int64
+	digest         string // SHA-256 of a received ciphertext
+}
+
+func (e entry) overlaps(off, n int64) bool {
+	return off < e.offset+e.length && e.offset < off+n
+}
+
+// Ledger records the pad regions one party has sent or received with, one
+// line per message:
+//
+//	party A
+//	sent 0 1200
+//	received 999000 512 <sha256 of the ciphertext>
+type Ledger struct {
+	path    string
+	party   Party
+	entries []entry
+}
+
+func OpenLedger(path string, party Party) (*Ledger, error) {
+	l := &Ledger{path: path, party: party}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var e entry
+		switch {
+		case fields[0] == "party" && len(fields) == 2:
+			if fields[1] != string(party) {
+				return nil, fmt.Errorf("%s: ledger belongs to party %s, not %c", path, fields[1], party)
+			}
+			continue
+		case fields[0] == "sent" && len(fields) == 3:
+			e.sent = true
+		case fields[0] == "received" && len(fields) == 4:
+			e.digest = fields[3]
+		default:
+			return nil, fmt.Errorf("%s:%d: malformed ledger line", path, line)
+		}
+		if _, err := fmt.Sscan(fields[1]+" "+fields[2], &e.offset, &e.length); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		l.entries = append(l.entries, e)
+	}
+	return l, sc.Err()
+}
+
+// Save rewrites the ledger atomically.
+func (l *Ledger) Save() error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "party %c\n", l.party)
+	for _, e := range l.entries {
+		if e.sent {
+			fmt.Fprintf(&b, "sent %d %d\n", e.offset, e.length)
+		} else {
+			fmt.Fprintf(&b, "received %d %d %s\n", e.offset, e.length, e.digest)
+		}
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Reserve picks n unused bytes at this party's end of a pad of size bytes
+// and records them as sent.
+func (l *Ledger) Reserve(n, size int64) (int64, error) {
+	var off int64
+	if l.party == PartyA {
+		for _, e := range l.entries {
+			if e.sent {
+				off = max(off, e.offset+e.length)
+			}
+		}
+	} else {
+		end := size
+		for _, e := range l.entries {
+			if e.sent {
+				end = min(end, e.offset)
+			}
+		}
+		off = end - n
+	}
+	if off < 0 || off+n > size || slices.ContainsFunc(l.entries, func(e entry) bool { return e.overlaps(off, n) }) {
+		return 0, fmt.Errorf("%w: need %d bytes, %d left", ErrPadExhausted, n, l.Remaining(size))
+	}
+	l.entries = append(l.entries, entry{sent: true, offset: off, length: n})
+	return off, nil
+}
+
+// CheckReceive reports ErrPadReuse if the region was used by one of our
+// messages or by a different received ciphertext. An empty digest checks
+// only against sent messages.
+func (l *Ledger) CheckReceive(off, n int64, digest string) error {
+	for _, e := range l.entries {
+		if !e.overlaps(off, n) {
+			continue
+		}
+		if e.sent {
+			return fmt.Errorf("%w: bytes %d-%d were used for a message we sent", ErrPadReuse, off, off+n)
+		}
+		if digest != "" && (e.offset != off || e.length != n || e.digest != digest) {
+			return fmt.Errorf("%w: bytes %d-%d encrypt another received message", ErrPadReuse, off, off+n)
+		}
+	}
+	return nil
+}
+
+// Receive records a received message; decrypting the same file twice is
+// not a reuse.
+func (l *Ledger) Receive(off, n int64, digest string) error {
+	if err := l.CheckReceive(off, n, digest); err != nil {
+		return err
+	}
+	if !slices.Contains(l.entries, entry{offset: off, length: n, digest: digest}) {
+		l.entries = append(l.entries, entry{offset: off, length: n, digest: digest})
+	}
+	return nil
+}
+
+// Remaining is the number of bytes this party can still send, between its
+// own cursor and the closest region seen in use by the other party.
+func (l *Ledger) Remaining(size int64) int64 {
+	lo, hi := int64(0), size
+	for _, e := range l.entries {
+		if e.sent == (l.party == PartyA) {
+			lo = max(lo, e.offset+e.length)
+		} else {
+			hi = min(hi, e.offset)
+		}
+	}
+	return max(hi-lo, 0)
+}
@@ -0,0 +1,240 @@
+package vernam
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"information-defending/internal/container"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
+	"os"
+)
+
+// A pad file is padIDSize random bytes naming the pad, then the key bytes.
+// Offsets count key bytes only, so the ID never encrypts anything.
+const padIDSize = 32
+
+// Pad is one party's copy of a one-time pad together with its ledger,
+// kept next to the pad as <pad>.ledger<party>. MAC tags what it encrypts,
+// keyed from the pad ID, which only the two parties know.
+type Pad struct {
+	MAC container.MAC
+
+	f      *os.File
+	id     []byte
+	size   int64
+	ledger *Ledger
+}
+
+// GeneratePad writes size key bytes from crypto/rand. Both parties need an
+// identical copy, handed over out of band.
+func GeneratePad(filename string, size int64) error {
+	if size <= 0 {
+		return errors.New("vernam: pad size must be positive")
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	_, err = io.CopyN(w, rand.Reader, padIDSize+size)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filename)
+	}
+	return err
+}
+
+func OpenPad(filename string, party Party) (*Pad, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	id := make([]byte, padIDSize)
+	if _, err := io.ReadFull(f, id); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: not a pad file", filename)
+	}
+	ledger, err := OpenLedger(fmt.Sprintf("%s.ledger%c", filename, party), party)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Pad{MAC: container.MACHMACSHA256, f: f, id: id, size: info.Size() - padIDSize, ledger: ledger}, nil
+}
+
+func (p *Pad) Close() error {
+	return p.f.Close()
+}
+
+func (p *Pad) Size() int64 {
+	return p.size
+}
+
+// Remaining is how many bytes this party can still encrypt.
+func (p *Pad) Remaining() int64 {
+	return p.ledger.Remaining(p.size)
+}
+
+func (p *Pad) fingerprint() [8]byte {
+	return container.Fingerprint(new(big.Int).SetBytes(p.id))
+}
+
+// EncryptFile encrypts inputFile with fresh pad bytes.
+func (p *Pad) EncryptFile(inputFile, outputFile string) error {
+	info, err := os.Stat(inputFile)
+	if err != nil {
+		return err
+	}
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return p.EncryptStream(r, w, info.Size())
+	})
+}
+
+func (p *Pad) DecryptFile(inputFile, outputFile string) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return p.DecryptStream(r, w)
+	})
+}
+
+// EncryptStream encrypts exactly n bytes of r. The region is written to
+// the ledger before any ciphertext, so a crash can waste pad but never
+// reuse it. The offset and length travel in the container parameters.
+func (p *Pad) EncryptStream(r io.Reader, w io.Writer, n int64) error {
+	if p.MAC == container.MACNone {
+		return errors.New("vernam: pad files need a MAC")
+	}
+	off, err := p.ledger.Reserve(n, p.size)
+	if err != nil {
+		return err
+	}
+	if err := p.ledger.Save(); err != nil {
+		return err
+	}
+
+	params := binary.BigEndian.AppendUint64(nil, uint64(off))
+	params = binary.BigEndian.AppendUint64(params, uint64(n))
+	cw, err := newWriter(w, container.Header{
+		Algorithm:   container.AlgVernam,
+		Fingerprint: p.fingerprint(),
+		BlockSize:   1,
+		Params:      params,
+		MAC:         p.MAC,
+	}, p.id)
+	if err != nil {
+		return err
+	}
+
+	key := bufio.NewReader(io.NewSectionReader(p.f, padIDSize+off, n))
+	src := stream.Blocks(io.LimitReader(r, n), 4096)
+	var done int64
+	for {
+		b, err := src()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := xorPad(b, key); err != nil {
+			return err
+		}
+		if err := cw.WriteBlock(b); err != nil {
+			return err
+		}
+		done += int64(len(b))
+	}
+	if done != n {
+		return fmt.Errorf("vernam: input ended after %d of %d bytes", done, n)
+	}
+	return cw.Close(uint64(n))
+}
+
+// DecryptStream refuses files whose pad region overlaps one this party
+// sent with, or that another received file already used.
+// The output in w is unverified until it returns nil, see
+// container.Reader.
+func (p *Pad) DecryptStream(r io.Reader, w io.Writer) error {
+	cr, err := container.NewReader(r)
+	if err != nil {
+		return err
+	}
+	h := cr.Header()
+	if err := h.Check(container.AlgVernam, p.fingerprint()); err != nil {
+		return err
+	}
+	if len(h.Params) != 16 {
+		return errors.New("vernam: file was not encrypted with a one-time pad")
+	}
+	off := int64(binary.BigEndian.Uint64(h.Params))
+	n := int64(binary.BigEndian.Uint64(h.Params[8:]))
+	if off < 0 || n < 0 || off > p.size || n > p.size-off {
+		return fmt.Errorf("vernam: bytes %d-%d are outside the pad", off, off+n)
+	}
+	if err := p.ledger.CheckReceive(off, n, ""); err != nil {
+		return err
+	}
+	if h.MAC == container.MACNone {
+		return ErrNoMAC
+	}
+	if err := authenticate(cr, p.id); err != nil {
+		return err
+	}
+
+	key := bufio.NewReader(io.NewSectionReader(p.f, padIDSize+off, n))
+	digest := sha256.New()
+	var done int64
+	for {
+		b, err := cr.ReadBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		digest.Write(b)
+		if done += int64(len(b)); done > n {
+			return container.ErrIntegrity
+		}
+		if err := xorPad(b, key); err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	if done != n || uint64(n) != cr.Len() {
+		return container.ErrIntegrity
+	}
+
+	if err := p.ledger.Receive(off, n, hex.EncodeToString(digest.Sum(nil))); err != nil {
+		return err
+	}
+	return p.ledger.Save()
+}
+
+func xorPad(b []byte, key io.Reader) error {
+	k := make([]byte, len(b))
+	if _, err := io.ReadFull(key, k); err != nil {
+		return fmt.Errorf("vernam: reading pad: %w", err)
+	}
+	for i := range b {
+		b[i] ^= k[i]
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package vernam
+
+import (
+	"bytes"
+	"errors"
+	"information-defending/internal/container"
+	"path/filepath"
+	"testing"
+)
+
+func openPads(t *testing.T, size int64) (string, *Pad, *Pad) {
+	t.Helper()
+	pad := filepath.Join(t.TempDir(), "pad")
+	if err := GeneratePad(pad, size); err != nil {
+		t.Fatal(err)
+	}
+	a, err := OpenPad(pad, PartyA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	b, err := OpenPad(pad, PartyB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return pad, a, b
+}
+
+func send(t *testing.T, from *Pad, msg []byte) []byte {
+	t.Helper()
+	var ct bytes.Buffer
+	if err := from.EncryptStream(bytes.NewReader(msg), &ct, int64(len(msg))); err != nil {
+		t.Fatal(err)
+	}
+	return ct.Bytes()
+}
+
+func receive(to *Pad, ct []byte) ([]byte, error) {
+	var out bytes.Buffer
+	err := to.DecryptStream(bytes.NewReader(ct), &out)
+	return out.Bytes(), err
+}
+
+func TestPadExchange(t *testing.T) {
+	pad, a, b := openPads(t, 100)
+	m1, m2, m3 := []byte("hello, Bob"), []byte("hello, Alice"), bytes.Repeat([]byte{0}, 30)
+	c1 := send(t, a, m1)
+	c2 := send(t, b, m2)
+	c3 := send(t, a, m3)
+	for _, c := range []struct {
+		to       *Pad
+		ct, want []byte
+	}{{b, c1, m1}, {a, c2, m2}, {b, c3, m3}, {b, c1, m1}} {
+		got, err := receive(c.to, c.ct)
+		if err != nil || !bytes.Equal(got, c.want) {
+			t.Fatalf("%q: got %q, %v", c.want, got, err)
+		}
+	}
+	if got := a.Remaining(); got != 100-int64(len(m1)+len(m3)+len(m2)) {
+		t.Fatalf("Remaining = %d", got)
+	}
+
+	// the ledger outlives the process: a reopened pad goes on after c3
+	a.Close()
+	a, err := OpenPad(pad, PartyA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	c4 := send(t, a, []byte("again"))
+	if got, err := receive(b, c4); err != nil || string(got) != "again" {
+		t.Fatalf("after reopening: %q, %v", got, err)
+	}
+}
+
+func TestPadReuse(t *testing.T) {
+	_, a, b := openPads(t, 64)
+	c1 := send(t, a, []byte("first"))
+	if _, err := receive(a, c1); !errors.Is(err, ErrPadReuse) {
+		t.Fatalf("own message: %v", err)
+	}
+	if _, err := receive(b, c1); err != nil {
+		t.Fatal(err)
+	}
+
+	var ct bytes.Buffer
+	if err := a.EncryptStream(bytes.NewReader(make([]byte, 60)), &ct, 60); !errors.Is(err, ErrPadExhausted) {
+		t.Fatalf("60 more bytes: %v", err)
+	}
+
+	tampered := append([]byte(nil), c1...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := receive(b, tampered); err != container.ErrAuth {
+		t.Fatalf("tampered: %v", err)
+	}
+
+	_, other, _ := openPads(t, 64)
+	if _, err := receive(other, c1); err != container.ErrWrongKey {
+		t.Fatalf("other pad: %v", err)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger")
+	l, err := OpenLedger(path, PartyB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	off, err := l.Reserve(10, 100)
+	if err != nil || off != 90 {
+		t.Fatalf("Reserve = %d, %v", off, err)
+	}
+	if err := l.Receive(0, 20, "aa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Receive(0, 20, "aa"); err != nil {
+		t.Fatalf("same message twice: %v", err)
+	}
+	if err := l.Receive(10, 5, "bb"); !errors.Is(err, ErrPadReuse) {
+		t.Fatalf("overlapping message: %v", err)
+	}
+	if err := l.CheckReceive(85, 10, ""); !errors.Is(err, ErrPadReuse) {
+		t.Fatalf("our own region: %v", err)
+	}
+	if got := l.Remaining(100); got != 70 {
+		t.Fatalf("Remaining = %d", got)
+	}
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := OpenLedger(path, PartyB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off, err := again.Reserve(70, 100); err != nil || off != 20 {
+		t.Fatalf("after reopening: %d, %v", off, err)
+	}
+	if _, err := again.Reserve(1, 100); !errors.Is(err, ErrPadExhausted) {
+		t.Fatalf("full pad: %v", err)
+	}
+	if _, err := OpenLedger(path, PartyA); err == nil {
+		t.Fatal("opened party B's ledger as party A")
+	}
+}
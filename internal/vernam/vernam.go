@@ -1,9 +1,18 @@
 package vernam
 
 import (
+	"bufio"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
 	"fmt"
-	"os"
-	"strings"
+	"information-defending/internal/container"
+	"information-defending/internal/crypto"
+	"information-defending/internal/kdf"
+	"information-defending/internal/stream"
+	"io"
+	"math/big"
 )
 
 func Encrypt(m, k byte) byte {
@@ -14,44 +23,212 @@ func Decrypt(m, k byte) byte {
 	return m ^ k
 }
 
-func EncryptFile(inputFile, outputFile string, k byte) error {
-	data, err := os.ReadFile(inputFile)
+func fingerprint(k byte) [8]byte {
+	return container.Fingerprint(big.NewInt(int64(k)))
+}
+
+// keyCheck is the key check value in the header, derived like the one in
+// seed from k, the secret behind the MAC key and the file nonce, so it
+// says no more about either than the MAC does.
+func keyCheck(k byte, secret, nonce []byte) ([8]byte, error) {
+	check, err := hkdf.Key(sha256.New, append([]byte{k}, secret...), nonce, "information-defending vernam check", 32)
 	if err != nil {
-		return err
+		return [8]byte{}, err
 	}
+	return container.Fingerprint(new(big.Int).SetBytes(check)), nil
+}
 
-	result := []byte{}
+// ErrNoMAC rejects a file whose MAC was stripped, for formats that always
+// carry one.
+var ErrNoMAC = errors.New("vernam: file is not authenticated")
 
-	for _, b := range data {
-		e := Encrypt(b, k)
-		result = append(result, e)
+// EncryptFile XORs every byte with the same k, which is a shift cipher
+// rather than a Vernam cipher; it stays for the demo7 files. The file is
+// tagged with HMAC-SHA-256 under a key stretched from password with the
+// default PBKDF2, not from k: a one-byte key would let a forger try all
+// 256 MAC keys. For real secrecy take a Pad or EncryptKeystreamFile.
+func EncryptFile(inputFile, outputFile string, k byte, password []byte) error {
+	p, err := kdf.New(kdf.PBKDF2, 0)
+	if err != nil {
+		return err
 	}
+	return EncryptFileMAC(inputFile, outputFile, k, password, p, container.MACHMACSHA256)
+}
+
+// EncryptFileMAC is EncryptFile with a choice of key derivation and MAC.
+func EncryptFileMAC(inputFile, outputFile string, k byte, password []byte, p kdf.Params, m container.MAC) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return EncryptStream(r, w, k, password, p, m)
+	})
+}
 
-	out := ""
-	for _, val := range result {
-		out += fmt.Sprintf("%d", val) + "\n"
+// DecryptFile checks the MAC with the key stretched from password. A nil
+// password reads the old formats instead, the decimal text of the first
+// EncryptFile and containers without a MAC, where a flipped bit decrypts
+// to a flipped bit. With a password these are ErrNoMAC, so stripping the
+// MAC does not get a file past it.
+func DecryptFile(inputFile, outputFile string, k byte, password []byte) error {
+	return stream.File(inputFile, outputFile, func(r io.Reader, w io.Writer) error {
+		return DecryptStream(r, w, k, password)
+	})
+}
+
+func xorBlock(b []byte, k byte) ([]byte, error) {
+	for i := range b {
+		b[i] ^= k
 	}
-	return os.WriteFile(outputFile, []byte(out), 0644)
+	return b, nil
 }
 
-func DecryptFile(inputFile, outputFile string, k byte) error {
-	data, err := os.ReadFile(inputFile)
+func EncryptStream(r io.Reader, w io.Writer, k byte, password []byte, p kdf.Params, m container.MAC) error {
+	if m == container.MACNone {
+		return errors.New("vernam: files need a MAC")
+	}
+	if len(password) == 0 {
+		return errors.New("vernam: files need a MAC password")
+	}
+	kdfParams, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	secret, err := p.Key(password, passwordSecretSize)
+	if err != nil {
+		return err
+	}
+	h := container.Header{
+		Algorithm: container.AlgVernam,
+		BlockSize: 1,
+		MAC:       m,
+		KDF:       kdfParams,
+		// salts the MAC key, so equal files under one password get unrelated tags
+		Params: make([]byte, NonceSize),
+	}
+	if _, err := rand.Read(h.Params); err != nil {
+		return err
+	}
+	if h.Fingerprint, err = keyCheck(k, secret, h.Params); err != nil {
+		return err
+	}
+	cw, err := newWriter(w, h, secret)
 	if err != nil {
 		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	var result []byte
-	var e byte
+	var n uint64
+	err = stream.Map(stream.Blocks(r, 4096), func(b []byte) ([]byte, error) {
+		return xorBlock(b, k)
+	}, func(b []byte) error {
+		n += uint64(len(b))
+		return cw.WriteBlock(b)
+	}, 0)
+	if err != nil {
+		return err
+	}
+	return cw.Close(n)
+}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+// DecryptStream is DecryptFile on streams. It leaves unverified output in
+// w until it returns nil, see container.Reader.
+func DecryptStream(r io.Reader, w io.Writer, k byte, password []byte) error {
+	br := bufio.NewReader(r)
+	if !container.Sniff(br) {
+		if password != nil {
+			return ErrNoMAC
+		}
+		return decryptText(br, w, k)
+	}
+	cr, err := container.NewReader(br)
+	if err != nil {
+		return err
+	}
+	h := cr.Header()
+	if h.MAC == container.MACNone {
+		if password != nil {
+			return ErrNoMAC
+		}
+		if err := h.Check(container.AlgVernam, fingerprint(k)); err != nil {
+			return err
 		}
-		fmt.Sscanf(line, "%d", &e)
-		m := Decrypt(e, k)
-		result = append(result, m)
+		return decryptBlocks(cr, w, k)
 	}
 
-	return os.WriteFile(outputFile, result, 0644)
+	if password == nil {
+		return errors.New("vernam: file is authenticated, the MAC password is needed")
+	}
+	if len(h.KDF) == 0 || len(h.Params) != NonceSize {
+		return errors.New("vernam: missing MAC key parameters")
+	}
+	var p kdf.Params
+	if err := p.UnmarshalBinary(h.KDF); err != nil {
+		return err
+	}
+	secret, err := p.Key(password, passwordSecretSize)
+	if err != nil {
+		return err
+	}
+	// a wrong k and a wrong password look the same here
+	fp, err := keyCheck(k, secret, h.Params)
+	if err != nil {
+		return err
+	}
+	if err := h.Check(container.AlgVernam, fp); err != nil {
+		return err
+	}
+	if err := authenticate(cr, secret); err != nil {
+		return err
+	}
+	return decryptBlocks(cr, w, k)
+}
+
+func decryptBlocks(cr *container.Reader, w io.Writer, k byte) error {
+	var n uint64
+	err := stream.Map(cr.ReadBlock, func(b []byte) ([]byte, error) {
+		return xorBlock(b, k)
+	}, stream.Writer(w, &n), 0)
+	if err != nil {
+		return err
+	}
+	if n != cr.Len() {
+		return container.ErrIntegrity
+	}
+	return nil
+}
+
+// newWriter starts the container, keyed with a MAC key derived from
+// secret and the header parameters.
+func newWriter(w io.Writer, h container.Header, secret []byte) (*container.Writer, error) {
+	key, err := container.DeriveMACKey(secret, h.Params)
+	if err != nil {
+		return nil, err
+	}
+	return container.NewMACWriter(w, h, key)
+}
+
+// authenticate is the reading side of newWriter; a stripped MAC is
+// ErrNoMAC.
+func authenticate(cr *container.Reader, secret []byte) error {
+	h := cr.Header()
+	if h.MAC == container.MACNone {
+		return ErrNoMAC
+	}
+	key, err := container.DeriveMACKey(secret, h.Params)
+	if err != nil {
+		return err
+	}
+	return cr.Authenticate(key)
+}
+
+// decryptText reads the old format: one decimal byte per line.
+func decryptText(r io.Reader, w io.Writer, k byte) error {
+	var n uint64
+	return stream.Map(stream.Lines(r), func(line []byte) ([]byte, error) {
+		e, err := crypto.ParseBigInt(string(line))
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsUint64() || e.Uint64() > 255 {
+			return nil, fmt.Errorf("vernam: not a byte: %s", line)
+		}
+		return []byte{Decrypt(byte(e.Uint64()), k)}, nil
+	}, stream.Writer(w, &n), 0)
 }
@@ -0,0 +1,121 @@
+package vernam
+
+import (
+	"bytes"
+	"information-defending/internal/container"
+	"information-defending/internal/kdf"
+	"testing"
+)
+
+// testKDF is cheap, so the tests do not spend seconds stretching passwords.
+func testKDF(t *testing.T) kdf.Params {
+	t.Helper()
+	p, err := kdf.New(kdf.PBKDF2, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func encrypt(t *testing.T, msg string, k byte, password string, m container.MAC) []byte {
+	t.Helper()
+	var ct bytes.Buffer
+	if err := EncryptStream(bytes.NewReader([]byte(msg)), &ct, k, []byte(password), testKDF(t), m); err != nil {
+		t.Fatal(err)
+	}
+	return ct.Bytes()
+}
+
+func decrypt(ct []byte, k byte, password []byte) (string, error) {
+	var out bytes.Buffer
+	err := DecryptStream(bytes.NewReader(ct), &out, k, password)
+	return out.String(), err
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, m := range []container.MAC{container.MACHMACSHA256, container.MACHMACStreebog, container.MACGOST28147} {
+		ct := encrypt(t, "message", 42, "mac password", m)
+		if got, err := decrypt(ct, 42, []byte("mac password")); err != nil || got != "message" {
+			t.Fatalf("%s: %q, %v", m, got, err)
+		}
+
+		// the last ciphertext byte, before the length and the tag
+		tampered := append([]byte(nil), ct...)
+		tampered[len(tampered)-8-m.Size()-1] ^= 1
+		if _, err := decrypt(tampered, 42, []byte("mac password")); err != container.ErrAuth {
+			t.Fatalf("%s, tampered: %v", m, err)
+		}
+	}
+	var ct bytes.Buffer
+	if err := EncryptStream(bytes.NewReader(nil), &ct, 42, nil, testKDF(t), container.MACHMACSHA256); err == nil {
+		t.Fatal("encrypted without a MAC password")
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	ct := encrypt(t, "message", 42, "mac password", container.MACHMACSHA256)
+	if _, err := decrypt(ct, 43, []byte("mac password")); err != container.ErrWrongKey {
+		t.Fatalf("wrong key: %v", err)
+	}
+	if _, err := decrypt(ct, 42, []byte("other password")); err != container.ErrWrongKey {
+		t.Fatalf("wrong password: %v", err)
+	}
+	if _, err := decrypt(ct, 42, nil); err == nil {
+		t.Fatal("authenticated file read without the password")
+	}
+}
+
+// The MAC key must not come from k alone: with the password unknown, none
+// of the 256 keys a one-byte k allows reproduces the tag.
+func TestMACKeyNotFromK(t *testing.T) {
+	ct := encrypt(t, "message", 42, "mac password", container.MACHMACSHA256)
+	for k := range 256 {
+		cr, err := container.NewReader(bytes.NewReader(ct))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := authenticate(cr, []byte{byte(k)}); err != nil {
+			t.Fatal(err)
+		}
+		// the tag is checked once the blocks run out
+		for err == nil {
+			_, err = cr.ReadBlock()
+		}
+		if err != container.ErrAuth {
+			t.Fatalf("k = %d: %v", k, err)
+		}
+	}
+}
+
+func TestLegacyFormats(t *testing.T) {
+	// the decimal text the first EncryptFile wrote
+	if got, err := decrypt([]byte("75\n72\n"), 42, nil); err != nil || got != "ab" {
+		t.Fatalf("text: %q, %v", got, err)
+	}
+	for _, bad := range []string{"x\n", "256\n", "-1\n"} {
+		if _, err := decrypt([]byte(bad), 42, nil); err == nil {
+			t.Fatalf("%q accepted", bad)
+		}
+	}
+
+	// a container without a MAC, as written before the MAC was added
+	var plain bytes.Buffer
+	cw, err := container.NewWriter(&plain, container.Header{Algorithm: container.AlgVernam, Fingerprint: fingerprint(42), BlockSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cw.WriteBlock([]byte{'a' ^ 42, 'b' ^ 42})
+	if err := cw.Close(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := decrypt(plain.Bytes(), 42, nil); err != nil || got != "ab" {
+		t.Fatalf("container without a MAC: %q, %v", got, err)
+	}
+
+	// with a password, stripping the MAC does not help
+	for _, old := range [][]byte{[]byte("75\n72\n"), plain.Bytes()} {
+		if _, err := decrypt(old, 42, []byte("mac password")); err != ErrNoMAC {
+			t.Fatalf("%.10q with a password: %v", old, err)
+		}
+	}
+}